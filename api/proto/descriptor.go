@@ -0,0 +1,32 @@
+package proto
+
+import (
+	_ "embed" // Встраивание набора дескрипторов
+	"fmt"
+
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+//go:generate go run ./protoset -out usdt.protoset
+
+// FileDescriptorSet скомпилированный набор дескрипторов usdt.proto и grpc.health.v1
+//
+//go:embed usdt.protoset
+var FileDescriptorSet []byte
+
+// DescriptorFiles возвращает реестр файлов, собранный из встроенного FileDescriptorSet
+func DescriptorFiles() (*protoregistry.Files, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := protov2.Unmarshal(FileDescriptorSet, &set); err != nil {
+		return nil, fmt.Errorf("unmarshal descriptor set: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("build descriptor registry: %w", err)
+	}
+	return files, nil
+}
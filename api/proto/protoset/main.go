@@ -0,0 +1,56 @@
+// Команда protoset собирает FileDescriptorSet для usdt.proto и сервиса здоровья.
+// Результат встраивается в бинарник и отдается через gRPC reflection.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	usdt "gRPC-USDT/api/proto"
+)
+
+func main() {
+	out := flag.String("out", "usdt.protoset", "output file")
+	flag.Parse()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	for _, fd := range []protoreflect.FileDescriptor{
+		usdt.File_usdt_proto,
+		grpc_health_v1.File_grpc_health_v1_health_proto,
+	} {
+		appendFile(set, fd, seen)
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		log.Fatalf("marshal descriptor set: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		log.Fatalf("write descriptor set: %v", err)
+	}
+}
+
+// appendFile добавляет файл вместе с зависимостями (аналог protoc --include_imports)
+func appendFile(set *descriptorpb.FileDescriptorSet, fd protoreflect.FileDescriptor, seen map[string]bool) {
+	if seen[fd.Path()] {
+		return
+	}
+	seen[fd.Path()] = true
+
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		appendFile(set, imports.Get(i).FileDescriptor, seen)
+	}
+
+	fdp := protodesc.ToFileDescriptorProto(fd)
+	fdp.SourceCodeInfo = nil
+	set.File = append(set.File, fdp)
+}
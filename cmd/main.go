@@ -2,12 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/alerts"
+	"gRPC-USDT/internal/anomaly"
+	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/lifecycle"
 	"gRPC-USDT/internal/optel"
+	"gRPC-USDT/internal/outbox"
+	"gRPC-USDT/internal/probes"
+	"gRPC-USDT/internal/reload"
+	"gRPC-USDT/internal/service"
+	"gRPC-USDT/internal/storage"
 	"gRPC-USDT/internal/utils"
-	"net/http"
 	"os"
 	"time"
 
@@ -17,7 +26,7 @@ import (
 
 func main() {
 
-	logger, err := utils.SetupLogger()
+	logger, logLevel, err := utils.SetupLogger()
 	if err != nil {
 		panic(err)
 	}
@@ -27,62 +36,261 @@ func main() {
 
 	// Инициализация конфигурации
 	flagSet := flag.NewFlagSet("gRPC-USDT", flag.ContinueOnError)
-	err = flagSet.Parse(os.Args[1:])
+	config.RegisterFlags(flagSet)
+	showConfig := flagSet.Bool("show-config", false, "Print effective configuration with value sources and exit")
+	showVersion := flagSet.Bool("version", false, "Print version and exit")
+	skipMigrations := flagSet.Bool("skip-migrations", false,
+		"Start serving without applying migrations (serve only); run them separately with the migrate command")
+	flagSet.Usage = func() {
+		out := flagSet.Output()
+		_, _ = fmt.Fprintf(out, "Usage: %s [serve|migrate <command>] [flags]\n", flagSet.Name())
+		_, _ = fmt.Fprintf(out, "       %s [flags] export [export flags]\n\n", flagSet.Name())
+		_, _ = fmt.Fprint(out, utils.MigrateUsage)
+		_, _ = fmt.Fprintln(out)
+		_, _ = fmt.Fprint(out, utils.ExportUsage)
+		_, _ = fmt.Fprintln(out)
+		_, _ = fmt.Fprintln(out, "Flags override environment variables, which override the configuration file.")
+		_, _ = fmt.Fprintln(out)
+		config.PrintDefaults(out, flagSet)
+	}
+	command, commandArgs, err := utils.ParseCommand(flagSet, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		logger.Error("Error parsing flags", zap.Error(err))
+		// Сообщение об ошибке и справка уже выведены
+		os.Exit(2)
 	}
 
-	// Загрузка конфигурации с учетом флагов
-	cfg := utils.LoadConfig(logger, flagSet)
+	if *showVersion {
+		fmt.Println(utils.VersionString())
+		return
+	}
 
-	// Инициализация трассировки
-	tp, err := optel.InitTracer(cfg.OTLPEndpoint, "usdt-service")
+	if *showConfig {
+		if err := utils.ShowConfig(os.Stdout, flagSet); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Загрузка конфигурации с учетом файла и флагов
+	cfg, err := utils.LoadConfig(logger, flagSet)
 	if err != nil {
-		logger.Fatal("Failed to initialize tracer", zap.Error(err))
-	} else {
-		logger.Info("Tracer initialized successfully")
-		color.Green("You can view traces at http://localhost:16686 (have to start Jaeger for that)")
+		logger.Fatal("Invalid configuration", zap.Error(err))
 	}
+	reload.LogLevel(logLevel)(*cfg)
 
-	defer func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			logger.Error("Error shutting down tracer provider", zap.Error(err))
-		} else {
-			logger.Info("Tracer provider shut down successfully")
+	// Миграции можно выполнять отдельно от выкатки сервиса
+	if command == utils.CommandMigrate {
+		if err := utils.RunMigrations(logger, cfg, commandArgs, os.Stdout); err != nil {
+			logger.Fatal("Migration command failed", zap.Error(err))
 		}
-	}()
+		return
+	}
+
+	// Выгрузка истории курсов в файл без запуска сервиса
+	if command == utils.CommandExport {
+		if err := utils.RunExport(cfg, commandArgs, os.Stdout); err != nil {
+			logger.Fatal("Export command failed", zap.Error(err))
+		}
+		return
+	}
+
+	// Менеджер упорядоченной остановки компонентов
+	manager := lifecycle.NewManager(logger)
+
+	// Сервис не готов, пока не применены миграции, не доступна БД и не получен первый курс
+	status := probes.New(probes.Migrations, probes.Database, probes.Exchange)
+
+	// Метрики и пробы поднимаются первыми, чтобы /livez отвечал во время старта
+	metricsServer := utils.StartMetricsServer(logger, cfg, status)
+	color.Green("You can view metrics at http://localhost:9091 (have to start Prometheus for that)")
+
+	// Инициализация трассировки (без OTLP_ENDPOINT спаны не экспортируются)
+	if cfg.OTLPEndpoint != "" {
+		tp, err := optel.InitTracer(cfg.OTLPEndpoint, "usdt-service")
+		if err != nil {
+			logger.Fatal("Failed to initialize tracer", zap.Error(err))
+		}
+		logger.Info("Tracer initialized successfully")
+		color.Green("You can view traces at http://localhost:16686 (have to start Jaeger for that)")
+		manager.Add(lifecycle.PhaseTelemetry, "tracer", tp.Shutdown)
+	} else {
+		logger.Warn("OTLP endpoint is not set, tracing is disabled")
+	}
 
 	store, err := utils.CreateStorage(cfg)
 	if err != nil {
-		logger.Fatal("Error creating store", zap.Error(err))
+		logger.Fatal("Error creating store", zap.Error(err), zap.String("backend", cfg.StorageBackend))
 	}
+	status.SetReady(probes.Database)
+	manager.Add(lifecycle.PhaseStorage, "storage", lifecycle.Closer(store.Close))
 
-	if err := utils.ApplyMigrations(store, cfg, logger); err != nil {
+	if *skipMigrations {
+		logger.Warn("Skipping migrations, schema must be migrated separately")
+	} else if err := utils.ApplyMigrations(store, cfg, logger); err != nil {
 		logger.Fatal("Error applying migrations", zap.Error(err))
 	}
+	status.SetReady(probes.Migrations)
+
+	// События о сохраненных курсах пишутся в outbox в одной транзакции с курсом
+	var relay *outbox.Relay
+	if cfg.OutboxEnabled {
+		relay, err = utils.CreateOutboxRelay(store, logger, cfg)
+		if err != nil {
+			logger.Fatal("Error creating outbox relay", zap.Error(err))
+		}
+	}
 
-	rateService := utils.CreateRateService(store, logger, cfg)
+	// При недоступности БД курсы пишутся в локальный журнал и воспроизводятся позже
+	var rateWriter storage.RateWriter = store
+	var spooling *storage.SpoolingStorage
+	if cfg.SpoolEnabled {
+		spooling, err = utils.CreateSpoolingStorage(store, logger, cfg)
+		if err != nil {
+			logger.Fatal("Error opening spool", zap.Error(err))
+		}
+		rateWriter = spooling
+	}
 
-	grpcServer, _, err := utils.StartServer(logger, cfg, rateService)
+	// Курсы дополнительно выгружаются во временную БД; ее отказ не влияет на сохранение
+	var fanOut *storage.FanOutStorage
+	if cfg.TSDBFormat != "" {
+		tsdbSink, err := utils.CreateTSDBSink(cfg)
+		if err != nil {
+			logger.Fatal("Error creating TSDB sink", zap.Error(err))
+		}
+		fanOut = storage.NewFanOutStorage(cfg.StorageBackend, rateWriter, logger,
+			storage.Sink{Name: "tsdb-" + cfg.TSDBFormat, Writer: tsdbSink, Timeout: cfg.TSDBTimeout})
+		rateWriter = fanOut
+	}
+
+	// При включенной отложенной записи курсы сохраняются пачками в фоне
+	var rateStorage service.RateStorage = rateWriter
+	var batchWriter *storage.BatchWriter
+	if cfg.WriteBehindEnabled {
+		batchWriter = utils.CreateBatchWriter(rateWriter, logger, cfg)
+		rateStorage = batchWriter
+	}
+
+	rateService := utils.CreateRateService(rateStorage, logger, cfg)
+	rateService.SetConverter(utils.CreateConverter(store, cfg))
+	rateService.SetTWAPCalculator(utils.CreateTWAPCalculator(store, cfg))
+	rateService.SetRateExporter(utils.CreateRateExporter(store))
+
+	// Некорректные курсы и выбросы попадают в карантин вместо основной таблицы
+	var detector *anomaly.Detector
+	if cfg.AnomalyEnabled {
+		detector = utils.CreateAnomalyDetector(store, logger, cfg)
+		rateService.SetRateValidator(detector)
+		rateService.AddRateObserver(detector)
+	}
+
+	// Оповещения проверяются на каждом сохраненном курсе
+	var grpcServices []utils.GRPCService
+	var alertEngine *alerts.Engine
+	if cfg.AlertsEnabled {
+		var alertServer *alerts.Server
+		alertEngine, alertServer = utils.CreateAlerts(store, logger, cfg)
+		rateService.AddRateObserver(alertEngine)
+		grpcServices = append(grpcServices, utils.GRPCService{Desc: &proto.AlertService_ServiceDesc, Impl: alertServer})
+	}
+
+	grpcServer, _, err := utils.StartServer(logger, cfg, rateService, status, grpcServices...)
 	if err != nil {
 		logger.Fatal("Failed to start server", zap.Error(err))
 	}
+	manager.Add(lifecycle.PhaseServers, "grpc", lifecycle.GRPCServer(grpcServer, cfg.GRPCDrainTimeout))
+	manager.Add(lifecycle.PhaseServers, "metrics", lifecycle.HTTPServer(metricsServer))
+
+	// Фоновые проверки готовности
+	workers := lifecycle.NewGroup()
+	workers.Go(func(ctx context.Context) {
+		utils.WatchDatabase(ctx, store, status, cfg)
+	})
+	// Учетные данные БД из хранилища секретов, смонтированных файлов или конфигурации проверяются периодически,
+	// при смене пул пересоздается
+	rotator, err := utils.CreateCredentialRotator(store, logger, cfg)
+	if err != nil {
+		logger.Fatal("Error creating credential rotator", zap.Error(err))
+	}
+	// Адрес биржи, интервал повтора, пороги выбросов, уровень логирования и учетные данные БД меняются без перезапуска
+	reloader := utils.CreateReloader(logger, logLevel, flagSet, cfg, rateService, detector, rotator)
+	workers.Go(func(ctx context.Context) {
+		utils.WarmUpExchange(ctx, logger, rateService, status, func() time.Duration {
+			return reloader.Current().ExchangeRetryInterval
+		})
+	})
+	manager.Add(lifecycle.PhaseWorkers, "readiness-checks", workers.Stop)
 
-	time.Sleep(1 * time.Second)
+	reloadWorker := lifecycle.NewGroup()
+	reloadWorker.Go(func(ctx context.Context) {
+		reloader.Run(ctx, config.FilePath(flagSet), cfg.ConfigWatchInterval)
+	})
+	manager.Add(lifecycle.PhaseWorkers, "config-reload", reloadWorker.Stop)
 
-	if err := utils.PerformHealthCheck(logger, cfg); err != nil {
-		logger.Fatal("Healthcheck failed", zap.Error(err))
+	if rotator != nil {
+		rotationWorker := lifecycle.NewGroup()
+		rotationWorker.Go(func(ctx context.Context) {
+			rotator.Run(ctx, cfg.SecretsRefreshInterval)
+		})
+		manager.Add(lifecycle.PhaseWorkers, "credential-rotation", rotationWorker.Stop)
 	}
 
-	// Экспозиция метрик Prometheus
-	go func() {
-		http.Handle("/metrics", metrics.ExposeMetrics())
-		logger.Info("Metrics endpoint started on port", zap.Int("port", cfg.MetricsPort))
-		color.Green("You can view metrics at http://localhost:9091 (have to start Prometheus for that)")
-		if err := http.ListenAndServe(fmt.Sprintf(":%d", cfg.MetricsPort), nil); err != nil {
-			logger.Error("Error starting metrics server", zap.Error(err))
-		}
-	}()
+	// Секции таблицы rates создаются заранее, устаревшие архивируются и отсоединяются
+	partitions, err := utils.CreatePartitionMaintainer(store, logger, cfg)
+	if err != nil {
+		logger.Fatal("Error creating partition maintainer", zap.Error(err))
+	}
+	if partitions != nil {
+		partitionWorker := lifecycle.NewGroup()
+		partitionWorker.Go(func(ctx context.Context) {
+			partitions.Run(ctx, cfg.PartitionsInterval)
+		})
+		manager.Add(lifecycle.PhaseWorkers, "partition-maintenance", partitionWorker.Stop)
+	}
+
+	if alertEngine != nil {
+		alertWorker := lifecycle.NewGroup()
+		alertWorker.Go(alertEngine.Run)
+		manager.Add(lifecycle.PhaseWorkers, "alerts", alertWorker.Stop)
+	}
+
+	if spooling != nil {
+		replayer := lifecycle.NewGroup()
+		replayer.Go(func(ctx context.Context) {
+			spooling.Replay(ctx, cfg.SpoolReplayInterval)
+		})
+		manager.Add(lifecycle.PhaseWorkers, "spool-replay", replayer.Stop)
+	}
+
+	// Буфер сбрасывается после остановки всех источников записи, но до закрытия БД
+	if batchWriter != nil {
+		manager.Add(lifecycle.PhaseWorkers, "write-behind", batchWriter.Close)
+	}
+	// Курсы из очередей временной БД отправляются после сброса буфера
+	if fanOut != nil {
+		manager.Add(lifecycle.PhaseWorkers, "storage-sinks", fanOut.Close)
+	}
+	// Ретранслятор останавливается последним из воркеров и публикует события последних курсов
+	if relay != nil {
+		relayWorker := lifecycle.NewGroup()
+		relayWorker.Go(func(ctx context.Context) {
+			relay.Run(ctx, cfg.OutboxPollInterval)
+		})
+		manager.Add(lifecycle.PhaseWorkers, "outbox-relay", func(ctx context.Context) error {
+			if err := relayWorker.Stop(ctx); err != nil {
+				return err
+			}
+			_, err := relay.Flush(ctx)
+			return errors.Join(err, relay.Close())
+		})
+	}
+	if spooling != nil {
+		manager.Add(lifecycle.PhaseStorage, "spool", lifecycle.Closer(spooling.Close))
+	}
 
-	utils.HandleSignals(logger, grpcServer, tp)
+	utils.HandleSignals(logger, manager, cfg.ShutdownTimeout)
 }
@@ -0,0 +1,197 @@
+package alerts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Engine проверяет правила оповещений на каждом сохраненном курсе.
+// Проверка выполняется в фоне, поэтому не добавляет задержку к запросу курса.
+//
+// Дедупликация: правило срабатывает только при переходе условия из ложного в истинное,
+// а повторное срабатывание возможно не раньше, чем через Cooldown после предыдущего.
+type Engine struct {
+	store    Store
+	notifier *Notifier
+	logger   *zap.Logger
+	queue    chan models.Rate
+
+	mu     sync.Mutex
+	rules  []models.AlertRule
+	stale  bool
+	active map[int64]bool // Было ли условие правила истинно на предыдущем курсе
+
+	deliveries sync.WaitGroup
+	now        func() time.Time
+}
+
+// NewEngine создает движок оповещений с очередью на queueSize курсов
+func NewEngine(store Store, notifier *Notifier, logger *zap.Logger, queueSize int) *Engine {
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	return &Engine{
+		store:    store,
+		notifier: notifier,
+		logger:   logger,
+		queue:    make(chan models.Rate, queueSize),
+		stale:    true,
+		active:   map[int64]bool{},
+		now:      time.Now,
+	}
+}
+
+// OnRate ставит курс в очередь проверки; при переполнении курс пропускается
+func (e *Engine) OnRate(_ context.Context, rate models.Rate) {
+	select {
+	case e.queue <- rate:
+	default:
+		metrics.AlertRatesDropped.Inc()
+	}
+}
+
+// Invalidate перечитывает правила перед следующей проверкой
+func (e *Engine) Invalidate() {
+	e.mu.Lock()
+	e.stale = true
+	e.mu.Unlock()
+}
+
+// Run проверяет курсы из очереди до отмены контекста и дожидается начатых доставок
+func (e *Engine) Run(ctx context.Context) {
+	defer e.deliveries.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case rate := <-e.queue:
+			e.Evaluate(ctx, rate)
+		}
+	}
+}
+
+// Evaluate проверяет все включенные правила на курсе и запускает доставку сработавших
+func (e *Engine) Evaluate(ctx context.Context, rate models.Rate) {
+	rules, err := e.loadRules(ctx)
+	if err != nil {
+		e.logger.Warn("Failed to load alert rules", zap.Error(err))
+		return
+	}
+
+	for _, rule := range rules {
+		value, ok := conditionValue(rule.Condition, rate)
+		if !ok || !rule.Enabled {
+			continue
+		}
+
+		triggered := conditionMet(rule.Condition, value, rule.Threshold)
+
+		e.mu.Lock()
+		wasActive := e.active[rule.ID]
+		e.active[rule.ID] = triggered
+		e.mu.Unlock()
+
+		if !triggered || wasActive {
+			continue
+		}
+		now := e.now()
+		if !rule.LastFiredAt.IsZero() && now.Sub(rule.LastFiredAt) < rule.Cooldown {
+			metrics.AlertsSuppressed.Inc()
+			continue
+		}
+
+		e.fire(ctx, rule, rate, value, now)
+	}
+}
+
+func (e *Engine) fire(ctx context.Context, rule models.AlertRule, rate models.Rate, value float64, now time.Time) {
+	if err := e.store.MarkAlertFired(ctx, rule.ID, now); err != nil {
+		e.logger.Warn("Failed to record alert firing", zap.Int64("rule_id", rule.ID), zap.Error(err))
+	}
+	e.mu.Lock()
+	for i := range e.rules {
+		if e.rules[i].ID == rule.ID {
+			e.rules[i].LastFiredAt = now
+		}
+	}
+	e.mu.Unlock()
+
+	metrics.AlertsFired.WithLabelValues(string(rule.Condition)).Inc()
+
+	alert := Alert{
+		ID:        alertID(rule.ID, rate),
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Condition: rule.Condition,
+		Threshold: rule.Threshold,
+		Value:     value,
+		Ask:       rate.Ask,
+		Bid:       rate.Bid,
+		Timestamp: rate.Time,
+	}
+
+	e.deliveries.Add(1)
+	go func() {
+		defer e.deliveries.Done()
+
+		// Доставка не прерывается остановкой проверки, ее ограничивают попытки и таймауты
+		if err := e.notifier.Send(context.WithoutCancel(ctx), rule.WebhookURL, alert); err != nil {
+			metrics.AlertDeliveries.WithLabelValues("error").Inc()
+			e.logger.Error("Alert delivery failed",
+				zap.Int64("rule_id", rule.ID), zap.String("alert_id", alert.ID), zap.Error(err))
+			return
+		}
+		metrics.AlertDeliveries.WithLabelValues("success").Inc()
+		e.logger.Info("Alert delivered", zap.Int64("rule_id", rule.ID), zap.String("alert_id", alert.ID))
+	}()
+}
+
+func (e *Engine) loadRules(ctx context.Context) ([]models.AlertRule, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stale {
+		rules, err := e.store.ListAlertRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.rules = rules
+		e.stale = false
+	}
+	return append([]models.AlertRule(nil), e.rules...), nil
+}
+
+// conditionValue возвращает проверяемую величину курса
+func conditionValue(condition models.AlertCondition, rate models.Rate) (float64, bool) {
+	switch condition {
+	case models.AlertPriceAbove, models.AlertPriceBelow:
+		return rate.Analytics().Mid, true
+	case models.AlertSpreadAbove:
+		return rate.Analytics().Spread, true
+	default:
+		return 0, false
+	}
+}
+
+func conditionMet(condition models.AlertCondition, value, threshold float64) bool {
+	if condition == models.AlertPriceBelow {
+		return value <= threshold
+	}
+	return value >= threshold
+}
+
+// alertID детерминирован для пары правило-курс, получатель отбрасывает по нему повторы
+func alertID(ruleID int64, rate models.Rate) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%g:%g", ruleID, rate.Time.UnixNano(), rate.Ask, rate.Bid)))
+	return hex.EncodeToString(sum[:16])
+}
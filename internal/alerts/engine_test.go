@@ -0,0 +1,137 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gRPC-USDT/internal/models"
+)
+
+// webhookStub принимает оповещения и складывает их в канал
+func webhookStub(t *testing.T) (string, <-chan Alert) {
+	alerts := make(chan Alert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+		alerts <- alert
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, alerts
+}
+
+func quote(ask, bid float64, ts time.Time) models.Rate {
+	return models.Rate{Ask: ask, Bid: bid, AskAmount: 1, BidAmount: 1, Time: ts}
+}
+
+func newTestEngine(t *testing.T, rules ...models.AlertRule) (*Engine, *MemoryStore) {
+	store := NewMemoryStore()
+	for _, rule := range rules {
+		_, err := store.CreateAlertRule(context.Background(), rule)
+		require.NoError(t, err)
+	}
+	engine := NewEngine(store, NewNotifier(NotifierConfig{MaxAttempts: 1}, nil), zap.NewNop(), 10)
+	return engine, store
+}
+
+func TestEngine_FiresOnCrossing(t *testing.T) {
+	url, delivered := webhookStub(t)
+	engine, store := newTestEngine(t, models.AlertRule{
+		Name: "btc above 100", Condition: models.AlertPriceAbove, Threshold: 100, WebhookURL: url, Enabled: true,
+	})
+
+	ctx := context.Background()
+	base := time.Now()
+	engine.Evaluate(ctx, quote(99.5, 99, base))                    // ниже порога
+	engine.Evaluate(ctx, quote(101, 100, base.Add(time.Second)))   // пересечение
+	engine.Evaluate(ctx, quote(102, 101, base.Add(2*time.Second))) // все еще выше - без повтора
+	engine.deliveries.Wait()
+
+	require.Len(t, delivered, 1)
+	alert := <-delivered
+	assert.Equal(t, "btc above 100", alert.RuleName)
+	assert.Equal(t, 100.5, alert.Value)
+
+	rule, err := store.GetAlertRule(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, rule.LastFiredAt.IsZero())
+}
+
+func TestEngine_Cooldown(t *testing.T) {
+	url, delivered := webhookStub(t)
+	engine, _ := newTestEngine(t, models.AlertRule{
+		Name: "wide spread", Condition: models.AlertSpreadAbove, Threshold: 5, WebhookURL: url,
+		Cooldown: time.Minute, Enabled: true,
+	})
+
+	now := time.Now()
+	engine.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	engine.Evaluate(ctx, quote(110, 100, now)) // спред 10 - срабатывание
+	engine.Evaluate(ctx, quote(101, 100, now)) // спред сузился
+	engine.Evaluate(ctx, quote(110, 100, now)) // новое пересечение, но окно тишины
+	engine.deliveries.Wait()
+	assert.Len(t, delivered, 1)
+
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(ctx, quote(101, 100, now))
+	engine.Evaluate(ctx, quote(110, 100, now))
+	engine.deliveries.Wait()
+	assert.Len(t, delivered, 2)
+}
+
+func TestEngine_InvalidateAndDisabledRules(t *testing.T) {
+	url, delivered := webhookStub(t)
+	engine, store := newTestEngine(t, models.AlertRule{
+		Name: "below 50", Condition: models.AlertPriceBelow, Threshold: 50, WebhookURL: url, Enabled: false,
+	})
+
+	ctx := context.Background()
+	engine.Evaluate(ctx, quote(41, 40, time.Now()))
+	engine.deliveries.Wait()
+	assert.Len(t, delivered, 0)
+
+	rule, err := store.GetAlertRule(ctx, 1)
+	require.NoError(t, err)
+	rule.Enabled = true
+	_, err = store.UpdateAlertRule(ctx, rule)
+	require.NoError(t, err)
+	engine.Invalidate()
+
+	engine.Evaluate(ctx, quote(41, 40, time.Now()))
+	engine.deliveries.Wait()
+	assert.Len(t, delivered, 1)
+}
+
+func TestEngine_Run(t *testing.T) {
+	url, delivered := webhookStub(t)
+	engine, _ := newTestEngine(t, models.AlertRule{
+		Name: "above", Condition: models.AlertPriceAbove, Threshold: 1, WebhookURL: url, Enabled: true,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		engine.Run(ctx)
+		close(done)
+	}()
+
+	engine.OnRate(ctx, quote(101, 100, time.Now()))
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("alert was not delivered")
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,142 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gRPC-USDT/internal/models"
+)
+
+// Заголовки доставки оповещения
+const (
+	HeaderAlertID   = "X-Alert-Id"            // Одинаков для всех попыток доставки одного оповещения
+	HeaderTimestamp = "X-Signature-Timestamp" // Unix-время подписи в секундах
+	HeaderSignature = "X-Signature-256"       // "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+)
+
+// Alert тело оповещения
+type Alert struct {
+	ID        string                `json:"id"`
+	RuleID    int64                 `json:"rule_id"`
+	RuleName  string                `json:"rule_name"`
+	Condition models.AlertCondition `json:"condition"`
+	Threshold float64               `json:"threshold"`
+	Value     float64               `json:"value"` // Значение, на котором сработало правило
+	Ask       float64               `json:"ask"`
+	Bid       float64               `json:"bid"`
+	Timestamp time.Time             `json:"timestamp"` // Время курса
+}
+
+// NotifierConfig настройки доставки оповещений
+type NotifierConfig struct {
+	Secret       string        // Ключ HMAC-подписи, пустой - без подписи
+	MaxAttempts  int           // Количество попыток доставки
+	RetryBackoff time.Duration // Пауза перед второй попыткой, дальше удваивается
+	Timeout      time.Duration // Таймаут одной попытки
+}
+
+// Notifier доставляет оповещения на webhook с подписью и повторами
+type Notifier struct {
+	cfg    NotifierConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewNotifier(cfg NotifierConfig, client *http.Client) *Notifier {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &Notifier{cfg: cfg, client: client, now: time.Now}
+}
+
+// errPermanent ошибка, после которой повторять доставку бессмысленно
+type errPermanent struct {
+	err error
+}
+
+func (e errPermanent) Error() string {
+	return e.err.Error()
+}
+
+// Send доставляет оповещение на url, повторяя попытки при сетевых ошибках, 429 и 5xx
+func (n *Notifier) Send(ctx context.Context, url string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.cfg.RetryBackoff
+	var lastErr error
+	for attempt := 1; attempt <= n.cfg.MaxAttempts; attempt++ {
+		lastErr = n.send(ctx, url, alert.ID, body)
+		var permanent errPermanent
+		if lastErr == nil || errors.As(lastErr, &permanent) {
+			return lastErr
+		}
+		if attempt == n.cfg.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("webhook delivery failed after %d attempts: %w", n.cfg.MaxAttempts, lastErr)
+}
+
+func (n *Notifier) send(ctx context.Context, url, alertID string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderAlertID, alertID)
+	if n.cfg.Secret != "" {
+		ts := strconv.FormatInt(n.now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderSignature, "sha256="+Sign(n.cfg.Secret, ts, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status: %s", resp.Status)
+	default:
+		return errPermanent{fmt.Errorf("webhook returned status: %s", resp.Status)}
+	}
+}
+
+// Sign вычисляет HMAC-SHA256 подпись тела оповещения; получатель проверяет ее тем же способом
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package alerts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_SignedDelivery(t *testing.T) {
+	var (
+		body      []byte
+		header    http.Header
+		signature string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		signature = "sha256=" + Sign("secret", r.Header.Get(HeaderTimestamp), body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(NotifierConfig{Secret: "secret", MaxAttempts: 1}, nil)
+	notifier.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	require.NoError(t, notifier.Send(context.Background(), server.URL, Alert{ID: "alert-1", RuleID: 1}))
+
+	assert.Equal(t, "alert-1", header.Get(HeaderAlertID))
+	assert.Equal(t, "1700000000", header.Get(HeaderTimestamp))
+	assert.Equal(t, signature, header.Get(HeaderSignature))
+	assert.Contains(t, string(body), `"rule_id":1`)
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	t.Run("retries server errors with the same alert id", func(t *testing.T) {
+		var calls atomic.Int32
+		ids := make(chan string, 3)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids <- r.Header.Get(HeaderAlertID)
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		notifier := NewNotifier(NotifierConfig{MaxAttempts: 3, RetryBackoff: time.Millisecond}, nil)
+		require.NoError(t, notifier.Send(context.Background(), server.URL, Alert{ID: "alert-1"}))
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, "alert-1", <-ids)
+		assert.Equal(t, "alert-1", <-ids)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		notifier := NewNotifier(NotifierConfig{MaxAttempts: 2, RetryBackoff: time.Millisecond}, nil)
+		err := notifier.Send(context.Background(), server.URL, Alert{ID: "alert-1"})
+		assert.ErrorContains(t, err, "after 2 attempts")
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		notifier := NewNotifier(NotifierConfig{MaxAttempts: 5, RetryBackoff: time.Millisecond}, nil)
+		assert.Error(t, notifier.Send(context.Background(), server.URL, Alert{ID: "alert-1"}))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
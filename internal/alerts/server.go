@@ -0,0 +1,176 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var conditionsToModel = map[proto.AlertCondition]models.AlertCondition{
+	proto.AlertCondition_ALERT_CONDITION_PRICE_ABOVE:  models.AlertPriceAbove,
+	proto.AlertCondition_ALERT_CONDITION_PRICE_BELOW:  models.AlertPriceBelow,
+	proto.AlertCondition_ALERT_CONDITION_SPREAD_ABOVE: models.AlertSpreadAbove,
+}
+
+// Server реализует gRPC AlertService поверх хранилища правил
+type Server struct {
+	proto.UnimplementedAlertServiceServer
+	store  Store
+	engine *Engine
+	logger *zap.Logger
+}
+
+// NewServer создает сервис правил; engine (может быть nil) перечитывает правила после изменений
+func NewServer(store Store, engine *Engine, logger *zap.Logger) *Server {
+	return &Server{store: store, engine: engine, logger: logger}
+}
+
+func (s *Server) CreateAlertRule(ctx context.Context, req *proto.CreateAlertRuleRequest) (*proto.AlertRule, error) {
+	rule, err := ruleFromProto(req.GetRule())
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.CreateAlertRule(ctx, rule)
+	if err != nil {
+		return nil, s.storeError(err)
+	}
+	s.invalidate()
+	s.logger.Info("Alert rule created", zap.Int64("id", created.ID), zap.String("name", created.Name))
+
+	return ruleToProto(created), nil
+}
+
+func (s *Server) GetAlertRule(ctx context.Context, req *proto.GetAlertRuleRequest) (*proto.AlertRule, error) {
+	rule, err := s.store.GetAlertRule(ctx, req.GetId())
+	if err != nil {
+		return nil, s.storeError(err)
+	}
+	return ruleToProto(rule), nil
+}
+
+func (s *Server) ListAlertRules(ctx context.Context, _ *proto.ListAlertRulesRequest) (*proto.ListAlertRulesResponse, error) {
+	rules, err := s.store.ListAlertRules(ctx)
+	if err != nil {
+		return nil, s.storeError(err)
+	}
+
+	resp := &proto.ListAlertRulesResponse{Rules: make([]*proto.AlertRule, 0, len(rules))}
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, ruleToProto(rule))
+	}
+	return resp, nil
+}
+
+func (s *Server) UpdateAlertRule(ctx context.Context, req *proto.UpdateAlertRuleRequest) (*proto.AlertRule, error) {
+	rule, err := ruleFromProto(req.GetRule())
+	if err != nil {
+		return nil, err
+	}
+	if rule.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "rule id is required")
+	}
+
+	updated, err := s.store.UpdateAlertRule(ctx, rule)
+	if err != nil {
+		return nil, s.storeError(err)
+	}
+	s.invalidate()
+	s.logger.Info("Alert rule updated", zap.Int64("id", updated.ID))
+
+	return ruleToProto(updated), nil
+}
+
+func (s *Server) DeleteAlertRule(ctx context.Context, req *proto.DeleteAlertRuleRequest) (*proto.DeleteAlertRuleResponse, error) {
+	if err := s.store.DeleteAlertRule(ctx, req.GetId()); err != nil {
+		return nil, s.storeError(err)
+	}
+	s.invalidate()
+	s.logger.Info("Alert rule deleted", zap.Int64("id", req.GetId()))
+
+	return &proto.DeleteAlertRuleResponse{}, nil
+}
+
+func (s *Server) invalidate() {
+	if s.engine != nil {
+		s.engine.Invalidate()
+	}
+}
+
+func (s *Server) storeError(err error) error {
+	if errors.Is(err, storage.ErrAlertRuleNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	s.logger.Error("Alert rule storage error", zap.Error(err))
+	return status.Error(codes.Internal, "alert rule storage error")
+}
+
+// ruleFromProto проверяет правило и собирает все ошибки в одно сообщение
+func ruleFromProto(p *proto.AlertRule) (models.AlertRule, error) {
+	if p == nil {
+		return models.AlertRule{}, status.Error(codes.InvalidArgument, "rule is required")
+	}
+
+	var problems []string
+	if strings.TrimSpace(p.GetName()) == "" {
+		problems = append(problems, "name is required")
+	}
+	condition, ok := conditionsToModel[p.GetCondition()]
+	if !ok {
+		problems = append(problems, "condition is required")
+	}
+	if p.GetThreshold() <= 0 {
+		problems = append(problems, "threshold must be positive")
+	}
+	if u, err := url.Parse(p.GetWebhookUrl()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, "webhook_url must be an absolute http(s) URL")
+	}
+	if p.GetCooldownSeconds() < 0 {
+		problems = append(problems, "cooldown_seconds must not be negative")
+	}
+	if len(problems) > 0 {
+		return models.AlertRule{}, status.Error(codes.InvalidArgument, strings.Join(problems, "; "))
+	}
+
+	return models.AlertRule{
+		ID:         p.GetId(),
+		Name:       strings.TrimSpace(p.GetName()),
+		Condition:  condition,
+		Threshold:  p.GetThreshold(),
+		WebhookURL: p.GetWebhookUrl(),
+		Cooldown:   time.Duration(p.GetCooldownSeconds()) * time.Second,
+		Enabled:    p.GetEnabled(),
+	}, nil
+}
+
+func ruleToProto(rule models.AlertRule) *proto.AlertRule {
+	p := &proto.AlertRule{
+		Id:              rule.ID,
+		Name:            rule.Name,
+		Threshold:       rule.Threshold,
+		WebhookUrl:      rule.WebhookURL,
+		CooldownSeconds: int64(rule.Cooldown / time.Second),
+		Enabled:         rule.Enabled,
+		CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       rule.UpdatedAt.Format(time.RFC3339),
+	}
+	for condition, model := range conditionsToModel {
+		if model == rule.Condition {
+			p.Condition = condition
+		}
+	}
+	if !rule.LastFiredAt.IsZero() {
+		p.LastFiredAt = rule.LastFiredAt.Format(time.RFC3339)
+	}
+	return p
+}
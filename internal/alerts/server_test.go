@@ -0,0 +1,97 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+)
+
+func validRule() *proto.AlertRule {
+	return &proto.AlertRule{
+		Name:            "btc above 100k",
+		Condition:       proto.AlertCondition_ALERT_CONDITION_PRICE_ABOVE,
+		Threshold:       100000,
+		WebhookUrl:      "https://hooks.example.com/alerts",
+		CooldownSeconds: 300,
+		Enabled:         true,
+	}
+}
+
+func TestServer_CRUD(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer(NewMemoryStore(), nil, zap.NewNop())
+
+	created, err := server.CreateAlertRule(ctx, &proto.CreateAlertRuleRequest{Rule: validRule()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Id)
+	assert.Equal(t, proto.AlertCondition_ALERT_CONDITION_PRICE_ABOVE, created.Condition)
+	assert.Equal(t, int64(300), created.CooldownSeconds)
+	assert.Empty(t, created.LastFiredAt)
+
+	got, err := server.GetAlertRule(ctx, &proto.GetAlertRuleRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, created.Name, got.Name)
+
+	update := validRule()
+	update.Id = created.Id
+	update.Condition = proto.AlertCondition_ALERT_CONDITION_SPREAD_ABOVE
+	update.Threshold = 25
+	updated, err := server.UpdateAlertRule(ctx, &proto.UpdateAlertRuleRequest{Rule: update})
+	require.NoError(t, err)
+	assert.Equal(t, proto.AlertCondition_ALERT_CONDITION_SPREAD_ABOVE, updated.Condition)
+	assert.Equal(t, 25.0, updated.Threshold)
+
+	list, err := server.ListAlertRules(ctx, &proto.ListAlertRulesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.Rules, 1)
+
+	_, err = server.DeleteAlertRule(ctx, &proto.DeleteAlertRuleRequest{Id: created.Id})
+	require.NoError(t, err)
+
+	_, err = server.GetAlertRule(ctx, &proto.GetAlertRuleRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = server.DeleteAlertRule(ctx, &proto.DeleteAlertRuleRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_Validation(t *testing.T) {
+	server := NewServer(NewMemoryStore(), nil, zap.NewNop())
+
+	_, err := server.CreateAlertRule(context.Background(), &proto.CreateAlertRuleRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.CreateAlertRule(context.Background(), &proto.CreateAlertRuleRequest{Rule: &proto.AlertRule{
+		WebhookUrl:      "ftp://example.com",
+		CooldownSeconds: -1,
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	msg := status.Convert(err).Message()
+	for _, problem := range []string{"name", "condition", "threshold", "webhook_url", "cooldown_seconds"} {
+		assert.Contains(t, msg, problem)
+	}
+
+	_, err = server.UpdateAlertRule(context.Background(), &proto.UpdateAlertRuleRequest{Rule: validRule()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_InvalidatesEngine(t *testing.T) {
+	store := NewMemoryStore()
+	engine := NewEngine(store, NewNotifier(NotifierConfig{}, nil), zap.NewNop(), 1)
+	_, err := engine.loadRules(context.Background())
+	require.NoError(t, err)
+
+	server := NewServer(store, engine, zap.NewNop())
+	_, err = server.CreateAlertRule(context.Background(), &proto.CreateAlertRuleRequest{Rule: validRule()})
+	require.NoError(t, err)
+
+	rules, err := engine.loadRules(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+}
@@ -0,0 +1,106 @@
+package alerts
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+// Store хранилище правил оповещений. В рабочем режиме это *storage.Storage (Postgres).
+type Store interface {
+	CreateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	GetAlertRule(ctx context.Context, id int64) (models.AlertRule, error)
+	ListAlertRules(ctx context.Context) ([]models.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id int64) error
+	MarkAlertFired(ctx context.Context, id int64, firedAt time.Time) error
+}
+
+// MemoryStore хранит правила в памяти процесса: для бэкендов без Postgres и тестов
+type MemoryStore struct {
+	mu     sync.Mutex
+	nextID int64
+	rules  map[int64]models.AlertRule
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rules: map[int64]models.AlertRule{}}
+}
+
+func (m *MemoryStore) CreateAlertRule(_ context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	now := time.Now().UTC()
+	rule.ID = m.nextID
+	rule.LastFiredAt = time.Time{}
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	m.rules[rule.ID] = rule
+	return rule, nil
+}
+
+func (m *MemoryStore) GetAlertRule(_ context.Context, id int64) (models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rule, ok := m.rules[id]
+	if !ok {
+		return models.AlertRule{}, storage.ErrAlertRuleNotFound
+	}
+	return rule, nil
+}
+
+func (m *MemoryStore) ListAlertRules(context.Context) ([]models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rules := make([]models.AlertRule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+func (m *MemoryStore) UpdateAlertRule(_ context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.rules[rule.ID]
+	if !ok {
+		return models.AlertRule{}, storage.ErrAlertRuleNotFound
+	}
+	rule.LastFiredAt = current.LastFiredAt
+	rule.CreatedAt = current.CreatedAt
+	rule.UpdatedAt = time.Now().UTC()
+	m.rules[rule.ID] = rule
+	return rule, nil
+}
+
+func (m *MemoryStore) DeleteAlertRule(_ context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.rules[id]; !ok {
+		return storage.ErrAlertRuleNotFound
+	}
+	delete(m.rules, id)
+	return nil
+}
+
+func (m *MemoryStore) MarkAlertFired(_ context.Context, id int64, firedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rule, ok := m.rules[id]; ok {
+		rule.LastFiredAt = firedAt
+		m.rules[id] = rule
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// Причины помещения курса в карантин
+const (
+	ReasonNonPositive = "non_positive" // Нулевая или отрицательная цена либо объем
+	ReasonCrossedBook = "crossed_book" // bid >= ask
+	ReasonJump        = "jump"         // Изменение средней цены больше MaxJumpPercent
+	ReasonZScore      = "zscore"       // Изменение средней цены выходит за ZScoreThreshold сигм
+)
+
+// History источник недавно сохраненных курсов
+type History interface {
+	GetRates(ctx context.Context, q storage.RateQuery) ([]models.Rate, error)
+}
+
+// Quarantine хранилище курсов, не прошедших проверку
+type Quarantine interface {
+	QuarantineRate(ctx context.Context, rate models.Rate, reason string) error
+}
+
+// Config настройки обнаружения выбросов
+type Config struct {
+	Window          time.Duration // Период недавних курсов, с которыми сравнивается новый
+	MaxJumpPercent  float64       // Допустимое изменение средней цены относительно предыдущего курса, 0 - не проверять
+	ZScoreThreshold float64       // Допустимое отклонение изменения цены в сигмах, 0 - не проверять
+	MinSamples      int           // Минимальное количество изменений цены для расчета z-оценки
+	Confirmations   int           // Через сколько выбросов подряд новый уровень цены считается настоящим, 0 - никогда
+}
+
+// Detector проверяет курсы перед сохранением.
+// Некорректные курсы (неположительные значения, пересеченный стакан) отклоняются всегда,
+// скачки средней цены определяются относительно курсов за последние Window.
+// Отклоненные курсы сохраняются в карантин вместо основной таблицы.
+type Detector struct {
+	history    History
+	quarantine Quarantine
+	logger     *zap.Logger
+	cfg        Config
+
+	mu      sync.Mutex
+	window  []models.Rate // Принятые курсы за последние Window по возрастанию времени
+	seeded  bool
+	outlier int // Количество скачков цены подряд
+}
+
+// NewDetector создает детектор выбросов
+func NewDetector(history History, quarantine Quarantine, logger *zap.Logger, cfg Config) *Detector {
+	return &Detector{
+		history:    history,
+		quarantine: quarantine,
+		logger:     logger,
+		cfg:        normalizeConfig(cfg),
+	}
+}
+
+// SetConfig заменяет настройки проверки; безопасен во время обработки курсов.
+// Накопленное окно сохраняется и обрезается по новому Window на следующем курсе.
+func (d *Detector) SetConfig(cfg Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cfg = normalizeConfig(cfg)
+}
+
+func normalizeConfig(cfg Config) Config {
+	if cfg.MinSamples < 2 {
+		cfg.MinSamples = 2
+	}
+	return cfg
+}
+
+// Validate проверяет курс и при обнаружении аномалии помещает его в карантин.
+// Возвращает причину или пустую строку, если курс можно сохранять.
+func (d *Detector) Validate(ctx context.Context, rate models.Rate) (string, error) {
+	reason := d.Check(ctx, rate)
+	if reason == "" {
+		return "", nil
+	}
+
+	metrics.RateAnomalies.WithLabelValues(reason).Inc()
+	d.logger.Warn("Rate quarantined",
+		zap.String("reason", reason),
+		zap.Float64("ask", rate.Ask),
+		zap.Float64("bid", rate.Bid),
+		zap.Time("timestamp", rate.Time))
+
+	if err := d.quarantine.QuarantineRate(ctx, rate, reason); err != nil {
+		metrics.RatesQuarantined.WithLabelValues("error").Inc()
+		return reason, fmt.Errorf("quarantine rate: %w", err)
+	}
+	metrics.RatesQuarantined.WithLabelValues("ok").Inc()
+	return reason, nil
+}
+
+// Check возвращает причину аномалии или пустую строку
+func (d *Detector) Check(ctx context.Context, rate models.Rate) string {
+	if rate.Ask <= 0 || rate.Bid <= 0 || rate.AskAmount <= 0 || rate.BidAmount <= 0 {
+		return ReasonNonPositive
+	}
+	if rate.Bid >= rate.Ask {
+		return ReasonCrossedBook
+	}
+
+	// Окно из хранилища загружается без блокировки: медленная база не должна задерживать другие проверки
+	seed, loaded := d.loadSeed(ctx, rate.Time)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if loaded && !d.seeded {
+		for _, r := range seed {
+			d.add(r)
+		}
+		d.seeded = true
+	}
+	d.prune(rate.Time)
+
+	reason := d.jump(rate.Analytics().Mid)
+	if reason == "" {
+		d.outlier = 0
+		return ""
+	}
+
+	// Устойчивый сдвиг цены: после нескольких выбросов подряд новый уровень становится базой
+	d.outlier++
+	if d.cfg.Confirmations > 0 && d.outlier >= d.cfg.Confirmations {
+		d.logger.Info("Price level shift confirmed, resetting anomaly baseline",
+			zap.Int("outliers", d.outlier), zap.Float64("mid", rate.Analytics().Mid))
+		d.window = nil
+		d.outlier = 0
+		metrics.AnomalyWindowSize.Set(0)
+		return ""
+	}
+	return reason
+}
+
+// OnRate добавляет сохраненный курс в окно сравнения
+func (d *Detector) OnRate(_ context.Context, rate models.Rate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Окно уже строится по сохраняемым курсам, загрузка из хранилища больше не нужна
+	d.seeded = true
+	d.add(rate)
+	d.prune(rate.Time)
+}
+
+// jump проверяет изменение средней цены относительно окна
+func (d *Detector) jump(mid float64) string {
+	if len(d.window) == 0 {
+		return ""
+	}
+
+	last := d.window[len(d.window)-1].Analytics().Mid
+	change := mid/last - 1
+	if d.cfg.MaxJumpPercent > 0 && math.Abs(change)*100 > d.cfg.MaxJumpPercent {
+		return ReasonJump
+	}
+
+	if d.cfg.ZScoreThreshold <= 0 || len(d.window)-1 < d.cfg.MinSamples {
+		return ""
+	}
+	var sum, sumSq float64
+	n := float64(len(d.window) - 1)
+	for i := 1; i < len(d.window); i++ {
+		r := d.window[i].Analytics().Mid/d.window[i-1].Analytics().Mid - 1
+		sum += r
+		sumSq += r * r
+	}
+	mean := sum / n
+	std := math.Sqrt(math.Max(sumSq/n-mean*mean, 0))
+	if std > 0 && math.Abs(change-mean)/std > d.cfg.ZScoreThreshold {
+		return ReasonZScore
+	}
+	return ""
+}
+
+// loadSeed загружает окно из хранилища, пока оно не заполнено сохраняемыми курсами.
+// При ошибке проверка скачков пропускается, загрузка повторится на следующем курсе.
+func (d *Detector) loadSeed(ctx context.Context, now time.Time) ([]models.Rate, bool) {
+	d.mu.Lock()
+	seeded, window := d.seeded, d.cfg.Window
+	d.mu.Unlock()
+	if seeded || window <= 0 {
+		return nil, false
+	}
+
+	rates, err := d.history.GetRates(ctx, storage.RateQuery{From: now.Add(-window)})
+	if err != nil {
+		d.logger.Warn("Failed to load recent rates for anomaly detection", zap.Error(err))
+		return nil, false
+	}
+	return rates, true
+}
+
+// add вставляет курс с сохранением порядка по времени
+func (d *Detector) add(rate models.Rate) {
+	i := len(d.window)
+	for i > 0 && d.window[i-1].Time.After(rate.Time) {
+		i--
+	}
+	d.window = append(d.window, models.Rate{})
+	copy(d.window[i+1:], d.window[i:])
+	d.window[i] = rate
+}
+
+// prune удаляет из окна курсы старше Window
+func (d *Detector) prune(now time.Time) {
+	cutoff := now.Add(-d.cfg.Window)
+	i := 0
+	for i < len(d.window) && d.window[i].Time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		d.window = append(d.window[:0:0], d.window[i:]...)
+	}
+	metrics.AnomalyWindowSize.Set(float64(len(d.window)))
+}
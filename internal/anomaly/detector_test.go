@@ -0,0 +1,229 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+// stubHistory отдает заранее заданные курсы
+type stubHistory struct {
+	rates []models.Rate
+	err   error
+	calls int
+}
+
+func (h *stubHistory) GetRates(_ context.Context, _ storage.RateQuery) ([]models.Rate, error) {
+	h.calls++
+	return h.rates, h.err
+}
+
+// recordingQuarantine запоминает отклоненные курсы
+type recordingQuarantine struct {
+	reasons []string
+	err     error
+}
+
+func (q *recordingQuarantine) QuarantineRate(_ context.Context, _ models.Rate, reason string) error {
+	q.reasons = append(q.reasons, reason)
+	return q.err
+}
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// quote строит курс со спредом 2 вокруг средней цены mid
+func quote(mid float64, offset time.Duration) models.Rate {
+	return models.Rate{Ask: mid + 1, Bid: mid - 1, AskAmount: 1, BidAmount: 1, Time: base.Add(offset)}
+}
+
+func TestDetector_InvalidQuotes(t *testing.T) {
+	d := NewDetector(&stubHistory{}, &recordingQuarantine{}, zap.NewNop(), Config{Window: time.Minute})
+
+	tests := []struct {
+		name string
+		rate models.Rate
+		want string
+	}{
+		{"valid", models.Rate{Ask: 101, Bid: 100, AskAmount: 1, BidAmount: 1, Time: base}, ""},
+		{"zero ask", models.Rate{Ask: 0, Bid: 100, AskAmount: 1, BidAmount: 1, Time: base}, ReasonNonPositive},
+		{"negative bid", models.Rate{Ask: 101, Bid: -1, AskAmount: 1, BidAmount: 1, Time: base}, ReasonNonPositive},
+		{"zero volume", models.Rate{Ask: 101, Bid: 100, AskAmount: 0, BidAmount: 1, Time: base}, ReasonNonPositive},
+		{"crossed book", models.Rate{Ask: 100, Bid: 101, AskAmount: 1, BidAmount: 1, Time: base}, ReasonCrossedBook},
+		{"locked book", models.Rate{Ask: 100, Bid: 100, AskAmount: 1, BidAmount: 1, Time: base}, ReasonCrossedBook},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, d.Check(context.Background(), tt.rate))
+		})
+	}
+}
+
+func TestDetector_Jump(t *testing.T) {
+	history := &stubHistory{rates: []models.Rate{quote(100, -30*time.Second)}}
+	d := NewDetector(history, &recordingQuarantine{}, zap.NewNop(), Config{Window: time.Minute, MaxJumpPercent: 5})
+	ctx := context.Background()
+
+	assert.Equal(t, "", d.Check(ctx, quote(104, 0)))
+	assert.Equal(t, ReasonJump, d.Check(ctx, quote(110, 0)))
+	assert.Equal(t, ReasonJump, d.Check(ctx, quote(90, 0)))
+	// Окно загружается из хранилища один раз
+	assert.Equal(t, 1, history.calls)
+
+	// Курс старше окна не участвует в сравнении
+	assert.Equal(t, "", d.Check(ctx, quote(110, 2*time.Minute)))
+}
+
+func TestDetector_ZScore(t *testing.T) {
+	d := NewDetector(&stubHistory{}, &recordingQuarantine{}, zap.NewNop(),
+		Config{Window: time.Hour, ZScoreThreshold: 4, MinSamples: 10})
+	ctx := context.Background()
+
+	// Цена колеблется на ±0.1%
+	mid := 100.0
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			mid *= 1.001
+		} else {
+			mid /= 1.001
+		}
+		d.OnRate(ctx, quote(mid, time.Duration(i)*time.Second))
+	}
+
+	assert.Equal(t, "", d.Check(ctx, quote(mid*1.002, 20*time.Second)))
+	assert.Equal(t, ReasonZScore, d.Check(ctx, quote(mid*1.01, 20*time.Second)))
+}
+
+func TestDetector_ZScoreNeedsSamples(t *testing.T) {
+	d := NewDetector(&stubHistory{}, &recordingQuarantine{}, zap.NewNop(),
+		Config{Window: time.Hour, ZScoreThreshold: 1, MinSamples: 10})
+	ctx := context.Background()
+
+	d.OnRate(ctx, quote(100, 0))
+	d.OnRate(ctx, quote(100.1, time.Second))
+	d.OnRate(ctx, quote(100, 2*time.Second))
+
+	assert.Equal(t, "", d.Check(ctx, quote(101, 3*time.Second)))
+}
+
+func TestDetector_LevelShiftConfirmed(t *testing.T) {
+	d := NewDetector(&stubHistory{rates: []models.Rate{quote(100, 0)}}, &recordingQuarantine{}, zap.NewNop(),
+		Config{Window: time.Hour, MaxJumpPercent: 5, Confirmations: 3})
+	ctx := context.Background()
+
+	assert.Equal(t, ReasonJump, d.Check(ctx, quote(120, time.Second)))
+	assert.Equal(t, ReasonJump, d.Check(ctx, quote(120, 2*time.Second)))
+	// Третий выброс подряд принимается как новый уровень
+	assert.Equal(t, "", d.Check(ctx, quote(120, 3*time.Second)))
+	d.OnRate(ctx, quote(120, 3*time.Second))
+	assert.Equal(t, "", d.Check(ctx, quote(121, 4*time.Second)))
+}
+
+func TestDetector_SetConfig(t *testing.T) {
+	d := NewDetector(&stubHistory{rates: []models.Rate{quote(100, 0)}}, &recordingQuarantine{}, zap.NewNop(),
+		Config{Window: time.Hour, MaxJumpPercent: 5})
+	ctx := context.Background()
+
+	assert.Equal(t, "", d.Check(ctx, quote(103, time.Second)))
+
+	d.SetConfig(Config{Window: time.Hour, MaxJumpPercent: 2})
+	assert.Equal(t, ReasonJump, d.Check(ctx, quote(103, time.Second)))
+
+	// Более короткое окно отбрасывает старые курсы
+	d.SetConfig(Config{Window: time.Millisecond, MaxJumpPercent: 2})
+	assert.Equal(t, "", d.Check(ctx, quote(103, time.Second)))
+}
+
+func TestDetector_HistoryError(t *testing.T) {
+	history := &stubHistory{err: errors.New("db down")}
+	d := NewDetector(history, &recordingQuarantine{}, zap.NewNop(), Config{Window: time.Minute, MaxJumpPercent: 5})
+	ctx := context.Background()
+
+	// Без окна проверяются только некорректные котировки, загрузка повторяется
+	assert.Equal(t, "", d.Check(ctx, quote(100, 0)))
+	assert.Equal(t, ReasonCrossedBook, d.Check(ctx, models.Rate{Ask: 1, Bid: 2, AskAmount: 1, BidAmount: 1}))
+	assert.Equal(t, "", d.Check(ctx, quote(200, time.Second)))
+	assert.Equal(t, 2, history.calls)
+}
+
+// blockingHistory отдает курсы только после закрытия release
+type blockingHistory struct {
+	started chan struct{}
+	release chan struct{}
+	rates   []models.Rate
+}
+
+func (h *blockingHistory) GetRates(ctx context.Context, _ storage.RateQuery) ([]models.Rate, error) {
+	close(h.started)
+	select {
+	case <-h.release:
+		return h.rates, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestDetector_SeedDoesNotHoldLock(t *testing.T) {
+	history := &blockingHistory{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		rates:   []models.Rate{quote(100, -30*time.Second)},
+	}
+	d := NewDetector(history, &recordingQuarantine{}, zap.NewNop(), Config{Window: time.Minute, MaxJumpPercent: 5})
+	ctx := context.Background()
+
+	done := make(chan string)
+	go func() { done <- d.Check(ctx, quote(110, 0)) }()
+	<-history.started
+
+	// Пока загрузка висит, сохранение курса не ждет базу
+	saved := make(chan struct{})
+	go func() {
+		d.OnRate(ctx, quote(109, -time.Second))
+		close(saved)
+	}()
+	select {
+	case <-saved:
+	case <-time.After(time.Second):
+		t.Fatal("OnRate blocked by history load")
+	}
+
+	// Окно уже заполнено сохраненным курсом, загруженные данные не добавляются повторно
+	close(history.release)
+	assert.Equal(t, "", <-done)
+}
+
+func TestDetector_Validate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("quarantines anomalies", func(t *testing.T) {
+		quarantine := &recordingQuarantine{}
+		d := NewDetector(&stubHistory{}, quarantine, zap.NewNop(), Config{Window: time.Minute})
+
+		reason, err := d.Validate(ctx, quote(100, 0))
+		require.NoError(t, err)
+		assert.Empty(t, reason)
+
+		reason, err = d.Validate(ctx, models.Rate{Ask: 0, Bid: 0})
+		require.NoError(t, err)
+		assert.Equal(t, ReasonNonPositive, reason)
+		assert.Equal(t, []string{ReasonNonPositive}, quarantine.reasons)
+	})
+
+	t.Run("quarantine error", func(t *testing.T) {
+		quarantine := &recordingQuarantine{err: errors.New("db down")}
+		d := NewDetector(&stubHistory{}, quarantine, zap.NewNop(), Config{Window: time.Minute})
+
+		reason, err := d.Validate(ctx, models.Rate{Ask: 0, Bid: 0})
+		assert.Equal(t, ReasonNonPositive, reason)
+		assert.ErrorContains(t, err, "db down")
+	})
+}
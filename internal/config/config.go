@@ -1,14 +1,44 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
 
+// Поддерживаемые бэкенды хранилища курсов
+const (
+	StorageBackendPostgres = "postgres"
+	StorageBackendSQLite   = "sqlite"
+	StorageBackendMemory   = "memory"
+)
+
+// Источники значений в порядке возрастания приоритета
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Флаг и переменная окружения с путем к файлу конфигурации
+const (
+	ConfigFileFlag = "config"
+	ConfigFileEnv  = "CONFIG_FILE"
+)
+
+// FileEnvSuffix суффикс переменной окружения, содержащей путь к файлу со значением: DB_PASSWORD_FILE
+const FileEnvSuffix = "_FILE"
+
+// Поддерживаемые источники секретов
+const SecretsProviderVault = "vault"
+
 type Config struct {
 	Env            string
 	DBUser         string
@@ -17,101 +47,260 @@ type Config struct {
 	DBPort         int
 	DBName         string
 	MigrationsPath string
+
+	// Полная строка подключения DATABASE_URL заменяет отдельные параметры DB_*
+	DatabaseURL       string
+	DBSSLMode         string
+	DBSSLRootCert     string
+	DBSSLCert         string
+	DBSSLKey          string
+	DBSearchPath      string
+	DBApplicationName string
+
+	// Секционирование таблицы rates по месяцам при применении миграции 0006
+	DBRatesPartitioning bool
+
+	StorageBackend string
+	SQLitePath     string
+	MemoryMaxRates int
+
+	DBMaxConns               int
+	DBMinConns               int
+	DBMaxConnLifetime        time.Duration
+	DBMaxConnIdleTime        time.Duration
+	DBStatementCacheCapacity int
+	DBQueryExecMode          string
+
 	GRPCPort       int
+	GRPCReflection bool
 	BinanceAPIURL  string
 	MetricsPort    int
 	OTLPEndpoint   string
+
+	ShutdownTimeout  time.Duration
+	GRPCDrainTimeout time.Duration
+
+	ExchangeRetryInterval time.Duration
+	DBCheckInterval       time.Duration
+
+	WriteBehindEnabled  bool
+	WriteBatchSize      int
+	WriteFlushInterval  time.Duration
+	WriteQueueSize      int
+	WriteEnqueueTimeout time.Duration
+
+	SpoolEnabled        bool
+	SpoolDir            string
+	SpoolMaxBytes       int
+	SpoolReplayInterval time.Duration
+
+	TSDBFormat      string
+	TSDBURL         string
+	TSDBToken       string
+	TSDBMeasurement string
+	TSDBTags        string
+	TSDBTimeout     time.Duration
+
+	OutboxEnabled      bool
+	OutboxPublisher    string
+	OutboxTarget       string
+	OutboxTopic        string
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+
+	AlertsEnabled       bool
+	AlertWebhookSecret  string
+	AlertMaxAttempts    int
+	AlertRetryBackoff   time.Duration
+	AlertWebhookTimeout time.Duration
+	AlertQueueSize      int
+
+	// Проверка курсов на аномалии перед сохранением
+	AnomalyEnabled        bool
+	AnomalyWindow         time.Duration
+	AnomalyMaxJumpPercent float64
+	AnomalyZScore         float64
+	AnomalyMinSamples     int
+	AnomalyConfirmations  int
+
+	// Отслеживаемая пара: цена BaseAsset в единицах QuoteAsset
+	BaseAsset  string
+	QuoteAsset string
+
+	// Расчет TWAP по сохраненным курсам
+	TWAPMaxGap    time.Duration
+	TWAPMaxWindow time.Duration
+
+	// Обслуживание месячных секций таблицы rates
+	PartitionsEnabled         bool
+	PartitionsInterval        time.Duration
+	PartitionsAhead           int
+	PartitionsRetentionMonths int
+	PartitionsRetentionAction string
+	PartitionsArchiveDir      string
+	PartitionsArchiveFormat   string
+	PartitionsDryRun          bool
+
+	// Уровень логирования и перезагрузка конфигурации без перезапуска
+	LogLevel            string
+	ConfigWatchInterval time.Duration
+
+	// Учетные данные БД из внешнего хранилища секретов
+	SecretsProvider        string
+	VaultAddr              string
+	VaultToken             string
+	VaultMount             string
+	VaultDBSecretPath      string
+	SecretsRefreshInterval time.Duration
 }
 
-func LoadConfig(logger *zap.Logger, flags *flag.FlagSet) Config {
+// LoadConfig загружает конфигурацию и записывает в лог итоговые значения.
+// Ошибки чтения файла и проверки возвращаются вызывающему.
+func LoadConfig(logger *zap.Logger, flags *flag.FlagSet) (Config, error) {
 	if err := godotenv.Load(); err != nil {
 		logger.Warn("No .env file found")
 	}
 
-	cfg := Config{
-		Env:            getValue(flags, "env", "ENV", "local"),
-		DBUser:         getValue(flags, "db-user", "DB_USER", ""),
-		DBPassword:     getValue(flags, "db-password", "DB_PASSWORD", ""),
-		DBHost:         getValue(flags, "db-host", "DB_HOST", "localhost"),
-		DBPort:         getIntValue(flags, "db-port", "DB_PORT", 5432),
-		DBName:         getValue(flags, "db-name", "DB_NAME", ""),
-		MigrationsPath: getValue(flags, "migrations-path", "MIGRATIONS_PATH", "../internal/storage/migrations"),
-		GRPCPort:       getIntValue(flags, "grpc-port", "GRPC_PORT", 50051),
-		BinanceAPIURL:  getValue(flags, "binance-api-url", "BINANCE_API_URL", ""),
-		MetricsPort:    getIntValue(flags, "metrics-port", "METRICS_PORT", 2112),
-		OTLPEndpoint:   getValue(flags, "otlp-endpoint", "OTLP_ENDPOINT", ""),
+	cfg, effective, err := Load(flags)
+	if err != nil {
+		return cfg, err
 	}
 
-	validateConfig(logger, cfg)
-	logConfig(logger, cfg)
-	return cfg
+	logConfig(logger, effective)
+	return cfg, nil
 }
 
-func getValue(flags *flag.FlagSet, flagName, envName, defaultValue string) string {
-	// 1. Проверяем флаг (только если он был явно установлен)
-	if flags != nil {
-		if f := flags.Lookup(flagName); f != nil {
-			// Если флаг был изменен (значение отличается от дефолтного)
-			if f.Value.String() != f.DefValue {
-				return f.Value.String()
+// Load собирает конфигурацию из значений по умолчанию, файла, переменных окружения и флагов
+// (каждый следующий источник важнее предыдущего) и проверяет ее.
+// Некорректные значения файла, переменных окружения и флагов и ошибки проверки собираются
+// в *ValidationError, чтобы опечатка не заменялась молча значением по умолчанию.
+func Load(flags *flag.FlagSet) (Config, *Effective, error) {
+	var cfg Config
+	schema := fields(&cfg)
+
+	effective := &Effective{Settings: make([]Setting, len(schema))}
+	for i, f := range schema {
+		effective.Settings[i] = Setting{Key: f.Key, Env: f.Env, Flag: f.Flag, Secret: f.Secret, Source: SourceDefault}
+	}
+
+	var problems []string
+
+	// 1. Файл конфигурации
+	if path := FilePath(flags); path != "" {
+		effective.File = path
+		values, err := readFile(path)
+		if err != nil {
+			return cfg, effective, err
+		}
+		byKey := make(map[string]int, len(schema))
+		for i, f := range schema {
+			byKey[f.Key] = i
+		}
+		for _, key := range sortedKeys(values) {
+			i, ok := byKey[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown key", key))
+				continue
+			}
+			if err := schema[i].value.Set(values[key]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid %s value %q", key, schema[i].Type, values[key]))
+				continue
 			}
+			effective.Settings[i].Source, effective.Settings[i].Origin = SourceFile, path
 		}
 	}
 
-	// 2. Проверяем переменную окружения
-	if value := os.Getenv(envName); value != "" {
-		return value
+	// 2. Переменные окружения, в том числе NAME_FILE с путем к файлу значения
+	for i, f := range schema {
+		raw, origin, err := lookupEnv(f.Env)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		if err := f.value.Set(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid %s value %q", origin, f.Type, redact(f.Secret, raw)))
+			continue
+		}
+		effective.Settings[i].Source, effective.Settings[i].Origin = SourceEnv, origin
 	}
 
-	// 3. Возвращаем значение по умолчанию
-	return defaultValue
-}
-
-func getIntValue(flags *flag.FlagSet, flagName, envName string, defaultValue int) int {
-	// 1. Проверяем флаг (только если он был явно установлен)
-	if flags != nil {
-		if f := flags.Lookup(flagName); f != nil {
-			// Если флаг был изменен (значение отличается от дефолтного)
-			if f.Value.String() != f.DefValue {
-				if intVal, err := strconv.Atoi(f.Value.String()); err == nil {
-					return intVal
-				}
-			}
+	// 3. Явно заданные флаги
+	explicit := setFlags(flags)
+	for i, f := range schema {
+		fl := lookupFlag(flags, f.Flag)
+		if fl == nil || !explicit[f.Flag] {
+			continue
+		}
+		raw := fl.Value.String()
+		if err := f.value.Set(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("-%s: invalid %s value %q", f.Flag, f.Type, redact(f.Secret, raw)))
+			continue
 		}
+		effective.Settings[i].Source, effective.Settings[i].Origin = SourceFlag, "-"+f.Flag
 	}
 
-	// 2. Проверяем переменную окружения
-	if value := os.Getenv(envName); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
+	for i, f := range schema {
+		effective.Settings[i].Value = f.value.String()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			problems = append(problems, verr.Problems...)
 		}
 	}
+	if len(problems) > 0 {
+		return cfg, effective, &ValidationError{Problems: problems}
+	}
+	return cfg, effective, nil
+}
+
+// lookupEnv возвращает значение переменной name или содержимое файла из name_FILE
+// (секреты Docker и Kubernetes монтируются файлами) и имя использованной переменной
+func lookupEnv(name string) (value, origin string, err error) {
+	value = os.Getenv(name)
+	fileVar := name + FileEnvSuffix
+	path := os.Getenv(fileVar)
+	if path == "" {
+		return value, name, nil
+	}
+	if value != "" {
+		return "", "", fmt.Errorf("%s, %s: only one of them can be set", name, fileVar)
+	}
 
-	// 3. Возвращаем значение по умолчанию
-	return defaultValue
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", fileVar, err)
+	}
+	// Файлы секретов обычно заканчиваются переводом строки, он не входит в значение
+	return strings.TrimRight(string(data), "\r\n"), fileVar, nil
+}
+
+// FilePath возвращает путь к файлу конфигурации из флага или переменной окружения
+func FilePath(flags *flag.FlagSet) string {
+	if fl := lookupFlag(flags, ConfigFileFlag); fl != nil && fl.Value.String() != "" {
+		return fl.Value.String()
+	}
+	return os.Getenv(ConfigFileEnv)
 }
 
-func validateConfig(logger *zap.Logger, cfg Config) {
-	if cfg.DBUser == "" || cfg.DBPassword == "" || cfg.DBName == "" || cfg.BinanceAPIURL == "" || cfg.OTLPEndpoint == "" {
-		logger.Fatal("Missing required configuration parameters",
-			zap.String("DBUser", cfg.DBUser),
-			zap.String("DBName", cfg.DBName),
-			zap.String("BinanceAPIURL", cfg.BinanceAPIURL),
-			zap.String("OTLPEndpoint", cfg.OTLPEndpoint),
-		)
+func lookupFlag(flags *flag.FlagSet, name string) *flag.Flag {
+	if flags == nil {
+		return nil
 	}
+	return flags.Lookup(name)
 }
 
-func logConfig(logger *zap.Logger, cfg Config) {
-	logger.Info("Loaded configuration",
-		zap.String("env", cfg.Env),
-		zap.String("db_host", cfg.DBHost),
-		zap.Int("db_port", cfg.DBPort),
-		zap.String("db_name", cfg.DBName),
-		zap.String("migrations_path", cfg.MigrationsPath),
-		zap.Int("grpc_port", cfg.GRPCPort),
-		zap.String("binance_url", cfg.BinanceAPIURL),
-		zap.Int("metrics_port", cfg.MetricsPort),
-		zap.String("otlp_endpoint", cfg.OTLPEndpoint),
-	)
+func logConfig(logger *zap.Logger, effective *Effective) {
+	fields := make([]zap.Field, 0, len(effective.Settings)+1)
+	if effective.File != "" {
+		fields = append(fields, zap.String("config_file", effective.File))
+	}
+	for _, s := range effective.Settings {
+		fields = append(fields, zap.String(s.Key, s.Display()))
+	}
+	logger.Info("Loaded configuration", fields...)
 }
@@ -3,9 +3,12 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -28,9 +31,14 @@ func TestLoadConfig(t *testing.T) {
 		"DB_NAME":         os.Getenv("DB_NAME"),
 		"MIGRATIONS_PATH": os.Getenv("MIGRATIONS_PATH"),
 		"GRPC_PORT":       os.Getenv("GRPC_PORT"),
+		"GRPC_REFLECTION": os.Getenv("GRPC_REFLECTION"),
 		"BINANCE_API_URL": os.Getenv("BINANCE_API_URL"),
 		"METRICS_PORT":    os.Getenv("METRICS_PORT"),
 		"OTLP_ENDPOINT":   os.Getenv("OTLP_ENDPOINT"),
+		"STORAGE_BACKEND": os.Getenv("STORAGE_BACKEND"),
+
+		"SHUTDOWN_TIMEOUT":   os.Getenv("SHUTDOWN_TIMEOUT"),
+		"GRPC_DRAIN_TIMEOUT": os.Getenv("GRPC_DRAIN_TIMEOUT"),
 	}
 
 	// Восстанавливаем env после тестов
@@ -61,17 +69,82 @@ func TestLoadConfig(t *testing.T) {
 			},
 			setupFlags: func(f *flag.FlagSet) {},
 			expectedConfig: Config{
-				Env:            "local",
-				DBUser:         "test-user",
-				DBPassword:     "test-pass",
-				DBHost:         "localhost",
-				DBPort:         5432,
-				DBName:         "test-db",
-				MigrationsPath: "../internal/storage/migrations",
-				GRPCPort:       50051,
-				BinanceAPIURL:  "http://test.api",
-				MetricsPort:    2112,
-				OTLPEndpoint:   "http://test-otel:4317",
+				Env:        "local",
+				DBUser:     "test-user",
+				DBPassword: "test-pass",
+				DBHost:     "localhost",
+				DBPort:     5432,
+				DBName:     "test-db",
+
+				StorageBackend: "postgres",
+				SQLitePath:     "./usdt.db",
+				MemoryMaxRates: 100000,
+
+				DBMaxConns:               10,
+				DBMinConns:               0,
+				DBMaxConnLifetime:        time.Hour,
+				DBMaxConnIdleTime:        30 * time.Minute,
+				DBStatementCacheCapacity: 512,
+				DBQueryExecMode:          "cache_statement",
+
+				GRPCPort:      50051,
+				BinanceAPIURL: "http://test.api",
+				MetricsPort:   2112,
+				OTLPEndpoint:  "http://test-otel:4317",
+
+				ShutdownTimeout:  15 * time.Second,
+				GRPCDrainTimeout: 10 * time.Second,
+
+				ExchangeRetryInterval: 5 * time.Second,
+				DBCheckInterval:       10 * time.Second,
+
+				WriteBatchSize:      100,
+				WriteFlushInterval:  time.Second,
+				WriteQueueSize:      1000,
+				WriteEnqueueTimeout: 500 * time.Millisecond,
+
+				SpoolDir:            "./spool",
+				SpoolMaxBytes:       100 << 20,
+				SpoolReplayInterval: 10 * time.Second,
+
+				TSDBMeasurement: "usdt_rate",
+				TSDBTimeout:     5 * time.Second,
+
+				OutboxPublisher:    "file",
+				OutboxTarget:       "./outbox/rates.jsonl",
+				OutboxTopic:        "usdt.rates",
+				OutboxPollInterval: time.Second,
+				OutboxBatchSize:    100,
+
+				AlertMaxAttempts:    5,
+				AlertRetryBackoff:   time.Second,
+				AlertWebhookTimeout: 5 * time.Second,
+				AlertQueueSize:      100,
+
+				AnomalyWindow:         5 * time.Minute,
+				AnomalyMaxJumpPercent: 5,
+				AnomalyMinSamples:     30,
+				AnomalyConfirmations:  3,
+
+				BaseAsset:  "BTC",
+				QuoteAsset: "USDT",
+
+				TWAPMaxGap:    time.Minute,
+				TWAPMaxWindow: 31 * 24 * time.Hour,
+
+				LogLevel:            "info",
+				ConfigWatchInterval: 5 * time.Second,
+
+				VaultMount:             "secret",
+				SecretsRefreshInterval: time.Minute,
+
+				DBSSLMode:         "disable",
+				DBApplicationName: "usdt-service",
+
+				PartitionsInterval:        time.Hour,
+				PartitionsAhead:           3,
+				PartitionsRetentionAction: "detach",
+				PartitionsArchiveFormat:   "csv",
 			},
 		},
 		{
@@ -85,6 +158,10 @@ func TestLoadConfig(t *testing.T) {
 				_ = os.Setenv("MIGRATIONS_PATH", "/custom/migrations")
 				_ = os.Setenv("GRPC_PORT", "8080")
 				_ = os.Setenv("METRICS_PORT", "9090")
+				_ = os.Setenv("GRPC_REFLECTION", "true")
+				_ = os.Setenv("SHUTDOWN_TIMEOUT", "30s")
+				_ = os.Setenv("GRPC_DRAIN_TIMEOUT", "5s")
+				_ = os.Setenv("ANOMALY_MAX_JUMP_PERCENT", "2.5")
 			},
 			setupFlags: func(f *flag.FlagSet) {},
 			expectedConfig: Config{
@@ -95,10 +172,77 @@ func TestLoadConfig(t *testing.T) {
 				DBPort:         1234,
 				DBName:         "test-db",
 				MigrationsPath: "/custom/migrations",
+
+				StorageBackend: "postgres",
+				SQLitePath:     "./usdt.db",
+				MemoryMaxRates: 100000,
+
+				DBMaxConns:               10,
+				DBMinConns:               0,
+				DBMaxConnLifetime:        time.Hour,
+				DBMaxConnIdleTime:        30 * time.Minute,
+				DBStatementCacheCapacity: 512,
+				DBQueryExecMode:          "cache_statement",
+
 				GRPCPort:       8080,
+				GRPCReflection: true,
 				BinanceAPIURL:  "http://test.api",
 				MetricsPort:    9090,
 				OTLPEndpoint:   "http://test-otel:4317",
+
+				ShutdownTimeout:  30 * time.Second,
+				GRPCDrainTimeout: 5 * time.Second,
+
+				ExchangeRetryInterval: 5 * time.Second,
+				DBCheckInterval:       10 * time.Second,
+
+				WriteBatchSize:      100,
+				WriteFlushInterval:  time.Second,
+				WriteQueueSize:      1000,
+				WriteEnqueueTimeout: 500 * time.Millisecond,
+
+				SpoolDir:            "./spool",
+				SpoolMaxBytes:       100 << 20,
+				SpoolReplayInterval: 10 * time.Second,
+
+				TSDBMeasurement: "usdt_rate",
+				TSDBTimeout:     5 * time.Second,
+
+				OutboxPublisher:    "file",
+				OutboxTarget:       "./outbox/rates.jsonl",
+				OutboxTopic:        "usdt.rates",
+				OutboxPollInterval: time.Second,
+				OutboxBatchSize:    100,
+
+				AlertMaxAttempts:    5,
+				AlertRetryBackoff:   time.Second,
+				AlertWebhookTimeout: 5 * time.Second,
+				AlertQueueSize:      100,
+
+				AnomalyWindow:         5 * time.Minute,
+				AnomalyMaxJumpPercent: 2.5,
+				AnomalyMinSamples:     30,
+				AnomalyConfirmations:  3,
+
+				BaseAsset:  "BTC",
+				QuoteAsset: "USDT",
+
+				TWAPMaxGap:    time.Minute,
+				TWAPMaxWindow: 31 * 24 * time.Hour,
+
+				LogLevel:            "info",
+				ConfigWatchInterval: 5 * time.Second,
+
+				VaultMount:             "secret",
+				SecretsRefreshInterval: time.Minute,
+
+				DBSSLMode:         "disable",
+				DBApplicationName: "usdt-service",
+
+				PartitionsInterval:        time.Hour,
+				PartitionsAhead:           3,
+				PartitionsRetentionAction: "detach",
+				PartitionsArchiveFormat:   "csv",
 			},
 		},
 		{
@@ -144,10 +288,76 @@ func TestLoadConfig(t *testing.T) {
 				DBPort:         4321,
 				DBName:         "flag-db",
 				MigrationsPath: "/flag/migrations",
-				GRPCPort:       8081,
-				BinanceAPIURL:  "http://flag.api",
-				MetricsPort:    9091,
-				OTLPEndpoint:   "http://flag-otel:4317",
+
+				StorageBackend: "postgres",
+				SQLitePath:     "./usdt.db",
+				MemoryMaxRates: 100000,
+
+				DBMaxConns:               10,
+				DBMinConns:               0,
+				DBMaxConnLifetime:        time.Hour,
+				DBMaxConnIdleTime:        30 * time.Minute,
+				DBStatementCacheCapacity: 512,
+				DBQueryExecMode:          "cache_statement",
+
+				GRPCPort:      8081,
+				BinanceAPIURL: "http://flag.api",
+				MetricsPort:   9091,
+				OTLPEndpoint:  "http://flag-otel:4317",
+
+				ShutdownTimeout:  15 * time.Second,
+				GRPCDrainTimeout: 10 * time.Second,
+
+				ExchangeRetryInterval: 5 * time.Second,
+				DBCheckInterval:       10 * time.Second,
+
+				WriteBatchSize:      100,
+				WriteFlushInterval:  time.Second,
+				WriteQueueSize:      1000,
+				WriteEnqueueTimeout: 500 * time.Millisecond,
+
+				SpoolDir:            "./spool",
+				SpoolMaxBytes:       100 << 20,
+				SpoolReplayInterval: 10 * time.Second,
+
+				TSDBMeasurement: "usdt_rate",
+				TSDBTimeout:     5 * time.Second,
+
+				OutboxPublisher:    "file",
+				OutboxTarget:       "./outbox/rates.jsonl",
+				OutboxTopic:        "usdt.rates",
+				OutboxPollInterval: time.Second,
+				OutboxBatchSize:    100,
+
+				AlertMaxAttempts:    5,
+				AlertRetryBackoff:   time.Second,
+				AlertWebhookTimeout: 5 * time.Second,
+				AlertQueueSize:      100,
+
+				AnomalyWindow:         5 * time.Minute,
+				AnomalyMaxJumpPercent: 5,
+				AnomalyMinSamples:     30,
+				AnomalyConfirmations:  3,
+
+				BaseAsset:  "BTC",
+				QuoteAsset: "USDT",
+
+				TWAPMaxGap:    time.Minute,
+				TWAPMaxWindow: 31 * 24 * time.Hour,
+
+				LogLevel:            "info",
+				ConfigWatchInterval: 5 * time.Second,
+
+				VaultMount:             "secret",
+				SecretsRefreshInterval: time.Minute,
+
+				DBSSLMode:         "disable",
+				DBApplicationName: "usdt-service",
+
+				PartitionsInterval:        time.Hour,
+				PartitionsAhead:           3,
+				PartitionsRetentionAction: "detach",
+				PartitionsArchiveFormat:   "csv",
 			},
 		},
 		{
@@ -163,21 +373,86 @@ func TestLoadConfig(t *testing.T) {
 				// Не устанавливаем значения - оставляем дефолтные
 			},
 			expectedConfig: Config{
-				Env:            "env-value",
-				DBUser:         "test-user",
-				DBPassword:     "test-pass",
-				DBHost:         "localhost",
-				DBPort:         5432,
-				DBName:         "test-db",
-				MigrationsPath: "../internal/storage/migrations",
-				GRPCPort:       50051,
-				BinanceAPIURL:  "http://test.api",
-				MetricsPort:    2112,
-				OTLPEndpoint:   "http://test-otel:4317",
+				Env:        "env-value",
+				DBUser:     "test-user",
+				DBPassword: "test-pass",
+				DBHost:     "localhost",
+				DBPort:     5432,
+				DBName:     "test-db",
+
+				StorageBackend: "postgres",
+				SQLitePath:     "./usdt.db",
+				MemoryMaxRates: 100000,
+
+				DBMaxConns:               10,
+				DBMinConns:               0,
+				DBMaxConnLifetime:        time.Hour,
+				DBMaxConnIdleTime:        30 * time.Minute,
+				DBStatementCacheCapacity: 512,
+				DBQueryExecMode:          "cache_statement",
+
+				GRPCPort:      50051,
+				BinanceAPIURL: "http://test.api",
+				MetricsPort:   2112,
+				OTLPEndpoint:  "http://test-otel:4317",
+
+				ShutdownTimeout:  15 * time.Second,
+				GRPCDrainTimeout: 10 * time.Second,
+
+				ExchangeRetryInterval: 5 * time.Second,
+				DBCheckInterval:       10 * time.Second,
+
+				WriteBatchSize:      100,
+				WriteFlushInterval:  time.Second,
+				WriteQueueSize:      1000,
+				WriteEnqueueTimeout: 500 * time.Millisecond,
+
+				SpoolDir:            "./spool",
+				SpoolMaxBytes:       100 << 20,
+				SpoolReplayInterval: 10 * time.Second,
+
+				TSDBMeasurement: "usdt_rate",
+				TSDBTimeout:     5 * time.Second,
+
+				OutboxPublisher:    "file",
+				OutboxTarget:       "./outbox/rates.jsonl",
+				OutboxTopic:        "usdt.rates",
+				OutboxPollInterval: time.Second,
+				OutboxBatchSize:    100,
+
+				AlertMaxAttempts:    5,
+				AlertRetryBackoff:   time.Second,
+				AlertWebhookTimeout: 5 * time.Second,
+				AlertQueueSize:      100,
+
+				AnomalyWindow:         5 * time.Minute,
+				AnomalyMaxJumpPercent: 5,
+				AnomalyMinSamples:     30,
+				AnomalyConfirmations:  3,
+
+				BaseAsset:  "BTC",
+				QuoteAsset: "USDT",
+
+				TWAPMaxGap:    time.Minute,
+				TWAPMaxWindow: 31 * 24 * time.Hour,
+
+				LogLevel:            "info",
+				ConfigWatchInterval: 5 * time.Second,
+
+				VaultMount:             "secret",
+				SecretsRefreshInterval: time.Minute,
+
+				DBSSLMode:         "disable",
+				DBApplicationName: "usdt-service",
+
+				PartitionsInterval:        time.Hour,
+				PartitionsAhead:           3,
+				PartitionsRetentionAction: "detach",
+				PartitionsArchiveFormat:   "csv",
 			},
 		},
 		{
-			name: "invalid port numbers fall back to defaults",
+			name: "invalid port numbers are rejected",
 			setupEnv: func() {
 				os.Clearenv()
 				setRequiredEnv()
@@ -185,20 +460,8 @@ func TestLoadConfig(t *testing.T) {
 				_ = os.Setenv("GRPC_PORT", "invalid")
 				_ = os.Setenv("METRICS_PORT", "invalid")
 			},
-			setupFlags: func(f *flag.FlagSet) {},
-			expectedConfig: Config{
-				Env:            "local",
-				DBUser:         "test-user",
-				DBPassword:     "test-pass",
-				DBHost:         "localhost",
-				DBPort:         5432,
-				DBName:         "test-db",
-				MigrationsPath: "../internal/storage/migrations",
-				GRPCPort:       50051,
-				BinanceAPIURL:  "http://test.api",
-				MetricsPort:    2112,
-				OTLPEndpoint:   "http://test-otel:4317",
-			},
+			setupFlags:  func(f *flag.FlagSet) {},
+			expectError: true,
 		},
 
 		{
@@ -220,17 +483,165 @@ func TestLoadConfig(t *testing.T) {
 				_ = f.Set("otlp-endpoint", "http://flag-otel:4317")
 			},
 			expectedConfig: Config{
-				Env:            "local",
-				DBUser:         "flag-user",
-				DBPassword:     "flag-pass",
-				DBHost:         "localhost",
-				DBPort:         5432,
-				DBName:         "flag-db",
-				MigrationsPath: "../internal/storage/migrations",
-				GRPCPort:       50051,
-				BinanceAPIURL:  "http://flag.api",
-				MetricsPort:    2112,
-				OTLPEndpoint:   "http://flag-otel:4317",
+				Env:        "local",
+				DBUser:     "flag-user",
+				DBPassword: "flag-pass",
+				DBHost:     "localhost",
+				DBPort:     5432,
+				DBName:     "flag-db",
+
+				StorageBackend: "postgres",
+				SQLitePath:     "./usdt.db",
+				MemoryMaxRates: 100000,
+
+				DBMaxConns:               10,
+				DBMinConns:               0,
+				DBMaxConnLifetime:        time.Hour,
+				DBMaxConnIdleTime:        30 * time.Minute,
+				DBStatementCacheCapacity: 512,
+				DBQueryExecMode:          "cache_statement",
+
+				GRPCPort:      50051,
+				BinanceAPIURL: "http://flag.api",
+				MetricsPort:   2112,
+				OTLPEndpoint:  "http://flag-otel:4317",
+
+				ShutdownTimeout:  15 * time.Second,
+				GRPCDrainTimeout: 10 * time.Second,
+
+				ExchangeRetryInterval: 5 * time.Second,
+				DBCheckInterval:       10 * time.Second,
+
+				WriteBatchSize:      100,
+				WriteFlushInterval:  time.Second,
+				WriteQueueSize:      1000,
+				WriteEnqueueTimeout: 500 * time.Millisecond,
+
+				SpoolDir:            "./spool",
+				SpoolMaxBytes:       100 << 20,
+				SpoolReplayInterval: 10 * time.Second,
+
+				TSDBMeasurement: "usdt_rate",
+				TSDBTimeout:     5 * time.Second,
+
+				OutboxPublisher:    "file",
+				OutboxTarget:       "./outbox/rates.jsonl",
+				OutboxTopic:        "usdt.rates",
+				OutboxPollInterval: time.Second,
+				OutboxBatchSize:    100,
+
+				AlertMaxAttempts:    5,
+				AlertRetryBackoff:   time.Second,
+				AlertWebhookTimeout: 5 * time.Second,
+				AlertQueueSize:      100,
+
+				AnomalyWindow:         5 * time.Minute,
+				AnomalyMaxJumpPercent: 5,
+				AnomalyMinSamples:     30,
+				AnomalyConfirmations:  3,
+
+				BaseAsset:  "BTC",
+				QuoteAsset: "USDT",
+
+				TWAPMaxGap:    time.Minute,
+				TWAPMaxWindow: 31 * 24 * time.Hour,
+
+				LogLevel:            "info",
+				ConfigWatchInterval: 5 * time.Second,
+
+				VaultMount:             "secret",
+				SecretsRefreshInterval: time.Minute,
+
+				DBSSLMode:         "disable",
+				DBApplicationName: "usdt-service",
+
+				PartitionsInterval:        time.Hour,
+				PartitionsAhead:           3,
+				PartitionsRetentionAction: "detach",
+				PartitionsArchiveFormat:   "csv",
+			},
+		},
+		{
+			name: "memory backend does not require database",
+			setupEnv: func() {
+				os.Clearenv()
+				_ = os.Setenv("STORAGE_BACKEND", "memory")
+				_ = os.Setenv("BINANCE_API_URL", "http://test.api")
+			},
+			setupFlags: func(f *flag.FlagSet) {},
+			expectedConfig: Config{
+				Env:    "local",
+				DBHost: "localhost",
+				DBPort: 5432,
+
+				StorageBackend: "memory",
+				SQLitePath:     "./usdt.db",
+				MemoryMaxRates: 100000,
+
+				DBMaxConns:               10,
+				DBMinConns:               0,
+				DBMaxConnLifetime:        time.Hour,
+				DBMaxConnIdleTime:        30 * time.Minute,
+				DBStatementCacheCapacity: 512,
+				DBQueryExecMode:          "cache_statement",
+
+				GRPCPort:      50051,
+				BinanceAPIURL: "http://test.api",
+				MetricsPort:   2112,
+
+				ShutdownTimeout:  15 * time.Second,
+				GRPCDrainTimeout: 10 * time.Second,
+
+				ExchangeRetryInterval: 5 * time.Second,
+				DBCheckInterval:       10 * time.Second,
+
+				WriteBatchSize:      100,
+				WriteFlushInterval:  time.Second,
+				WriteQueueSize:      1000,
+				WriteEnqueueTimeout: 500 * time.Millisecond,
+
+				SpoolDir:            "./spool",
+				SpoolMaxBytes:       100 << 20,
+				SpoolReplayInterval: 10 * time.Second,
+
+				TSDBMeasurement: "usdt_rate",
+				TSDBTimeout:     5 * time.Second,
+
+				OutboxPublisher:    "file",
+				OutboxTarget:       "./outbox/rates.jsonl",
+				OutboxTopic:        "usdt.rates",
+				OutboxPollInterval: time.Second,
+				OutboxBatchSize:    100,
+
+				AlertMaxAttempts:    5,
+				AlertRetryBackoff:   time.Second,
+				AlertWebhookTimeout: 5 * time.Second,
+				AlertQueueSize:      100,
+
+				AnomalyWindow:         5 * time.Minute,
+				AnomalyMaxJumpPercent: 5,
+				AnomalyMinSamples:     30,
+				AnomalyConfirmations:  3,
+
+				BaseAsset:  "BTC",
+				QuoteAsset: "USDT",
+
+				TWAPMaxGap:    time.Minute,
+				TWAPMaxWindow: 31 * 24 * time.Hour,
+
+				LogLevel:            "info",
+				ConfigWatchInterval: 5 * time.Second,
+
+				VaultMount:             "secret",
+				SecretsRefreshInterval: time.Minute,
+
+				DBSSLMode:         "disable",
+				DBApplicationName: "usdt-service",
+
+				PartitionsInterval:        time.Hour,
+				PartitionsAhead:           3,
+				PartitionsRetentionAction: "detach",
+				PartitionsArchiveFormat:   "csv",
 			},
 		},
 	}
@@ -245,14 +656,14 @@ func TestLoadConfig(t *testing.T) {
 			tt.setupFlags(flags)
 
 			if tt.expectError {
-				assert.Panics(t, func() {
-					LoadConfig(logger, flags)
-				}, "Expected panic for missing required fields")
+				_, err := LoadConfig(logger, flags)
+				assert.Error(t, err, "Expected error for missing required fields")
 				return
 			}
 
 			// Загружаем конфиг
-			cfg := LoadConfig(logger, flags)
+			cfg, err := LoadConfig(logger, flags)
+			require.NoError(t, err)
 
 			// Выводим отладочную информацию при неудаче
 			if !assert.Equal(t, tt.expectedConfig, cfg) {
@@ -262,3 +673,202 @@ func TestLoadConfig(t *testing.T) {
 		})
 	}
 }
+
+// writeConfigFile создает файл конфигурации во временном каталоге теста
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_FilePrecedence(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	path := writeConfigFile(t, "config.yaml", `
+app:
+  env: file-env
+db:
+  user: file-user
+  password: file-pass
+  name: file-db
+  host: file-host
+  port: 6432
+grpc:
+  port: 7000
+exchange:
+  binance_api_url: http://file.api
+  retry_interval: 2s
+telemetry:
+  metrics_port: 7001
+anomaly:
+  max_jump_percent: 1.5
+`)
+	_ = os.Setenv(ConfigFileEnv, path)
+	_ = os.Setenv("DB_HOST", "env-host")
+	_ = os.Setenv("GRPC_PORT", "8000")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("grpc-port", "", "")
+	require.NoError(t, flags.Set("grpc-port", "9000"))
+
+	cfg, effective, err := Load(flags)
+	require.NoError(t, err)
+
+	assert.Equal(t, "file-env", cfg.Env)
+	assert.Equal(t, "file-user", cfg.DBUser)
+	assert.Equal(t, 6432, cfg.DBPort)
+	assert.Equal(t, "env-host", cfg.DBHost)
+	assert.Equal(t, 9000, cfg.GRPCPort)
+	assert.Equal(t, 7001, cfg.MetricsPort)
+	assert.Equal(t, 2*time.Second, cfg.ExchangeRetryInterval)
+	assert.Equal(t, 1.5, cfg.AnomalyMaxJumpPercent)
+
+	sources := map[string]Setting{}
+	for _, s := range effective.Settings {
+		sources[s.Key] = s
+	}
+	assert.Equal(t, path, effective.File)
+	assert.Equal(t, Setting{Key: "db.port", Env: "DB_PORT", Flag: "db-port", Value: "6432", Source: SourceFile, Origin: path},
+		sources["db.port"])
+	assert.Equal(t, SourceEnv, sources["db.host"].Source)
+	assert.Equal(t, "DB_HOST", sources["db.host"].Origin)
+	assert.Equal(t, SourceFlag, sources["grpc.port"].Source)
+	assert.Equal(t, "-grpc-port", sources["grpc.port"].Origin)
+	assert.Equal(t, SourceDefault, sources["storage.backend"].Source)
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	path := writeConfigFile(t, "config.toml", `
+[storage]
+backend = "memory"
+
+[exchange]
+binance_api_url = "http://file.api"
+base_asset = "ETH"
+
+[grpc]
+reflection = true
+drain_timeout = "3s"
+`)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String(ConfigFileFlag, "", "")
+	require.NoError(t, flags.Set(ConfigFileFlag, path))
+
+	cfg, _, err := Load(flags)
+	require.NoError(t, err)
+
+	assert.Equal(t, StorageBackendMemory, cfg.StorageBackend)
+	assert.Equal(t, "ETH", cfg.BaseAsset)
+	assert.True(t, cfg.GRPCReflection)
+	assert.Equal(t, 3*time.Second, cfg.GRPCDrainTimeout)
+}
+
+func TestLoad_CollectsErrors(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	path := writeConfigFile(t, "config.yaml", `
+db:
+  port: not-a-number
+grpc:
+  prot: 1
+anomaly:
+  enabled: true
+  window: 0s
+`)
+	_ = os.Setenv(ConfigFileEnv, path)
+
+	_, _, err := Load(nil)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{
+		`db.port: invalid int value "not-a-number"`,
+		"grpc.prot: unknown key",
+		"db.user: required for postgres storage backend",
+		"db.password: required for postgres storage backend",
+		"db.name: required for postgres storage backend",
+		"anomaly.window: must be positive, got 0s",
+		"exchange.binance_api_url: required",
+	}, verr.Problems)
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	t.Run("missing file", func(t *testing.T) {
+		_ = os.Setenv(ConfigFileEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+		_, _, err := Load(nil)
+		assert.ErrorContains(t, err, "read config file")
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_ = os.Setenv(ConfigFileEnv, writeConfigFile(t, "config.json", "{}"))
+		_, _, err := Load(nil)
+		assert.ErrorContains(t, err, "unsupported config file extension")
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_ = os.Setenv(ConfigFileEnv, writeConfigFile(t, "config.toml", "[db\nport = 1"))
+		_, _, err := Load(nil)
+		assert.ErrorContains(t, err, "parse config file")
+	})
+}
+
+func TestLoad_InvalidEnvAndFlagValues(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	setRequiredEnv()
+	_ = os.Setenv("DB_PORT", "54x2")
+
+	// Флаг, зарегистрированный строкой, пропускает любое значение до Load
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("grpc-port", "", "")
+	require.NoError(t, fs.Parse([]string{"-grpc-port", "abc"}))
+
+	_, _, err := Load(fs)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Problems, `DB_PORT: invalid int value "54x2"`)
+	assert.Contains(t, verr.Problems, `-grpc-port: invalid int value "abc"`)
+}
+
+func TestLoad_FileEnvVariants(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	setRequiredEnv()
+	_ = os.Unsetenv("DB_PASSWORD")
+
+	secret := writeConfigFile(t, "db_password", "from-file\n")
+	_ = os.Setenv("DB_PASSWORD_FILE", secret)
+
+	cfg, effective, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.DBPassword)
+	for _, s := range effective.Settings {
+		if s.Key == "db.password" {
+			assert.Equal(t, SourceEnv, s.Source)
+			assert.Equal(t, "DB_PASSWORD_FILE", s.Origin)
+		}
+	}
+
+	t.Run("both set", func(t *testing.T) {
+		_ = os.Setenv("DB_PASSWORD", "plain")
+		defer os.Unsetenv("DB_PASSWORD")
+
+		_, _, err := Load(nil)
+		assert.ErrorContains(t, err, "DB_PASSWORD, DB_PASSWORD_FILE: only one of them can be set")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_ = os.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, _, err := Load(nil)
+		assert.ErrorContains(t, err, "DB_PASSWORD_FILE: open")
+	})
+}
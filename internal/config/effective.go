@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// redacted заменяет значения секретных параметров в выводе
+const redacted = "******"
+
+// Setting итоговое значение параметра и источник, из которого оно взято
+type Setting struct {
+	Key    string
+	Env    string
+	Flag   string
+	Value  string
+	Source string // SourceDefault, SourceFile, SourceEnv или SourceFlag
+	Origin string // Путь к файлу, имя переменной окружения или флага
+	Secret bool
+}
+
+// Display возвращает значение для вывода, секреты скрываются
+func (s Setting) Display() string {
+	return redact(s.Secret, s.Value)
+}
+
+// Effective итоговая конфигурация с источниками значений
+type Effective struct {
+	File     string // Загруженный файл конфигурации, пусто если файл не задан
+	Settings []Setting
+}
+
+// String возвращает таблицу параметров: ключ, значение и источник
+func (e *Effective) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range e.Settings {
+		source := s.Source
+		if s.Origin != "" {
+			source += " (" + s.Origin + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Display(), source)
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+func redact(secret bool, value string) string {
+	if secret && value != "" {
+		return redacted
+	}
+	return value
+}
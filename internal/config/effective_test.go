@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffective_String(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	setRequiredEnv()
+	_ = os.Setenv("TSDB_TOKEN", "very-secret-token")
+
+	_, effective, err := Load(nil)
+	require.NoError(t, err)
+
+	out := effective.String()
+	assert.Contains(t, out, "KEY")
+	assert.Regexp(t, `db\.password\s+\*{6}\s+env \(DB_PASSWORD\)`, out)
+	assert.Regexp(t, `tsdb\.token\s+\*{6}\s+env \(TSDB_TOKEN\)`, out)
+	assert.Regexp(t, `db\.host\s+localhost\s+default\n`, out)
+	assert.NotContains(t, out, "test-pass")
+	assert.NotContains(t, out, "very-secret-token")
+
+	// Пустой секрет не маскируется: видно, что значение не задано
+	assert.Regexp(t, `alerts\.webhook_secret\s+default\n`, out)
+}
+
+func TestSetting_Display(t *testing.T) {
+	assert.Equal(t, "value", Setting{Value: "value"}.Display())
+	assert.Equal(t, redacted, Setting{Value: "value", Secret: true}.Display())
+	assert.Equal(t, "", Setting{Secret: true}.Display())
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile читает файл конфигурации YAML или TOML (формат определяется по расширению)
+// и возвращает значения по ключам вида секция.параметр
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q, expected .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", raw, values); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten раскрывает вложенные секции в ключи через точку, скаляры приводит к строке
+func flatten(prefix string, node map[string]interface{}, out map[string]string) error {
+	for name, v := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, val, out); err != nil {
+				return err
+			}
+		case string:
+			out[key] = val
+		case int, int64, uint64, float64, bool:
+			out[key] = fmt.Sprint(val)
+		case time.Time:
+			out[key] = val.Format(time.RFC3339)
+		case nil:
+			// Пустое значение оставляет значение по умолчанию
+		default:
+			return fmt.Errorf("%s: unsupported value of type %T", key, v)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
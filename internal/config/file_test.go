@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFile(t *testing.T) {
+	want := map[string]string{
+		"app.env":                  "prod",
+		"db.port":                  "5433",
+		"grpc.reflection":          "true",
+		"anomaly.max_jump_percent": "2.5",
+		"twap.max_gap":             "30s",
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yml", `
+app:
+  env: prod
+db:
+  port: 5433
+  user:
+grpc:
+  reflection: true
+anomaly:
+  max_jump_percent: 2.5
+twap:
+  max_gap: 30s
+`)
+		values, err := readFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, values)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		path := writeConfigFile(t, "config.toml", `
+[app]
+env = "prod"
+
+[db]
+port = 5433
+
+[grpc]
+reflection = true
+
+[anomaly]
+max_jump_percent = 2.5
+
+[twap]
+max_gap = "30s"
+`)
+		values, err := readFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, values)
+	})
+
+	t.Run("lists are rejected", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", "db:\n  host: [a, b]\n")
+		_, err := readFile(path)
+		assert.ErrorContains(t, err, "db.host: unsupported value of type []interface {}")
+	})
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RegisterFlags регистрирует флаг -config и типизированный флаг для каждого параметра Config.
+// Значение флага по умолчанию совпадает со значением параметра по умолчанию,
+// в описании указывается переменная окружения с тем же параметром.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.String(ConfigFileFlag, "", fmt.Sprintf("Path to YAML or TOML configuration file (env %s)", ConfigFileEnv))
+
+	// Значения флагов хранятся отдельно: Load читает только явно заданные флаги
+	var defaults Config
+	for _, f := range fields(&defaults) {
+		fs.Var(f.value, f.Flag, fmt.Sprintf("%s (env %s, file %s)", f.Usage, f.Env, f.Key))
+	}
+}
+
+// setFlags возвращает имена флагов, явно заданных в командной строке
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	if fs != nil {
+		fs.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+		})
+	}
+	return set
+}
+
+// PrintDefaults выводит справку по флагам в формате flag.PrintDefaults с типами параметров конфигурации
+func PrintDefaults(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		switch f.Value.(type) {
+		case *stringValue:
+			name = "string"
+		case *intValue:
+			name = "int"
+		case *floatValue:
+			name = "float"
+		case *durationValue:
+			name = "duration"
+		case *boolValue:
+			name = ""
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "  -%s", f.Name)
+		if name != "" {
+			fmt.Fprintf(&b, " %s", name)
+		}
+		fmt.Fprintf(&b, "\n    \t%s", usage)
+		switch f.DefValue {
+		case "", "0", "false", "0s":
+		default:
+			if name == "string" {
+				fmt.Fprintf(&b, " (default %q)", f.DefValue)
+			} else {
+				fmt.Fprintf(&b, " (default %v)", f.DefValue)
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	})
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	setRequiredEnv()
+	_ = os.Setenv("GRPC_PORT", "8080")
+	_ = os.Setenv("DB_HOST", "env-host")
+	_ = os.Setenv("METRICS_PORT", "9090")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+
+	// Каждый параметр конфигурации доступен как флаг
+	var cfg Config
+	for _, f := range fields(&cfg) {
+		assert.NotNil(t, fs.Lookup(f.Flag), f.Flag)
+	}
+	assert.NotNil(t, fs.Lookup(ConfigFileFlag))
+
+	require.NoError(t, fs.Parse([]string{
+		"-grpc-port", "9000",
+		"-grpc-reflection",
+		"-anomaly-window=1m",
+		"-anomaly-max-jump-percent", "2.5",
+		// Явно заданное значение по умолчанию важнее переменной окружения
+		"-metrics-port=2112",
+	}))
+
+	cfg, _, err := Load(fs)
+	require.NoError(t, err)
+	assert.Equal(t, 9000, cfg.GRPCPort)
+	assert.True(t, cfg.GRPCReflection)
+	assert.Equal(t, time.Minute, cfg.AnomalyWindow)
+	assert.Equal(t, 2.5, cfg.AnomalyMaxJumpPercent)
+	assert.Equal(t, 2112, cfg.MetricsPort)
+	// Незаданный флаг не перекрывает переменную окружения
+	assert.Equal(t, "env-host", cfg.DBHost)
+}
+
+func TestRegisterFlags_TypeErrors(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+
+	assert.ErrorContains(t, fs.Parse([]string{"-grpc-port", "abc"}), "-grpc-port")
+	assert.ErrorContains(t, fs.Parse([]string{"-shutdown-timeout", "10"}), "-shutdown-timeout")
+}
+
+func TestPrintDefaults(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	fs.Bool("version", false, "Print version and exit")
+
+	var out bytes.Buffer
+	PrintDefaults(&out, fs)
+
+	assert.Contains(t, out.String(), "  -grpc-port int\n    \tgRPC server port (env GRPC_PORT, file grpc.port) (default 50051)\n")
+	assert.Contains(t, out.String(), "  -grpc-reflection\n    \tEnable gRPC server reflection (env GRPC_REFLECTION, file grpc.reflection)\n")
+	assert.Contains(t, out.String(), "  -shutdown-timeout duration\n")
+	assert.Contains(t, out.String(), "  -anomaly-zscore float\n")
+	assert.Contains(t, out.String(), "  -version\n    \tPrint version and exit\n")
+	assert.Contains(t, out.String(), "  -config string\n")
+	assert.Contains(t, out.String(), "(env STORAGE_BACKEND, file storage.backend) (default \"postgres\")\n")
+}
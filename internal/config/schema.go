@@ -0,0 +1,329 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// value типизированное значение параметра; совместимо с flag.Value
+type value interface {
+	String() string
+	Set(s string) error
+}
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+// IsBoolFlag позволяет указывать флаг без значения: -grpc-reflection
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+// field описывает параметр конфигурации и все его источники
+type field struct {
+	Key     string // Путь в файле конфигурации: секция.параметр
+	Env     string // Переменная окружения
+	Flag    string // Флаг командной строки без дефиса
+	Usage   string
+	Secret  bool // Значение скрывается в выводе конфигурации
+	Runtime bool // Значение можно изменить перезагрузкой конфигурации без перезапуска
+	Type    string
+	value   value
+}
+
+func stringField(key, env, flagName string, p *string, def, usage string) field {
+	*p = def
+	return field{Key: key, Env: env, Flag: flagName, Usage: usage, Type: "string", value: (*stringValue)(p)}
+}
+
+func intField(key, env, flagName string, p *int, def int, usage string) field {
+	*p = def
+	return field{Key: key, Env: env, Flag: flagName, Usage: usage, Type: "int", value: (*intValue)(p)}
+}
+
+func boolField(key, env, flagName string, p *bool, def bool, usage string) field {
+	*p = def
+	return field{Key: key, Env: env, Flag: flagName, Usage: usage, Type: "bool", value: (*boolValue)(p)}
+}
+
+func floatField(key, env, flagName string, p *float64, def float64, usage string) field {
+	*p = def
+	return field{Key: key, Env: env, Flag: flagName, Usage: usage, Type: "float", value: (*floatValue)(p)}
+}
+
+func durationField(key, env, flagName string, p *time.Duration, def time.Duration, usage string) field {
+	*p = def
+	return field{Key: key, Env: env, Flag: flagName, Usage: usage, Type: "duration", value: (*durationValue)(p)}
+}
+
+func (f field) secret() field {
+	f.Secret = true
+	return f
+}
+
+func (f field) runtime() field {
+	f.Runtime = true
+	return f
+}
+
+// fields заполняет cfg значениями по умолчанию и возвращает описание всех его параметров.
+// Секции файла: app, db, storage, grpc, exchange, telemetry и секции отдельных подсистем.
+func fields(c *Config) []field {
+	return []field{
+		stringField("app.env", "ENV", "env", &c.Env, "local", "Environment name"),
+		durationField("app.shutdown_timeout", "SHUTDOWN_TIMEOUT", "shutdown-timeout", &c.ShutdownTimeout, 15*time.Second,
+			"Deadline for the whole graceful shutdown"),
+		stringField("app.log_level", "LOG_LEVEL", "log-level", &c.LogLevel, "info",
+			"Log level: debug, info, warn or error").runtime(),
+		durationField("app.config_watch_interval", "CONFIG_WATCH_INTERVAL", "config-watch-interval",
+			&c.ConfigWatchInterval, 5*time.Second, "Interval of configuration file change checks, 0 disables"),
+
+		stringField("db.user", "DB_USER", "db-user", &c.DBUser, "", "Postgres user").runtime(),
+		stringField("db.password", "DB_PASSWORD", "db-password", &c.DBPassword, "", "Postgres password").secret().runtime(),
+		stringField("db.host", "DB_HOST", "db-host", &c.DBHost, "localhost", "Postgres host"),
+		intField("db.port", "DB_PORT", "db-port", &c.DBPort, 5432, "Postgres port"),
+		stringField("db.name", "DB_NAME", "db-name", &c.DBName, "", "Postgres database name"),
+		stringField("db.migrations_path", "MIGRATIONS_PATH", "migrations-path", &c.MigrationsPath,
+			"", "Directory with Postgres migrations overriding the ones embedded in the binary"),
+		stringField("db.url", "DATABASE_URL", "database-url", &c.DatabaseURL, "",
+			"Full Postgres connection URL, replaces all db.* connection settings except db.user and db.password").secret(),
+		stringField("db.sslmode", "DB_SSLMODE", "db-sslmode", &c.DBSSLMode, "disable",
+			"TLS mode: disable, require, verify-ca or verify-full"),
+		stringField("db.sslrootcert", "DB_SSLROOTCERT", "db-sslrootcert", &c.DBSSLRootCert, "",
+			"CA certificate file for server verification"),
+		stringField("db.sslcert", "DB_SSLCERT", "db-sslcert", &c.DBSSLCert, "", "Client certificate file"),
+		stringField("db.sslkey", "DB_SSLKEY", "db-sslkey", &c.DBSSLKey, "", "Client certificate key file"),
+		stringField("db.search_path", "DB_SEARCH_PATH", "db-search-path", &c.DBSearchPath, "",
+			"Schema search path of database sessions"),
+		stringField("db.application_name", "DB_APPLICATION_NAME", "db-application-name", &c.DBApplicationName,
+			"usdt-service", "Application name reported to Postgres"),
+		boolField("db.rates_partitioning", "DB_RATES_PARTITIONING", "db-rates-partitioning", &c.DBRatesPartitioning,
+			false, "Partition the rates table by month when the schema redesign migration is applied"),
+		intField("db.max_conns", "DB_MAX_CONNS", "db-max-conns", &c.DBMaxConns, 10, "Maximum pool connections"),
+		intField("db.min_conns", "DB_MIN_CONNS", "db-min-conns", &c.DBMinConns, 0, "Minimum idle pool connections"),
+		durationField("db.max_conn_lifetime", "DB_MAX_CONN_LIFETIME", "db-max-conn-lifetime", &c.DBMaxConnLifetime,
+			time.Hour, "Maximum lifetime of a pool connection"),
+		durationField("db.max_conn_idle_time", "DB_MAX_CONN_IDLE_TIME", "db-max-conn-idle-time", &c.DBMaxConnIdleTime,
+			30*time.Minute, "Maximum idle time of a pool connection"),
+		intField("db.statement_cache_capacity", "DB_STATEMENT_CACHE_CAPACITY", "db-statement-cache-capacity",
+			&c.DBStatementCacheCapacity, 512, "Prepared statement cache size per connection"),
+		stringField("db.query_exec_mode", "DB_QUERY_EXEC_MODE", "db-query-exec-mode", &c.DBQueryExecMode,
+			"cache_statement", "pgx query execution mode"),
+		durationField("db.check_interval", "DB_CHECK_INTERVAL", "db-check-interval", &c.DBCheckInterval,
+			10*time.Second, "Interval of database readiness checks"),
+
+		stringField("storage.backend", "STORAGE_BACKEND", "storage-backend", &c.StorageBackend, StorageBackendPostgres,
+			"Rate storage backend: postgres, sqlite or memory"),
+		stringField("storage.sqlite_path", "SQLITE_PATH", "sqlite-path", &c.SQLitePath, "./usdt.db",
+			"SQLite database file"),
+		intField("storage.memory_max_rates", "MEMORY_MAX_RATES", "memory-max-rates", &c.MemoryMaxRates, 100000,
+			"Number of rates kept by the memory backend"),
+
+		intField("grpc.port", "GRPC_PORT", "grpc-port", &c.GRPCPort, 50051, "gRPC server port"),
+		boolField("grpc.reflection", "GRPC_REFLECTION", "grpc-reflection", &c.GRPCReflection, false,
+			"Enable gRPC server reflection"),
+		durationField("grpc.drain_timeout", "GRPC_DRAIN_TIMEOUT", "grpc-drain-timeout", &c.GRPCDrainTimeout,
+			10*time.Second, "Time to drain gRPC calls before forced stop"),
+
+		stringField("exchange.binance_api_url", "BINANCE_API_URL", "binance-api-url", &c.BinanceAPIURL, "",
+			"Binance order book depth URL").runtime(),
+		durationField("exchange.retry_interval", "EXCHANGE_RETRY_INTERVAL", "exchange-retry-interval",
+			&c.ExchangeRetryInterval, 5*time.Second, "Interval between exchange fetch attempts").runtime(),
+		stringField("exchange.base_asset", "BASE_ASSET", "base-asset", &c.BaseAsset, "BTC", "Base asset of the tracked pair"),
+		stringField("exchange.quote_asset", "QUOTE_ASSET", "quote-asset", &c.QuoteAsset, "USDT",
+			"Quote asset of the tracked pair"),
+
+		intField("telemetry.metrics_port", "METRICS_PORT", "metrics-port", &c.MetricsPort, 2112,
+			"Port of /metrics, /livez and /readyz"),
+		stringField("telemetry.otlp_endpoint", "OTLP_ENDPOINT", "otlp-endpoint", &c.OTLPEndpoint, "",
+			"OTLP trace exporter endpoint, empty disables tracing"),
+
+		boolField("write_behind.enabled", "WRITE_BEHIND_ENABLED", "write-behind-enabled", &c.WriteBehindEnabled, false,
+			"Save rates in background batches"),
+		intField("write_behind.batch_size", "WRITE_BATCH_SIZE", "write-batch-size", &c.WriteBatchSize, 100,
+			"Rates per batch"),
+		durationField("write_behind.flush_interval", "WRITE_FLUSH_INTERVAL", "write-flush-interval",
+			&c.WriteFlushInterval, time.Second, "Maximum time a rate waits in the buffer"),
+		intField("write_behind.queue_size", "WRITE_QUEUE_SIZE", "write-queue-size", &c.WriteQueueSize, 1000,
+			"Buffer capacity in rates"),
+		durationField("write_behind.enqueue_timeout", "WRITE_ENQUEUE_TIMEOUT", "write-enqueue-timeout",
+			&c.WriteEnqueueTimeout, 500*time.Millisecond, "Time to wait for buffer space"),
+
+		boolField("spool.enabled", "SPOOL_ENABLED", "spool-enabled", &c.SpoolEnabled, false,
+			"Spool rates to disk while the database is unavailable"),
+		stringField("spool.dir", "SPOOL_DIR", "spool-dir", &c.SpoolDir, "./spool", "Spool directory"),
+		intField("spool.max_bytes", "SPOOL_MAX_BYTES", "spool-max-bytes", &c.SpoolMaxBytes, 100<<20,
+			"Maximum spool size in bytes"),
+		durationField("spool.replay_interval", "SPOOL_REPLAY_INTERVAL", "spool-replay-interval",
+			&c.SpoolReplayInterval, 10*time.Second, "Interval of spool replay attempts"),
+
+		stringField("tsdb.format", "TSDB_FORMAT", "tsdb-format", &c.TSDBFormat, "",
+			"TSDB export format: influx or remote_write, empty disables export"),
+		stringField("tsdb.url", "TSDB_URL", "tsdb-url", &c.TSDBURL, "", "TSDB write URL"),
+		stringField("tsdb.token", "TSDB_TOKEN", "tsdb-token", &c.TSDBToken, "", "TSDB auth token").secret(),
+		stringField("tsdb.measurement", "TSDB_MEASUREMENT", "tsdb-measurement", &c.TSDBMeasurement, "usdt_rate",
+			"Measurement or metric name prefix"),
+		stringField("tsdb.tags", "TSDB_TAGS", "tsdb-tags", &c.TSDBTags, "", "Constant tags: k=v,k=v"),
+		durationField("tsdb.timeout", "TSDB_TIMEOUT", "tsdb-timeout", &c.TSDBTimeout, 5*time.Second,
+			"TSDB write timeout"),
+
+		boolField("outbox.enabled", "OUTBOX_ENABLED", "outbox-enabled", &c.OutboxEnabled, false,
+			"Write rate events to the transactional outbox"),
+		stringField("outbox.publisher", "OUTBOX_PUBLISHER", "outbox-publisher", &c.OutboxPublisher, "file",
+			"Outbox publisher: file, webhook or nats"),
+		stringField("outbox.target", "OUTBOX_TARGET", "outbox-target", &c.OutboxTarget, "./outbox/rates.jsonl",
+			"Publisher target: file path, URL or NATS address"),
+		stringField("outbox.topic", "OUTBOX_TOPIC", "outbox-topic", &c.OutboxTopic, "usdt.rates", "Event topic"),
+		durationField("outbox.poll_interval", "OUTBOX_POLL_INTERVAL", "outbox-poll-interval", &c.OutboxPollInterval,
+			time.Second, "Outbox relay poll interval"),
+		intField("outbox.batch_size", "OUTBOX_BATCH_SIZE", "outbox-batch-size", &c.OutboxBatchSize, 100,
+			"Events per relay batch"),
+
+		boolField("alerts.enabled", "ALERTS_ENABLED", "alerts-enabled", &c.AlertsEnabled, false,
+			"Enable price and spread alerts"),
+		stringField("alerts.webhook_secret", "ALERT_WEBHOOK_SECRET", "alert-webhook-secret", &c.AlertWebhookSecret, "",
+			"HMAC secret for alert webhook signatures").secret(),
+		intField("alerts.max_attempts", "ALERT_MAX_ATTEMPTS", "alert-max-attempts", &c.AlertMaxAttempts, 5,
+			"Webhook delivery attempts"),
+		durationField("alerts.retry_backoff", "ALERT_RETRY_BACKOFF", "alert-retry-backoff", &c.AlertRetryBackoff,
+			time.Second, "Initial pause between delivery attempts"),
+		durationField("alerts.webhook_timeout", "ALERT_WEBHOOK_TIMEOUT", "alert-webhook-timeout", &c.AlertWebhookTimeout,
+			5*time.Second, "Webhook request timeout"),
+		intField("alerts.queue_size", "ALERT_QUEUE_SIZE", "alert-queue-size", &c.AlertQueueSize, 100,
+			"Rates waiting for alert evaluation"),
+
+		boolField("anomaly.enabled", "ANOMALY_ENABLED", "anomaly-enabled", &c.AnomalyEnabled, false,
+			"Quarantine invalid and outlier quotes"),
+		durationField("anomaly.window", "ANOMALY_WINDOW", "anomaly-window", &c.AnomalyWindow, 5*time.Minute,
+			"Window of recent rates used as the baseline").runtime(),
+		floatField("anomaly.max_jump_percent", "ANOMALY_MAX_JUMP_PERCENT", "anomaly-max-jump-percent",
+			&c.AnomalyMaxJumpPercent, 5, "Maximum mid price change from the previous rate, 0 disables").runtime(),
+		floatField("anomaly.zscore", "ANOMALY_ZSCORE", "anomaly-zscore", &c.AnomalyZScore, 0,
+			"Maximum z-score of the mid price change, 0 disables").runtime(),
+		intField("anomaly.min_samples", "ANOMALY_MIN_SAMPLES", "anomaly-min-samples", &c.AnomalyMinSamples, 30,
+			"Price changes required for the z-score check").runtime(),
+		intField("anomaly.confirmations", "ANOMALY_CONFIRMATIONS", "anomaly-confirmations", &c.AnomalyConfirmations, 3,
+			"Consecutive outliers accepted as a new price level, 0 disables").runtime(),
+
+		stringField("secrets.provider", "SECRETS_PROVIDER", "secrets-provider", &c.SecretsProvider, "",
+			"Source of database credentials: vault, empty uses db.user and db.password"),
+		stringField("secrets.vault_addr", "VAULT_ADDR", "vault-addr", &c.VaultAddr, "", "Vault server address"),
+		stringField("secrets.vault_token", "VAULT_TOKEN", "vault-token", &c.VaultToken, "", "Vault token").secret(),
+		stringField("secrets.vault_mount", "VAULT_KV_MOUNT", "vault-kv-mount", &c.VaultMount, "secret",
+			"Mount path of the Vault KV v2 engine"),
+		stringField("secrets.db_path", "VAULT_DB_SECRET_PATH", "vault-db-secret-path", &c.VaultDBSecretPath, "",
+			"Path of the secret with username and password fields"),
+		durationField("secrets.refresh_interval", "SECRETS_REFRESH_INTERVAL", "secrets-refresh-interval",
+			&c.SecretsRefreshInterval, time.Minute, "Interval of credential rotation checks"),
+
+		durationField("twap.max_gap", "TWAP_MAX_GAP", "twap-max-gap", &c.TWAPMaxGap, time.Minute,
+			"Longest interval between rates not reported as a gap"),
+		durationField("twap.max_window", "TWAP_MAX_WINDOW", "twap-max-window", &c.TWAPMaxWindow, 31*24*time.Hour,
+			"Longest TWAP window"),
+
+		boolField("partitions.enabled", "PARTITIONS_ENABLED", "partitions-enabled", &c.PartitionsEnabled, false,
+			"Maintain monthly partitions of the rates table"),
+		durationField("partitions.interval", "PARTITIONS_INTERVAL", "partitions-interval", &c.PartitionsInterval,
+			time.Hour, "Interval of partition maintenance runs"),
+		intField("partitions.ahead", "PARTITIONS_AHEAD", "partitions-ahead", &c.PartitionsAhead, 3,
+			"Number of future monthly partitions created in advance"),
+		intField("partitions.retention_months", "PARTITIONS_RETENTION_MONTHS", "partitions-retention-months",
+			&c.PartitionsRetentionMonths, 0, "Number of full past months kept attached, 0 keeps all partitions"),
+		stringField("partitions.retention_action", "PARTITIONS_RETENTION_ACTION", "partitions-retention-action",
+			&c.PartitionsRetentionAction, "detach", "Action for partitions past retention: detach or drop"),
+		stringField("partitions.archive_dir", "PARTITIONS_ARCHIVE_DIR", "partitions-archive-dir",
+			&c.PartitionsArchiveDir, "", "Directory for archives of expired partitions, empty disables archiving"),
+		stringField("partitions.archive_format", "PARTITIONS_ARCHIVE_FORMAT", "partitions-archive-format",
+			&c.PartitionsArchiveFormat, "csv", "Archive format: csv (gzip compressed) or parquet"),
+		boolField("partitions.dry_run", "PARTITIONS_DRY_RUN", "partitions-dry-run", &c.PartitionsDryRun, false,
+			"Only log planned partition operations"),
+	}
+}
+
+// Changes сравнивает две конфигурации и возвращает ключи измененных параметров:
+// runtime - применимых без перезапуска, restart - требующих перезапуска
+func Changes(old, updated Config) (runtime, restart []string) {
+	// Описание строится на рабочей копии, затем в нее копируются сравниваемые значения
+	var oldCopy, newCopy Config
+	oldFields, newFields := fields(&oldCopy), fields(&newCopy)
+	oldCopy, newCopy = old, updated
+
+	for i, f := range oldFields {
+		if f.value.String() == newFields[i].value.String() {
+			continue
+		}
+		if f.Runtime {
+			runtime = append(runtime, f.Key)
+		} else {
+			restart = append(restart, f.Key)
+		}
+	}
+	return runtime, restart
+}
+
+// ApplyRuntime возвращает копию current, в которую перенесены только параметры updated,
+// изменяемые без перезапуска
+func ApplyRuntime(current, updated Config) Config {
+	var src Config
+	srcFields := fields(&src)
+	src = updated
+
+	dst := current
+	dstFields := fields(&dst)
+	dst = current
+	for i, f := range dstFields {
+		if f.Runtime {
+			// Значения уже проверены Validate, поэтому Set не возвращает ошибку
+			_ = f.value.Set(srcFields[i].value.String())
+		}
+	}
+	return dst
+}
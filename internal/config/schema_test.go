@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields_UniqueNames(t *testing.T) {
+	var cfg Config
+	keys, envs, flags := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, f := range fields(&cfg) {
+		assert.False(t, keys[f.Key], "duplicate key %s", f.Key)
+		assert.False(t, envs[f.Env], "duplicate env %s", f.Env)
+		assert.False(t, flags[f.Flag], "duplicate flag %s", f.Flag)
+		assert.NotEmpty(t, f.Usage, f.Key)
+		keys[f.Key], envs[f.Env], flags[f.Flag] = true, true, true
+	}
+}
+
+func TestChanges(t *testing.T) {
+	old := validConfig()
+
+	updated := old
+	updated.BinanceAPIURL = "http://other.api"
+	updated.LogLevel = "debug"
+	updated.GRPCPort = 6000
+	updated.DBPassword = "rotated"
+
+	runtime, restart := Changes(old, updated)
+	assert.Equal(t, []string{"app.log_level", "db.password", "exchange.binance_api_url"}, runtime)
+	assert.Equal(t, []string{"grpc.port"}, restart)
+
+	runtime, restart = Changes(old, old)
+	assert.Empty(t, runtime)
+	assert.Empty(t, restart)
+}
+
+func TestApplyRuntime(t *testing.T) {
+	current := validConfig()
+
+	updated := current
+	updated.BinanceAPIURL = "http://other.api"
+	updated.ExchangeRetryInterval = time.Second
+	updated.AnomalyMaxJumpPercent = 1.5
+	updated.GRPCPort = 6000
+
+	applied := ApplyRuntime(current, updated)
+	assert.Equal(t, "http://other.api", applied.BinanceAPIURL)
+	assert.Equal(t, time.Second, applied.ExchangeRetryInterval)
+	assert.Equal(t, 1.5, applied.AnomalyMaxJumpPercent)
+	// Параметры, требующие перезапуска, остаются прежними
+	assert.Equal(t, current.GRPCPort, applied.GRPCPort)
+	// Исходные конфигурации не изменяются
+	assert.Equal(t, "http://test.api", current.BinanceAPIURL)
+}
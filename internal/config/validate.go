@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ValidationError содержит все найденные ошибки конфигурации
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate проверяет конфигурацию целиком и возвращает *ValidationError со всеми ошибками.
+// Параметры в сообщениях называются ключами файла конфигурации.
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.StorageBackend {
+	case StorageBackendPostgres:
+		var required []struct{ key, value string }
+		if c.DatabaseURL == "" {
+			required = append(required, struct{ key, value string }{"db.name", c.DBName})
+		}
+		// Из хранилища секретов или DATABASE_URL берутся пользователь и пароль
+		if c.SecretsProvider == "" && c.DatabaseURL == "" {
+			required = append([]struct{ key, value string }{
+				{"db.user", c.DBUser}, {"db.password", c.DBPassword},
+			}, required...)
+		}
+		for _, r := range required {
+			if r.value == "" {
+				add("%s: required for postgres storage backend", r.key)
+			}
+		}
+		if c.DatabaseURL == "" {
+			checkPort(add, "db.port", c.DBPort)
+		}
+		checkConnection(add, c)
+	case StorageBackendSQLite:
+		if c.SQLitePath == "" {
+			add("storage.sqlite_path: required for sqlite storage backend")
+		}
+	case StorageBackendMemory:
+	default:
+		add("storage.backend: unknown backend %q", c.StorageBackend)
+	}
+
+	checkPort(add, "grpc.port", c.GRPCPort)
+	checkPort(add, "telemetry.metrics_port", c.MetricsPort)
+
+	// Доступность хранилища проверяется по таймеру при любом бэкенде
+	if c.DBCheckInterval <= 0 {
+		add("db.check_interval: must be positive, got %s", c.DBCheckInterval)
+	}
+
+	// Выгрузка во временную БД включается форматом и требует адрес записи
+	if c.TSDBFormat != "" && c.TSDBURL == "" {
+		add("tsdb.url: required when tsdb.format is %q", c.TSDBFormat)
+	}
+
+	// Отложенные курсы повторяются по таймеру
+	if c.SpoolEnabled && c.SpoolReplayInterval <= 0 {
+		add("spool.replay_interval: must be positive, got %s", c.SpoolReplayInterval)
+	}
+
+	// Outbox пишется в одной транзакции с курсами, поэтому доступен только для Postgres
+	if c.OutboxEnabled && c.StorageBackend != StorageBackendPostgres {
+		add("outbox.enabled: requires postgres storage backend, got %q", c.StorageBackend)
+	}
+	if c.OutboxEnabled && c.OutboxPollInterval <= 0 {
+		add("outbox.poll_interval: must be positive, got %s", c.OutboxPollInterval)
+	}
+
+	if c.PartitionsEnabled {
+		checkPartitions(add, c)
+	}
+
+	// Скачки цены определяются относительно курсов за окно, поэтому оно должно быть положительным
+	if c.AnomalyEnabled && c.AnomalyWindow <= 0 {
+		add("anomaly.window: must be positive, got %s", c.AnomalyWindow)
+	}
+
+	if c.BaseAsset == "" || c.QuoteAsset == "" || strings.EqualFold(c.BaseAsset, c.QuoteAsset) {
+		add("exchange.base_asset, exchange.quote_asset: must be set and differ, got %q and %q",
+			c.BaseAsset, c.QuoteAsset)
+	}
+
+	switch c.SecretsProvider {
+	case "":
+	case SecretsProviderVault:
+		for _, r := range []struct{ key, value string }{
+			{"secrets.vault_addr", c.VaultAddr}, {"secrets.vault_token", c.VaultToken}, {"secrets.db_path", c.VaultDBSecretPath},
+		} {
+			if r.value == "" {
+				add("%s: required for vault secrets provider", r.key)
+			}
+		}
+	default:
+		add("secrets.provider: unknown provider %q", c.SecretsProvider)
+	}
+	// Учетные данные Postgres проверяются периодически и без внешнего хранилища секретов
+	if (c.SecretsProvider != "" || c.StorageBackend == StorageBackendPostgres) && c.SecretsRefreshInterval <= 0 {
+		add("secrets.refresh_interval: must be positive, got %s", c.SecretsRefreshInterval)
+	}
+
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		add("app.log_level: unknown level %q", c.LogLevel)
+	}
+
+	// OTLPEndpoint необязателен: без него трассировка не экспортируется
+	if c.BinanceAPIURL == "" {
+		add("exchange.binance_api_url: required")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func checkPort(add func(string, ...interface{}), key string, port int) {
+	if port < 1 || port > 65535 {
+		add("%s: must be between 1 and 65535, got %d", key, port)
+	}
+}
+
+// sslModes режимы TLS, которые одинаково понимают пул pgx и драйвер миграций.
+// Единственный список: storage.ConnConfig передает режим в DSN без проверки.
+var sslModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
+func checkConnection(add func(string, ...interface{}), c Config) {
+	if c.DatabaseURL != "" {
+		u, err := url.Parse(c.DatabaseURL)
+		switch {
+		case err != nil:
+			// Текст ошибки url.Parse содержит строку целиком вместе с паролем
+			add("db.url: invalid URL")
+		case u.Scheme != "postgres" && u.Scheme != "postgresql":
+			add("db.url: unsupported scheme %q, expected postgres or postgresql", u.Scheme)
+		}
+		return
+	}
+
+	valid := false
+	for _, mode := range sslModes {
+		valid = valid || c.DBSSLMode == mode
+	}
+	if !valid {
+		add("db.sslmode: unknown mode %q, expected one of %s", c.DBSSLMode, strings.Join(sslModes, ", "))
+	}
+	if (c.DBSSLCert == "") != (c.DBSSLKey == "") {
+		add("db.sslcert, db.sslkey: client certificate and key must be set together")
+	}
+}
+
+func checkPartitions(add func(string, ...interface{}), c Config) {
+	// Секции есть только у таблицы rates в Postgres
+	if c.StorageBackend != StorageBackendPostgres {
+		add("partitions.enabled: requires postgres storage backend, got %q", c.StorageBackend)
+	}
+	if c.PartitionsInterval <= 0 {
+		add("partitions.interval: must be positive, got %s", c.PartitionsInterval)
+	}
+	if c.PartitionsAhead < 0 {
+		add("partitions.ahead: must not be negative, got %d", c.PartitionsAhead)
+	}
+	if c.PartitionsRetentionMonths < 0 {
+		add("partitions.retention_months: must not be negative, got %d", c.PartitionsRetentionMonths)
+	}
+	if c.PartitionsRetentionAction != "detach" && c.PartitionsRetentionAction != "drop" {
+		add("partitions.retention_action: unknown action %q, expected detach or drop", c.PartitionsRetentionAction)
+	}
+	if c.PartitionsArchiveFormat != "csv" && c.PartitionsArchiveFormat != "parquet" {
+		add("partitions.archive_format: unknown format %q, expected csv or parquet", c.PartitionsArchiveFormat)
+	}
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig возвращает конфигурацию по умолчанию с заполненными обязательными параметрами
+func validConfig() Config {
+	var cfg Config
+	fields(&cfg)
+	cfg.DBUser, cfg.DBPassword, cfg.DBName = "user", "pass", "db"
+	cfg.BinanceAPIURL = "http://test.api"
+	return cfg
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, validConfig().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{
+			name:   "unknown backend",
+			modify: func(c *Config) { c.StorageBackend = "mongo" },
+			want:   []string{`storage.backend: unknown backend "mongo"`},
+		},
+		{
+			name:   "sqlite without path",
+			modify: func(c *Config) { c.StorageBackend, c.SQLitePath = StorageBackendSQLite, "" },
+			want:   []string{"storage.sqlite_path: required for sqlite storage backend"},
+		},
+		{
+			name:   "ports out of range",
+			modify: func(c *Config) { c.DBPort, c.GRPCPort, c.MetricsPort = 0, 70000, -1 },
+			want: []string{
+				"db.port: must be between 1 and 65535, got 0",
+				"grpc.port: must be between 1 and 65535, got 70000",
+				"telemetry.metrics_port: must be between 1 and 65535, got -1",
+			},
+		},
+		{
+			name:   "non-positive database check interval",
+			modify: func(c *Config) { c.DBCheckInterval = 0 },
+			want:   []string{"db.check_interval: must be positive, got 0s"},
+		},
+		{
+			name: "spool without replay interval",
+			modify: func(c *Config) {
+				c.SpoolEnabled = true
+				c.SpoolReplayInterval = -time.Second
+			},
+			want: []string{"spool.replay_interval: must be positive, got -1s"},
+		},
+		{
+			name:   "tsdb without url",
+			modify: func(c *Config) { c.TSDBFormat = "influx" },
+			want:   []string{`tsdb.url: required when tsdb.format is "influx"`},
+		},
+		{
+			name:   "outbox without postgres",
+			modify: func(c *Config) { c.StorageBackend, c.OutboxEnabled = StorageBackendMemory, true },
+			want:   []string{`outbox.enabled: requires postgres storage backend, got "memory"`},
+		},
+		{
+			name: "outbox without poll interval",
+			modify: func(c *Config) {
+				c.OutboxEnabled = true
+				c.OutboxPollInterval = 0
+			},
+			want: []string{"outbox.poll_interval: must be positive, got 0s"},
+		},
+		{
+			name: "database url replaces individual fields",
+			modify: func(c *Config) {
+				c.DatabaseURL = "postgres://u:p@db:5432/binance?sslmode=require"
+				c.DBUser, c.DBPassword, c.DBName, c.DBPort = "", "", "", 0
+			},
+		},
+		{
+			name:   "invalid database url",
+			modify: func(c *Config) { c.DatabaseURL = "mysql://u:secret@db/binance" },
+			want:   []string{`db.url: unsupported scheme "mysql", expected postgres or postgresql`},
+		},
+		{
+			name:   "unparsable database url does not leak password",
+			modify: func(c *Config) { c.DatabaseURL = "postgres://u:secret@db:port/binance" },
+			want:   []string{"db.url: invalid URL"},
+		},
+		{
+			name:   "unknown ssl mode",
+			modify: func(c *Config) { c.DBSSLMode = "prefer" },
+			want:   []string{`db.sslmode: unknown mode "prefer", expected one of disable, require, verify-ca, verify-full`},
+		},
+		{
+			name:   "client certificate without key",
+			modify: func(c *Config) { c.DBSSLMode, c.DBSSLCert = "verify-full", "/certs/client.crt" },
+			want:   []string{"db.sslcert, db.sslkey: client certificate and key must be set together"},
+		},
+		{
+			name: "partition maintenance",
+			modify: func(c *Config) {
+				c.PartitionsEnabled, c.PartitionsInterval = true, time.Hour
+				c.PartitionsRetentionAction, c.PartitionsArchiveFormat = "drop", "parquet"
+			},
+		},
+		{
+			name: "invalid partition maintenance",
+			modify: func(c *Config) {
+				c.StorageBackend = StorageBackendSQLite
+				c.PartitionsEnabled, c.PartitionsAhead, c.PartitionsInterval = true, -1, 0
+				c.PartitionsRetentionAction, c.PartitionsArchiveFormat = "truncate", "xlsx"
+			},
+			want: []string{
+				`partitions.enabled: requires postgres storage backend, got "sqlite"`,
+				"partitions.interval: must be positive, got 0s",
+				"partitions.ahead: must not be negative, got -1",
+				`partitions.retention_action: unknown action "truncate", expected detach or drop`,
+				`partitions.archive_format: unknown format "xlsx", expected csv or parquet`,
+			},
+		},
+		{
+			name:   "postgres without credential refresh interval",
+			modify: func(c *Config) { c.SecretsRefreshInterval = 0 },
+			want:   []string{"secrets.refresh_interval: must be positive, got 0s"},
+		},
+		{
+			name:   "unknown secrets provider",
+			modify: func(c *Config) { c.SecretsProvider = "aws" },
+			want:   []string{`secrets.provider: unknown provider "aws"`},
+		},
+		{
+			name: "vault without settings",
+			modify: func(c *Config) {
+				c.SecretsProvider = SecretsProviderVault
+				c.DBUser, c.DBPassword = "", ""
+			},
+			// Пользователь и пароль БД берутся из Vault и не требуются в конфигурации
+			want: []string{
+				"secrets.vault_addr: required for vault secrets provider",
+				"secrets.vault_token: required for vault secrets provider",
+				"secrets.db_path: required for vault secrets provider",
+			},
+		},
+		{
+			name: "several problems at once",
+			modify: func(c *Config) {
+				c.AnomalyEnabled = true
+				c.AnomalyWindow = -time.Second
+				c.QuoteAsset = "btc"
+				c.BinanceAPIURL = ""
+			},
+			want: []string{
+				"anomaly.window: must be positive, got -1s",
+				`exchange.base_asset, exchange.quote_asset: must be set and differ, got "BTC" and "btc"`,
+				"exchange.binance_api_url: required",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.want, verr.Problems)
+			assert.Contains(t, err.Error(), tt.want[0])
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+// Стороны стакана, по которым выполняется конвертация
+const (
+	SideBid = "bid" // Продажа базового актива по лучшей цене покупателя
+	SideAsk = "ask" // Покупка базового актива по лучшей цене продавца
+)
+
+var (
+	// ErrNoRoute возвращается, если активы не связаны отслеживаемыми парами
+	ErrNoRoute = errors.New("no conversion route")
+	// ErrNoRate возвращается, если для пары маршрута нет сохраненных курсов
+	ErrNoRate = errors.New("no stored rate")
+	// ErrInvalidAmount возвращается для неположительной или нечисловой суммы
+	ErrInvalidAmount = errors.New("amount must be a positive finite number")
+)
+
+// RateSource источник сохраненных курсов пары
+type RateSource interface {
+	LatestRate(ctx context.Context) (models.Rate, error)
+	NearestRate(ctx context.Context, at time.Time) (models.Rate, error)
+}
+
+// Market отслеживаемая пара: цена Base в единицах Quote
+type Market struct {
+	Base   string
+	Quote  string
+	Source RateSource
+}
+
+// Symbol возвращает обозначение пары в формате биржи, например BTCUSDT
+func (m Market) Symbol() string {
+	return m.Base + m.Quote
+}
+
+// Leg шаг конвертации через одну пару
+type Leg struct {
+	Symbol    string
+	Side      string
+	Price     float64
+	Time      time.Time // Время использованного курса
+	AmountIn  float64
+	AmountOut float64
+}
+
+// Result итог конвертации
+type Result struct {
+	Amount float64   // Сумма в целевом активе
+	Rate   float64   // Итоговый курс: единиц целевого актива за единицу исходного
+	Time   time.Time // Время самого старого из использованных курсов
+	Legs   []Leg
+}
+
+// Converter пересчитывает суммы между активами по сохраненным курсам.
+// Если прямой пары нет, конвертация идет по кратчайшей цепочке отслеживаемых пар.
+type Converter struct {
+	markets []Market
+}
+
+// NewConverter создает конвертер по отслеживаемым парам
+func NewConverter(markets ...Market) *Converter {
+	normalized := make([]Market, 0, len(markets))
+	for _, m := range markets {
+		m.Base, m.Quote = normalize(m.Base), normalize(m.Quote)
+		normalized = append(normalized, m)
+	}
+	return &Converter{markets: normalized}
+}
+
+// Convert пересчитывает amount из from в to.
+// Нулевое at означает последний курс, иначе берется ближайший по времени к at.
+// Продажа базового актива идет по bid, покупка - по ask.
+func (c *Converter) Convert(ctx context.Context, amount float64, from, to string, at time.Time) (Result, error) {
+	if amount <= 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Result{}, ErrInvalidAmount
+	}
+	from, to = normalize(from), normalize(to)
+	if from == to {
+		return Result{}, fmt.Errorf("%w: %s to itself", ErrNoRoute, from)
+	}
+
+	route, ok := c.route(from, to)
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %s to %s", ErrNoRoute, from, to)
+	}
+
+	result := Result{Amount: amount}
+	asset := from
+	for _, market := range route {
+		rate, err := fetchRate(ctx, market, at)
+		if err != nil {
+			return Result{}, err
+		}
+
+		leg := Leg{Symbol: market.Symbol(), Time: rate.Time, AmountIn: result.Amount}
+		if asset == market.Base {
+			leg.Side, leg.Price = SideBid, rate.Bid
+			leg.AmountOut = result.Amount * rate.Bid
+			asset = market.Quote
+		} else {
+			if rate.Ask <= 0 {
+				return Result{}, fmt.Errorf("invalid ask price %v for %s", rate.Ask, market.Symbol())
+			}
+			leg.Side, leg.Price = SideAsk, rate.Ask
+			leg.AmountOut = result.Amount / rate.Ask
+			asset = market.Base
+		}
+
+		result.Amount = leg.AmountOut
+		result.Legs = append(result.Legs, leg)
+		if result.Time.IsZero() || leg.Time.Before(result.Time) {
+			result.Time = leg.Time
+		}
+	}
+	result.Rate = result.Amount / amount
+	return result, nil
+}
+
+// route ищет кратчайшую цепочку пар от from до to поиском в ширину
+func (c *Converter) route(from, to string) ([]Market, bool) {
+	type step struct {
+		asset string
+		path  []Market
+	}
+	visited := map[string]bool{from: true}
+	queue := []step{{asset: from}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, m := range c.markets {
+			var next string
+			switch current.asset {
+			case m.Base:
+				next = m.Quote
+			case m.Quote:
+				next = m.Base
+			default:
+				continue
+			}
+			if visited[next] {
+				continue
+			}
+			path := append(append([]Market(nil), current.path...), m)
+			if next == to {
+				return path, true
+			}
+			visited[next] = true
+			queue = append(queue, step{asset: next, path: path})
+		}
+	}
+	return nil, false
+}
+
+func fetchRate(ctx context.Context, market Market, at time.Time) (models.Rate, error) {
+	var (
+		rate models.Rate
+		err  error
+	)
+	if at.IsZero() {
+		rate, err = market.Source.LatestRate(ctx)
+	} else {
+		rate, err = market.Source.NearestRate(ctx, at)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return models.Rate{}, fmt.Errorf("%w for %s", ErrNoRate, market.Symbol())
+	}
+	if err != nil {
+		return models.Rate{}, fmt.Errorf("get %s rate: %w", market.Symbol(), err)
+	}
+	return rate, nil
+}
+
+func normalize(asset string) string {
+	return strings.ToUpper(strings.TrimSpace(asset))
+}
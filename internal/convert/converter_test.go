@@ -0,0 +1,116 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func memorySource(t *testing.T, rates ...models.Rate) *storage.MemoryStorage {
+	store := storage.NewMemoryStorage(0)
+	require.NoError(t, store.SaveRates(context.Background(), rates))
+	return store
+}
+
+// failingSource всегда возвращает ошибку хранилища
+type failingSource struct{}
+
+func (failingSource) LatestRate(context.Context) (models.Rate, error) {
+	return models.Rate{}, errors.New("db down")
+}
+
+func (failingSource) NearestRate(context.Context, time.Time) (models.Rate, error) {
+	return models.Rate{}, errors.New("db down")
+}
+
+func TestConverter_Direct(t *testing.T) {
+	btc := memorySource(t,
+		models.Rate{Ask: 101, Bid: 100, AskAmount: 1, BidAmount: 1, Time: base},
+		models.Rate{Ask: 202, Bid: 200, AskAmount: 1, BidAmount: 1, Time: base.Add(time.Hour)},
+	)
+	c := NewConverter(Market{Base: "BTC", Quote: "USDT", Source: btc})
+	ctx := context.Background()
+
+	t.Run("sell base at bid", func(t *testing.T) {
+		result, err := c.Convert(ctx, 2, "BTC", "USDT", time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 400.0, result.Amount)
+		assert.Equal(t, 200.0, result.Rate)
+		assert.Equal(t, base.Add(time.Hour), result.Time)
+		require.Len(t, result.Legs, 1)
+		assert.Equal(t, Leg{Symbol: "BTCUSDT", Side: SideBid, Price: 200, Time: base.Add(time.Hour),
+			AmountIn: 2, AmountOut: 400}, result.Legs[0])
+	})
+
+	t.Run("buy base at ask", func(t *testing.T) {
+		result, err := c.Convert(ctx, 404, "usdt", "btc", time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 2.0, result.Amount)
+		assert.Equal(t, SideAsk, result.Legs[0].Side)
+		assert.Equal(t, 202.0, result.Legs[0].Price)
+	})
+
+	t.Run("historical nearest rate", func(t *testing.T) {
+		result, err := c.Convert(ctx, 1, "BTC", "USDT", base.Add(10*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, result.Amount)
+		assert.Equal(t, base, result.Time)
+	})
+}
+
+func TestConverter_Chain(t *testing.T) {
+	c := NewConverter(
+		Market{Base: "BTC", Quote: "USDT", Source: memorySource(t,
+			models.Rate{Ask: 101, Bid: 100, Time: base.Add(time.Minute)})},
+		Market{Base: "ETH", Quote: "USDT", Source: memorySource(t,
+			models.Rate{Ask: 10, Bid: 9, Time: base})},
+	)
+
+	// BTC -> USDT по bid, затем USDT -> ETH по ask
+	result, err := c.Convert(context.Background(), 1, "BTC", "ETH", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, result.Legs, 2)
+	assert.Equal(t, "BTCUSDT", result.Legs[0].Symbol)
+	assert.Equal(t, SideBid, result.Legs[0].Side)
+	assert.Equal(t, "ETHUSDT", result.Legs[1].Symbol)
+	assert.Equal(t, SideAsk, result.Legs[1].Side)
+	assert.InDelta(t, 10.0, result.Amount, 1e-9)
+	// Время итога - самый старый из использованных курсов
+	assert.Equal(t, base, result.Time)
+}
+
+func TestConverter_Errors(t *testing.T) {
+	ctx := context.Background()
+	c := NewConverter(
+		Market{Base: "BTC", Quote: "USDT", Source: memorySource(t)},
+		Market{Base: "ETH", Quote: "USDT", Source: failingSource{}},
+	)
+
+	for _, amount := range []float64{0, -1, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := c.Convert(ctx, amount, "BTC", "USDT", time.Time{})
+		assert.ErrorIs(t, err, ErrInvalidAmount, "amount %v", amount)
+	}
+
+	_, err := c.Convert(ctx, 1, "BTC", "EUR", time.Time{})
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	_, err = c.Convert(ctx, 1, "BTC", "btc", time.Time{})
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	_, err = c.Convert(ctx, 1, "BTC", "USDT", time.Time{})
+	assert.ErrorIs(t, err, ErrNoRate)
+
+	_, err = c.Convert(ctx, 1, "ETH", "USDT", base)
+	assert.ErrorContains(t, err, "db down")
+	assert.NotErrorIs(t, err, ErrNoRate)
+}
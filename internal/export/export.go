@@ -0,0 +1,140 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format формат выгрузки курсов
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSONL   Format = "jsonl" // Объект JSON на строку
+	FormatParquet Format = "parquet"
+)
+
+// Formats поддерживаемые форматы
+var Formats = []Format{FormatCSV, FormatJSONL, FormatParquet}
+
+// ParseFormat проверяет название формата
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q", name)
+}
+
+// parquetRowGroupSize ограничивает число строк, которые parquet держит в памяти до записи
+const parquetRowGroupSize = 64 * 1024
+
+// Row курс в выгрузке
+type Row struct {
+	Timestamp time.Time `parquet:"timestamp,timestamp(microsecond)" json:"timestamp"`
+	Symbol    string    `parquet:"symbol,dict" json:"symbol"`
+	Source    string    `parquet:"source,dict" json:"source"`
+	Ask       float64   `parquet:"ask" json:"ask"`
+	Bid       float64   `parquet:"bid" json:"bid"`
+	AskAmount float64   `parquet:"ask_amount" json:"ask_amount"`
+	BidAmount float64   `parquet:"bid_amount" json:"bid_amount"`
+}
+
+// Writer последовательно записывает курсы в выбранном формате
+type Writer interface {
+	Write(row Row) error
+	// Close дописывает завершающую часть формата; нижележащий io.Writer не закрывается
+	Close() error
+}
+
+// NewWriter создает Writer формата format поверх w
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		return &parquetWriter{w: parquet.NewGenericWriter[Row](w,
+			parquet.Compression(&parquet.Zstd),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize))}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// csvHeader заголовок CSV; время записывается в RFC 3339 по UTC
+var csvHeader = []string{"timestamp", "symbol", "source", "ask", "bid", "ask_amount", "bid_amount"}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), record: make([]string, len(csvHeader))}
+}
+
+func (c *csvWriter) Write(row Row) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.record[0] = row.Timestamp.UTC().Format(time.RFC3339Nano)
+	c.record[1] = row.Symbol
+	c.record[2] = row.Source
+	c.record[3] = strconv.FormatFloat(row.Ask, 'f', -1, 64)
+	c.record[4] = strconv.FormatFloat(row.Bid, 'f', -1, 64)
+	c.record[5] = strconv.FormatFloat(row.AskAmount, 'f', -1, 64)
+	c.record[6] = strconv.FormatFloat(row.BidAmount, 'f', -1, 64)
+	return c.w.Write(c.record)
+}
+
+// Close записывает заголовок, даже если курсов не было
+func (c *csvWriter) Close() error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+// Write записывает курс отдельной строкой; время - в RFC 3339 по UTC, как в CSV
+func (j *jsonlWriter) Write(row Row) error {
+	row.Timestamp = row.Timestamp.UTC()
+	return j.enc.Encode(row)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[Row]
+}
+
+func (p *parquetWriter) Write(row Row) error {
+	_, err := p.w.Write([]Row{row})
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
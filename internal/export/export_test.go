@@ -0,0 +1,90 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRows() []Row {
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return []Row{
+		{Timestamp: ts, Symbol: "BTCUSDT", Source: "binance", Ask: 42000.5, Bid: 41999.25, AskAmount: 1.5, BidAmount: 0.125},
+		{Timestamp: ts.Add(time.Second), Symbol: "BTCUSDT", Source: "binance", Ask: 42001, Bid: 42000, AskAmount: 2, BidAmount: 3},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("parquet")
+	require.NoError(t, err)
+	assert.Equal(t, FormatParquet, format)
+
+	_, err = ParseFormat("xlsx")
+	assert.EqualError(t, err, `unknown export format "xlsx"`)
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV)
+	require.NoError(t, err)
+	for _, row := range testRows() {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "timestamp,symbol,source,ask,bid,ask_amount,bid_amount\n"+
+		"2024-01-01T12:00:00Z,BTCUSDT,binance,42000.5,41999.25,1.5,0.125\n"+
+		"2024-01-01T12:00:01Z,BTCUSDT,binance,42001,42000,2,3\n", buf.String())
+}
+
+func TestWriter_CSVEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "timestamp,symbol,source,ask,bid,ask_amount,bid_amount\n", buf.String())
+}
+
+func TestWriter_JSONL(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatJSONL)
+	require.NoError(t, err)
+	for _, row := range testRows() {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, `{"timestamp":"2024-01-01T12:00:00Z","symbol":"BTCUSDT","source":"binance",`+
+		`"ask":42000.5,"bid":41999.25,"ask_amount":1.5,"bid_amount":0.125}`+"\n"+
+		`{"timestamp":"2024-01-01T12:00:01Z","symbol":"BTCUSDT","source":"binance",`+
+		`"ask":42001,"bid":42000,"ask_amount":2,"bid_amount":3}`+"\n", buf.String())
+}
+
+func TestWriter_Parquet(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatParquet)
+	require.NoError(t, err)
+	for _, row := range testRows() {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	rows, err := parquet.Read[Row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	for i, want := range testRows() {
+		assert.True(t, want.Timestamp.Equal(rows[i].Timestamp))
+		rows[i].Timestamp = want.Timestamp
+		assert.Equal(t, want, rows[i])
+	}
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,199 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Phase определяет очередность остановки компонентов
+type Phase int
+
+const (
+	// PhaseServers - входящие соединения (gRPC, HTTP) закрываются первыми
+	PhaseServers Phase = iota
+	// PhaseWorkers - фоновые обработчики, которые еще могут писать в БД и создавать спаны
+	PhaseWorkers
+	// PhaseTelemetry - сброс трассировки после остановки всех источников спанов
+	PhaseTelemetry
+	// PhaseStorage - соединения с хранилищем закрываются последними
+	PhaseStorage
+)
+
+var phaseNames = map[Phase]string{
+	PhaseServers:   "servers",
+	PhaseWorkers:   "workers",
+	PhaseTelemetry: "telemetry",
+	PhaseStorage:   "storage",
+}
+
+func (p Phase) String() string {
+	if name, ok := phaseNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("phase-%d", int(p))
+}
+
+// StopFunc останавливает компонент с учетом дедлайна контекста
+type StopFunc func(ctx context.Context) error
+
+type component struct {
+	phase Phase
+	name  string
+	stop  StopFunc
+}
+
+// Manager управляет упорядоченной остановкой компонентов приложения
+type Manager struct {
+	logger     *zap.Logger
+	mu         sync.Mutex
+	components []component
+	once       sync.Once
+	err        error
+}
+
+// NewManager создает новый менеджер жизненного цикла
+func NewManager(logger *zap.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Add регистрирует компонент для остановки в указанной фазе.
+// Внутри фазы компоненты останавливаются в порядке регистрации.
+func (m *Manager) Add(phase Phase, name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{phase: phase, name: name, stop: stop})
+}
+
+// Shutdown останавливает все компоненты по фазам и возвращает объединенную ошибку.
+// Повторные вызовы возвращают результат первого.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		m.err = m.shutdown(ctx)
+	})
+	return m.err
+}
+
+func (m *Manager) shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	components := make([]component, len(m.components))
+	copy(components, m.components)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, phase := range []Phase{PhaseServers, PhaseWorkers, PhaseTelemetry, PhaseStorage} {
+		for _, c := range components {
+			if c.phase != phase {
+				continue
+			}
+
+			start := time.Now()
+			err := c.stop(ctx)
+			fields := []zap.Field{
+				zap.String("phase", phase.String()),
+				zap.String("component", c.name),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				m.logger.Error("Component shutdown failed", append(fields, zap.Error(err))...)
+				errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+				continue
+			}
+			m.logger.Info("Component stopped", fields...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ErrForcedStop возвращается, если gRPC сервер не успел завершить запросы и был остановлен принудительно
+var ErrForcedStop = errors.New("graceful stop timed out, server stopped forcibly")
+
+// GRPCServer возвращает StopFunc, которая дожидается завершения активных RPC не дольше drainTimeout,
+// после чего принудительно закрывает соединения через Stop()
+func GRPCServer(server *grpc.Server, drainTimeout time.Duration) StopFunc {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(done)
+		}()
+
+		timer := time.NewTimer(drainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+			return nil
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+
+		server.Stop()
+		<-done
+		return ErrForcedStop
+	}
+}
+
+// HTTPServer возвращает StopFunc для корректной остановки HTTP сервера
+func HTTPServer(server *http.Server) StopFunc {
+	return func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
+			return fmt.Errorf("http server shutdown failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// Closer адаптирует Close() error к StopFunc
+func Closer(close func() error) StopFunc {
+	return func(context.Context) error {
+		return close()
+	}
+}
+
+// Group запускает фоновые обработчики с общим контекстом отмены
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewGroup создает группу фоновых обработчиков
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go запускает обработчик в отдельной горутине
+func (g *Group) Go(fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// Stop отменяет контекст группы и дожидается завершения обработчиков
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop: %w", ctx.Err())
+	}
+}
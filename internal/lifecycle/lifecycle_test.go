@@ -0,0 +1,139 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestManager_ShutdownOrder(t *testing.T) {
+	manager := NewManager(zap.NewNop())
+
+	var order []string
+	record := func(name string) StopFunc {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	// Регистрируем в "неправильном" порядке - менеджер должен упорядочить по фазам
+	manager.Add(PhaseStorage, "db", record("db"))
+	manager.Add(PhaseTelemetry, "tracer", record("tracer"))
+	manager.Add(PhaseWorkers, "worker", record("worker"))
+	manager.Add(PhaseServers, "grpc", record("grpc"))
+	manager.Add(PhaseServers, "metrics", record("metrics"))
+
+	require.NoError(t, manager.Shutdown(context.Background()))
+	assert.Equal(t, []string{"grpc", "metrics", "worker", "tracer", "db"}, order)
+}
+
+func TestManager_ShutdownErrors(t *testing.T) {
+	manager := NewManager(zap.NewNop())
+
+	called := false
+	manager.Add(PhaseServers, "grpc", func(context.Context) error { return errors.New("boom") })
+	manager.Add(PhaseStorage, "db", func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	err := manager.Shutdown(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grpc: boom")
+	assert.True(t, called, "later phases must run even if an earlier step failed")
+
+	// Повторный вызов не запускает шаги заново
+	called = false
+	assert.Equal(t, err, manager.Shutdown(context.Background()))
+	assert.False(t, called)
+}
+
+func TestGRPCServer(t *testing.T) {
+	start := func(t *testing.T) (*grpc.Server, *grpc.ClientConn) {
+		srv := grpc.NewServer()
+		healthpb.RegisterHealthServer(srv, health.NewServer())
+
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go func() { _ = srv.Serve(lis) }()
+
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return srv, conn
+	}
+
+	t.Run("graceful", func(t *testing.T) {
+		srv, _ := start(t)
+		assert.NoError(t, GRPCServer(srv, time.Second)(context.Background()))
+	})
+
+	t.Run("forced after drain timeout", func(t *testing.T) {
+		srv, conn := start(t)
+
+		// Watch - долгоживущий стрим, который не дает GracefulStop завершиться
+		stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		err = GRPCServer(srv, 50*time.Millisecond)(context.Background())
+		assert.ErrorIs(t, err, ErrForcedStop)
+	})
+}
+
+func TestHTTPServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{ReadHeaderTimeout: time.Second}
+	go func() { _ = srv.Serve(lis) }()
+
+	assert.NoError(t, HTTPServer(srv)(context.Background()))
+
+	_, err = http.Get("http://" + lis.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("stop waits for workers", func(t *testing.T) {
+		group := NewGroup()
+
+		stopped := make(chan struct{})
+		group.Go(func(ctx context.Context) {
+			<-ctx.Done()
+			close(stopped)
+		})
+
+		require.NoError(t, group.Stop(context.Background()))
+		select {
+		case <-stopped:
+		default:
+			t.Fatal("worker was not stopped")
+		}
+	})
+
+	t.Run("stop respects deadline", func(t *testing.T) {
+		group := NewGroup()
+
+		release := make(chan struct{})
+		defer close(release)
+		group.Go(func(context.Context) { <-release })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, group.Stop(ctx), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DBPoolStats снимок статистики пула соединений с БД
+type DBPoolStats struct {
+	AcquiredConns        int32
+	IdleConns            int32
+	TotalConns           int32
+	MaxConns             int32
+	AcquireCount         int64
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+	AcquireDuration      time.Duration
+}
+
+var (
+	dbPoolAcquiredConns = prometheus.NewDesc(
+		"db_pool_acquired_connections",
+		"Number of currently acquired connections in the pool",
+		nil, nil,
+	)
+	dbPoolIdleConns = prometheus.NewDesc(
+		"db_pool_idle_connections",
+		"Number of currently idle connections in the pool",
+		nil, nil,
+	)
+	dbPoolTotalConns = prometheus.NewDesc(
+		"db_pool_total_connections",
+		"Total number of connections currently in the pool",
+		nil, nil,
+	)
+	dbPoolMaxConns = prometheus.NewDesc(
+		"db_pool_max_connections",
+		"Maximum size of the pool",
+		nil, nil,
+	)
+	dbPoolAcquires = prometheus.NewDesc(
+		"db_pool_acquires_total",
+		"Cumulative count of successful acquires from the pool",
+		nil, nil,
+	)
+	dbPoolEmptyAcquires = prometheus.NewDesc(
+		"db_pool_empty_acquires_total",
+		"Cumulative count of acquires that waited for a connection because the pool was empty",
+		nil, nil,
+	)
+	dbPoolCanceledAcquires = prometheus.NewDesc(
+		"db_pool_canceled_acquires_total",
+		"Cumulative count of acquires canceled by a context",
+		nil, nil,
+	)
+	dbPoolAcquireWait = prometheus.NewDesc(
+		"db_pool_acquire_wait_seconds_total",
+		"Total time spent waiting to acquire connections from the pool",
+		nil, nil,
+	)
+)
+
+// dbPoolCollector снимает статистику пула в момент сбора метрик
+type dbPoolCollector struct {
+	stats func() DBPoolStats
+}
+
+// NewDBPoolCollector создает коллектор метрик пула соединений
+func NewDBPoolCollector(stats func() DBPoolStats) prometheus.Collector {
+	return &dbPoolCollector{stats: stats}
+}
+
+// RegisterDBPool регистрирует метрики пула соединений в глобальном реестре
+func RegisterDBPool(stats func() DBPoolStats) error {
+	return prometheus.Register(NewDBPoolCollector(stats))
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbPoolAcquiredConns
+	ch <- dbPoolIdleConns
+	ch <- dbPoolTotalConns
+	ch <- dbPoolMaxConns
+	ch <- dbPoolAcquires
+	ch <- dbPoolEmptyAcquires
+	ch <- dbPoolCanceledAcquires
+	ch <- dbPoolAcquireWait
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(dbPoolIdleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(dbPoolTotalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(dbPoolMaxConns, prometheus.GaugeValue, float64(s.MaxConns))
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquires, prometheus.CounterValue, float64(s.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(dbPoolEmptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount))
+	ch <- prometheus.MustNewConstMetric(dbPoolCanceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount))
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquireWait, prometheus.CounterValue, s.AcquireDuration.Seconds())
+}
@@ -47,6 +47,259 @@ var (
 			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5},
 		},
 	)
+
+	WriteQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "write_behind_queue_depth",
+			Help: "Number of rates waiting in the write-behind buffer",
+		},
+	)
+
+	WriteFlushLatency = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "write_behind_flush_latency_seconds",
+			Help:    "Latency of flushing a batch of rates to storage",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5},
+		},
+	)
+
+	WriteBatchSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "write_behind_batch_size",
+			Help:    "Number of rates in a flushed batch",
+			Buckets: []float64{1, 5, 10, 50, 100, 500, 1000},
+		},
+	)
+
+	WriteFlushErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "write_behind_flush_errors_total",
+			Help: "Total number of failed batch flushes",
+		},
+	)
+
+	WriteDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "write_behind_dropped_total",
+			Help: "Total number of buffered rates dropped after all flush retries failed",
+		},
+	)
+
+	WriteRejected = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "write_behind_rejected_total",
+			Help: "Total number of rates rejected because the write-behind buffer was full",
+		},
+	)
+
+	SpoolRecords = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "spool_records",
+			Help: "Number of rates waiting in the local spool",
+		},
+	)
+
+	SpoolBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "spool_size_bytes",
+			Help: "Size of the local spool on disk",
+		},
+	)
+
+	SpoolOldestAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "spool_oldest_record_age_seconds",
+			Help: "Age of the oldest rate waiting in the local spool",
+		},
+	)
+
+	SpoolWrites = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "spool_writes_total",
+			Help: "Total number of rates written to the local spool",
+		},
+	)
+
+	SpoolReplayed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "spool_replayed_total",
+			Help: "Total number of spooled rates replayed into the database",
+		},
+	)
+
+	SpoolRejected = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "spool_rejected_total",
+			Help: "Total number of spooled rates rejected by the database on replay and set aside",
+		},
+	)
+
+	SinkWrites = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sink_writes_total",
+			Help: "Total number of rate writes to storage sinks by result",
+		},
+		[]string{"sink", "result"},
+	)
+
+	SinkWriteLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sink_write_latency_seconds",
+			Help:    "Latency of writing rates to a storage sink",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5},
+		},
+		[]string{"sink"},
+	)
+
+	OutboxPublished = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "outbox_published_total",
+			Help: "Total number of rate events published from the outbox",
+		},
+	)
+
+	OutboxPublishErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "outbox_publish_errors_total",
+			Help: "Total number of failed outbox publish attempts",
+		},
+	)
+
+	OutboxLag = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_lag_seconds",
+			Help: "Age of the oldest unpublished event in the outbox",
+		},
+	)
+
+	AlertsFired = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_fired_total",
+			Help: "Total number of fired alert rules by condition",
+		},
+		[]string{"condition"},
+	)
+
+	AlertsSuppressed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "alerts_suppressed_total",
+			Help: "Total number of alerts suppressed by the cooldown window",
+		},
+	)
+
+	AlertDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alert_deliveries_total",
+			Help: "Total number of alert webhook deliveries by result",
+		},
+		[]string{"result"},
+	)
+
+	AlertRatesDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "alert_rates_dropped_total",
+			Help: "Total number of rates skipped because the alert queue was full",
+		},
+	)
+
+	RateAnomalies = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_anomalies_total",
+			Help: "Total number of quotes flagged by the anomaly detector by reason",
+		},
+		[]string{"reason"},
+	)
+
+	RatesQuarantined = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rates_quarantined_total",
+			Help: "Total number of quarantine writes by result",
+		},
+		[]string{"result"},
+	)
+
+	AnomalyWindowSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "anomaly_window_size",
+			Help: "Number of recent rates used as the anomaly detection baseline",
+		},
+	)
+
+	ConfigReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "Total number of configuration reloads by result",
+		},
+		[]string{"result"},
+	)
+
+	ConfigReloadRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reload_rejected_total",
+			Help: "Total number of changed settings rejected on reload because they require a restart",
+		},
+		[]string{"key"},
+	)
+
+	ConfigLastReload = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_success_timestamp_seconds",
+			Help: "Time of the last successful configuration reload",
+		},
+	)
+
+	SecretRefreshes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "secret_refreshes_total",
+			Help: "Total number of database credential checks by result",
+		},
+		[]string{"result"},
+	)
+
+	DBPoolRebuilds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_pool_rebuilds_total",
+			Help: "Total number of connection pool rebuilds after credential rotation by result",
+		},
+		[]string{"result"},
+	)
+
+	PartitionOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rates_partition_operations_total",
+			Help: "Total number of rates partition operations by operation (create, archive, detach, drop) and result",
+		},
+		[]string{"operation", "result"},
+	)
+
+	PartitionCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rates_partitions",
+			Help: "Number of monthly partitions attached to the rates table",
+		},
+	)
+
+	PartitionArchivedRows = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rates_partition_archived_rows_total",
+			Help: "Total number of rates written to partition archives",
+		},
+	)
+
+	PartitionLastMaintenance = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rates_partition_last_maintenance_success_timestamp_seconds",
+			Help: "Unix time of the last partition maintenance run without errors",
+		},
+	)
+
+	RatesExported = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rates_exported_total",
+			Help: "Total number of rates streamed by ExportRates by format",
+		},
+		[]string{"format"},
+	)
 )
 
 func init() {
@@ -55,6 +308,40 @@ func init() {
 	prometheus.MustRegister(binanceAPIRequests)
 	prometheus.MustRegister(DBSaves)
 	prometheus.MustRegister(DBSaveLatency)
+	prometheus.MustRegister(WriteQueueDepth)
+	prometheus.MustRegister(WriteFlushLatency)
+	prometheus.MustRegister(WriteBatchSize)
+	prometheus.MustRegister(WriteFlushErrors)
+	prometheus.MustRegister(WriteDropped)
+	prometheus.MustRegister(WriteRejected)
+	prometheus.MustRegister(SpoolRecords)
+	prometheus.MustRegister(SpoolBytes)
+	prometheus.MustRegister(SpoolOldestAge)
+	prometheus.MustRegister(SpoolWrites)
+	prometheus.MustRegister(SpoolReplayed)
+	prometheus.MustRegister(SpoolRejected)
+	prometheus.MustRegister(SinkWrites)
+	prometheus.MustRegister(SinkWriteLatency)
+	prometheus.MustRegister(OutboxPublished)
+	prometheus.MustRegister(OutboxPublishErrors)
+	prometheus.MustRegister(OutboxLag)
+	prometheus.MustRegister(AlertsFired)
+	prometheus.MustRegister(AlertsSuppressed)
+	prometheus.MustRegister(AlertDeliveries)
+	prometheus.MustRegister(AlertRatesDropped)
+	prometheus.MustRegister(RateAnomalies)
+	prometheus.MustRegister(RatesQuarantined)
+	prometheus.MustRegister(AnomalyWindowSize)
+	prometheus.MustRegister(ConfigReloads)
+	prometheus.MustRegister(ConfigReloadRejected)
+	prometheus.MustRegister(ConfigLastReload)
+	prometheus.MustRegister(SecretRefreshes)
+	prometheus.MustRegister(DBPoolRebuilds)
+	prometheus.MustRegister(PartitionOperations)
+	prometheus.MustRegister(PartitionCount)
+	prometheus.MustRegister(PartitionArchivedRows)
+	prometheus.MustRegister(PartitionLastMaintenance)
+	prometheus.MustRegister(RatesExported)
 }
 
 // ExposeMetrics - экспозиция метрик через HTTP
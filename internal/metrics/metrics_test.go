@@ -3,7 +3,9 @@ package metrics
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
@@ -157,3 +159,33 @@ func TestHistogramMetrics(_ *testing.T) {
 	RateExchangeLatency.WithLabelValues("test").Observe(0.1)
 	DBSaveLatency.Observe(0.2)
 }
+
+func TestDBPoolCollector(t *testing.T) {
+	collector := NewDBPoolCollector(func() DBPoolStats {
+		return DBPoolStats{
+			AcquiredConns:     2,
+			IdleConns:         3,
+			TotalConns:        5,
+			MaxConns:          10,
+			AcquireCount:      42,
+			EmptyAcquireCount: 4,
+			AcquireDuration:   1500 * time.Millisecond,
+		}
+	})
+
+	expected := `
+# HELP db_pool_acquired_connections Number of currently acquired connections in the pool
+# TYPE db_pool_acquired_connections gauge
+db_pool_acquired_connections 2
+# HELP db_pool_idle_connections Number of currently idle connections in the pool
+# TYPE db_pool_idle_connections gauge
+db_pool_idle_connections 3
+# HELP db_pool_acquire_wait_seconds_total Total time spent waiting to acquire connections from the pool
+# TYPE db_pool_acquire_wait_seconds_total counter
+db_pool_acquire_wait_seconds_total 1.5
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"db_pool_acquired_connections", "db_pool_idle_connections", "db_pool_acquire_wait_seconds_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, testutil.CollectAndCount(collector))
+}
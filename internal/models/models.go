@@ -12,8 +12,54 @@ type Rate struct {
 	Time      time.Time `json:"timestamp"` // Время получения курса
 }
 
+// Analytics производные показатели курса
+type Analytics struct {
+	Mid       float64 // Средняя цена (ask + bid) / 2
+	Spread    float64 // Спред ask - bid
+	SpreadBps float64 // Спред в базисных пунктах от средней цены
+	Imbalance float64 // Дисбаланс стакана (bid_amount - ask_amount) / (bid_amount + ask_amount), от -1 до 1
+}
+
+// Analytics вычисляет производные показатели курса.
+// Те же формулы используются в вычисляемых колонках таблицы rates.
+func (r Rate) Analytics() Analytics {
+	a := Analytics{
+		Mid:    (r.Ask + r.Bid) / 2,
+		Spread: r.Ask - r.Bid,
+	}
+	if a.Mid > 0 {
+		a.SpreadBps = a.Spread / a.Mid * 10000
+	}
+	if total := r.BidAmount + r.AskAmount; total > 0 {
+		a.Imbalance = (r.BidAmount - r.AskAmount) / total
+	}
+	return a
+}
+
 type BinanceDepthResponse struct {
 	LastUpdateID int64      `json:"lastUpdateId"`
 	Bids         [][]string `json:"bids"`
 	Asks         [][]string `json:"asks"`
 }
+
+// AlertCondition условие срабатывания правила оповещения
+type AlertCondition string
+
+const (
+	AlertPriceAbove  AlertCondition = "price_above"  // Средняя цена не ниже порога
+	AlertPriceBelow  AlertCondition = "price_below"  // Средняя цена не выше порога
+	AlertSpreadAbove AlertCondition = "spread_above" // Спред ask - bid не ниже порога
+)
+
+type AlertRule struct {
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	Condition   AlertCondition `json:"condition"`
+	Threshold   float64        `json:"threshold"`     // Порог в USDT
+	WebhookURL  string         `json:"webhook_url"`   // Адрес доставки оповещения
+	Cooldown    time.Duration  `json:"cooldown"`      // Минимальный интервал между оповещениями
+	Enabled     bool           `json:"enabled"`       // Выключенные правила не проверяются
+	LastFiredAt time.Time      `json:"last_fired_at"` // Нулевое значение - правило не срабатывало
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRate_Analytics(t *testing.T) {
+	tests := []struct {
+		name string
+		rate Rate
+		want Analytics
+	}{
+		{
+			name: "regular book",
+			rate: Rate{Ask: 101, Bid: 99, AskAmount: 1, BidAmount: 3},
+			want: Analytics{Mid: 100, Spread: 2, SpreadBps: 200, Imbalance: 0.5},
+		},
+		{
+			name: "ask side heavier",
+			rate: Rate{Ask: 100.5, Bid: 99.5, AskAmount: 3, BidAmount: 1},
+			want: Analytics{Mid: 100, Spread: 1, SpreadBps: 100, Imbalance: -0.5},
+		},
+		{
+			name: "empty book",
+			rate: Rate{},
+			want: Analytics{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rate.Analytics()
+			assert.InDelta(t, tt.want.Mid, got.Mid, 1e-9)
+			assert.InDelta(t, tt.want.Spread, got.Spread, 1e-9)
+			assert.InDelta(t, tt.want.SpreadBps, got.SpreadBps, 1e-9)
+			assert.InDelta(t, tt.want.Imbalance, got.Imbalance, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package outbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/storage"
+)
+
+// Поддерживаемые получатели событий
+const (
+	PublisherFile    = "file"
+	PublisherWebhook = "webhook"
+	PublisherNATS    = "nats"
+)
+
+// IdempotencyHeader заголовок с ключом идемпотентности события
+const IdempotencyHeader = "Idempotency-Key"
+
+// defaultTimeout таймаут публикации, если контекст его не задает
+const defaultTimeout = 5 * time.Second
+
+// fileRecord строка файла событий
+type fileRecord struct {
+	IdempotencyKey string          `json:"idempotency_key"`
+	Topic          string          `json:"topic"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// FilePublisher дописывает события в локальный файл JSON Lines
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePublisher открывает (или создает) файл событий
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create outbox dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox file: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event storage.OutboxEvent) error {
+	line, err := json.Marshal(fileRecord{
+		IdempotencyKey: event.IdempotencyKey,
+		Topic:          event.Topic,
+		CreatedAt:      event.CreatedAt,
+		Payload:        event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// Событие считается доставленным только после сброса на диск
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// WebhookPublisher отправляет каждое событие POST-запросом
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookPublisher создает отправителя событий на url
+func NewWebhookPublisher(url string, client *http.Client) *WebhookPublisher {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &WebhookPublisher{url: url, client: client}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event storage.OutboxEvent) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(event.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyHeader, event.IdempotencyKey)
+	req.Header.Set("X-Event-Topic", event.Topic)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned status: %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *WebhookPublisher) Close() error {
+	return nil
+}
+
+// NATSPublisher публикует события по текстовому протоколу NATS.
+// Ключ идемпотентности передается заголовком Nats-Msg-Id, по которому JetStream отбрасывает дубли.
+// После каждой публикации выполняется PING/PONG, чтобы убедиться, что сервер принял сообщение.
+type NATSPublisher struct {
+	addr string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSPublisher создает публикатор; соединение устанавливается при первой публикации
+func NewNATSPublisher(addr string) *NATSPublisher {
+	return &NATSPublisher{addr: strings.TrimPrefix(addr, "nats://")}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event storage.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, event); err != nil {
+		// После любой ошибки состояние протокола неизвестно, переподключаемся при следующей публикации
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) publish(ctx context.Context, event storage.OutboxEvent) error {
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	header := "NATS/1.0\r\nNats-Msg-Id: " + event.IdempotencyKey + "\r\n\r\n"
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "HPUB %s %d %d\r\n", event.Topic, len(header), len(header)+len(event.Payload))
+	msg.WriteString(header)
+	msg.Write(event.Payload)
+	msg.WriteString("\r\nPING\r\n")
+
+	if _, err := p.conn.Write(msg.Bytes()); err != nil {
+		return fmt.Errorf("nats write: %w", err)
+	}
+	return p.waitPong()
+}
+
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: defaultTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("nats connect: %w", err)
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if err := conn.SetDeadline(time.Now().Add(defaultTimeout)); err != nil {
+		return err
+	}
+
+	// Сервер первым присылает INFO
+	line, err := p.readLine()
+	if err != nil {
+		return fmt.Errorf("nats handshake: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("nats handshake: unexpected %q", line)
+	}
+
+	const connect = `CONNECT {"verbose":false,"pedantic":false,"headers":true,"name":"usdt-outbox"}` + "\r\n"
+	if _, err := conn.Write([]byte(connect)); err != nil {
+		return fmt.Errorf("nats handshake: %w", err)
+	}
+	return nil
+}
+
+func (p *NATSPublisher) waitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return fmt.Errorf("nats read: %w", err)
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("nats write: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *NATSPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *NATSPublisher) closeConn() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+		p.conn = nil
+		p.reader = nil
+	}
+}
+
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConn()
+	return nil
+}
+
+// NewPublisher создает получателя событий по имени
+func NewPublisher(kind, target string) (Publisher, error) {
+	if strings.TrimSpace(target) == "" {
+		return nil, errors.New("outbox target cannot be empty")
+	}
+	switch kind {
+	case PublisherFile:
+		return NewFilePublisher(target)
+	case PublisherWebhook:
+		return NewWebhookPublisher(target, nil), nil
+	case PublisherNATS:
+		return NewNATSPublisher(target), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", kind)
+	}
+}
@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gRPC-USDT/internal/storage"
+)
+
+func testEvent() storage.OutboxEvent {
+	return storage.OutboxEvent{
+		ID:             1,
+		IdempotencyKey: "0123456789abcdef",
+		Topic:          "usdt.rates",
+		Payload:        []byte(`{"ask":100.5}`),
+		CreatedAt:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events", "rates.jsonl")
+	publisher, err := NewFilePublisher(path)
+	require.NoError(t, err)
+
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	require.NoError(t, publisher.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var record fileRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "0123456789abcdef", record.IdempotencyKey)
+	assert.Equal(t, "usdt.rates", record.Topic)
+	assert.JSONEq(t, `{"ask":100.5}`, string(record.Payload))
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var (
+		gotKey  string
+		gotBody []byte
+		status  = http.StatusAccepted
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get(IdempotencyHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher(server.URL, nil)
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	assert.Equal(t, "0123456789abcdef", gotKey)
+	assert.JSONEq(t, `{"ask":100.5}`, string(gotBody))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, publisher.Publish(context.Background(), testEvent()), "503")
+}
+
+// natsStub - минимальный сервер NATS: отвечает INFO, принимает HPUB и отвечает на PING
+func natsStub(t *testing.T, messages chan<- string) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = lis.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_, _ = conn.Write([]byte(`INFO {"headers":true}` + "\r\n"))
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimRight(line, "\r\n")
+					switch {
+					case line == "PING":
+						_, _ = conn.Write([]byte("PONG\r\n"))
+					case strings.HasPrefix(line, "HPUB "):
+						var subject string
+						var hdrLen, total int
+						_, _ = fmt.Sscanf(line, "HPUB %s %d %d", &subject, &hdrLen, &total)
+						buf := make([]byte, total+2)
+						if _, err := io.ReadFull(r, buf); err != nil {
+							return
+						}
+						messages <- subject + "|" + string(buf[:total])
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return "nats://" + lis.Addr().String()
+}
+
+func TestNATSPublisher(t *testing.T) {
+	messages := make(chan string, 2)
+	publisher := NewNATSPublisher(natsStub(t, messages))
+	defer publisher.Close()
+
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+
+	msg := <-messages
+	assert.Equal(t, "usdt.rates|NATS/1.0\r\nNats-Msg-Id: 0123456789abcdef\r\n\r\n{\"ask\":100.5}", msg)
+	<-messages
+}
+
+func TestNATSPublisher_Unavailable(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	publisher := NewNATSPublisher(addr)
+	assert.ErrorContains(t, publisher.Publish(context.Background(), testEvent()), "nats connect")
+}
+
+func TestNewPublisher(t *testing.T) {
+	_, err := NewPublisher(PublisherWebhook, "")
+	assert.Error(t, err)
+
+	_, err = NewPublisher("kafka", "localhost:9092")
+	assert.ErrorContains(t, err, "unknown outbox publisher")
+
+	p, err := NewPublisher(PublisherNATS, "nats://localhost:4222")
+	require.NoError(t, err)
+	assert.IsType(t, &NATSPublisher{}, p)
+}
@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// Publisher доставляет событие во внешнюю систему.
+// Успешный возврат означает, что получатель принял событие.
+type Publisher interface {
+	Publish(ctx context.Context, event storage.OutboxEvent) error
+	Close() error
+}
+
+// Store источник событий outbox
+type Store interface {
+	FetchOutbox(ctx context.Context, limit int) ([]storage.OutboxEvent, error)
+	AckOutbox(ctx context.Context, ids []int64) error
+	FailOutbox(ctx context.Context, id int64, cause error) error
+}
+
+// Relay переносит события из таблицы outbox в Publisher.
+// Доставка "как минимум один раз": событие удаляется только после успешной публикации,
+// поэтому после сбоя между публикацией и удалением оно будет отправлено повторно
+// с тем же ключом идемпотентности. Порядок сохраняется: при ошибке раунд прерывается.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	logger    *zap.Logger
+	batchSize int
+}
+
+// NewRelay создает ретранслятор событий
+func NewRelay(store Store, publisher Publisher, logger *zap.Logger, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		logger:    logger,
+		batchSize: batchSize,
+	}
+}
+
+// Run публикует события каждые interval до отмены контекста
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Warn("Outbox relay round failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush публикует все накопленные события и возвращает их количество
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := r.store.FetchOutbox(ctx, r.batchSize)
+		if err != nil {
+			return published, err
+		}
+		if len(events) == 0 {
+			metrics.OutboxLag.Set(0)
+			return published, nil
+		}
+		metrics.OutboxLag.Set(time.Since(events[0].CreatedAt).Seconds())
+
+		n, err := r.publish(ctx, events)
+		published += n
+		if err != nil {
+			return published, err
+		}
+		if len(events) < r.batchSize {
+			metrics.OutboxLag.Set(0)
+			return published, nil
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, events []storage.OutboxEvent) (int, error) {
+	acked := make([]int64, 0, len(events))
+
+	var publishErr error
+	for _, event := range events {
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			metrics.OutboxPublishErrors.Inc()
+			if ferr := r.store.FailOutbox(ctx, event.ID, err); ferr != nil {
+				r.logger.Warn("Failed to record outbox failure", zap.Int64("id", event.ID), zap.Error(ferr))
+			}
+			publishErr = err
+			break
+		}
+		acked = append(acked, event.ID)
+	}
+
+	if err := r.store.AckOutbox(ctx, acked); err != nil {
+		// События будут опубликованы повторно, потребители отбросят их по ключу
+		return 0, err
+	}
+	metrics.OutboxPublished.Add(float64(len(acked)))
+
+	return len(acked), publishErr
+}
+
+// Close закрывает соединение с получателем
+func (r *Relay) Close() error {
+	return r.publisher.Close()
+}
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gRPC-USDT/internal/storage"
+)
+
+// fakeStore - таблица outbox в памяти
+type fakeStore struct {
+	mu       sync.Mutex
+	events   []storage.OutboxEvent
+	failures map[int64]int
+	ackErr   error
+}
+
+func newFakeStore(n int) *fakeStore {
+	s := &fakeStore{failures: map[int64]int{}}
+	for i := 1; i <= n; i++ {
+		s.events = append(s.events, storage.OutboxEvent{
+			ID:             int64(i),
+			IdempotencyKey: string(rune('a' + i - 1)),
+			Topic:          "usdt.rates",
+			CreatedAt:      time.Now(),
+		})
+	}
+	return s
+}
+
+func (s *fakeStore) FetchOutbox(_ context.Context, limit int) ([]storage.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if limit > len(s.events) {
+		limit = len(s.events)
+	}
+	return append([]storage.OutboxEvent(nil), s.events[:limit]...), nil
+}
+
+func (s *fakeStore) AckOutbox(_ context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ackErr != nil {
+		return s.ackErr
+	}
+	acked := map[int64]bool{}
+	for _, id := range ids {
+		acked[id] = true
+	}
+	kept := s.events[:0]
+	for _, e := range s.events {
+		if !acked[e.ID] {
+			kept = append(kept, e)
+		}
+	}
+	s.events = kept
+	return nil
+}
+
+func (s *fakeStore) FailOutbox(_ context.Context, id int64, _ error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[id]++
+	return nil
+}
+
+// fakePublisher запоминает ключи опубликованных событий
+type fakePublisher struct {
+	mu     sync.Mutex
+	keys   []string
+	failOn string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, event storage.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if event.IdempotencyKey == p.failOn {
+		return errors.New("broker unavailable")
+	}
+	p.keys = append(p.keys, event.IdempotencyKey)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+func TestRelay_FlushInOrder(t *testing.T) {
+	store := newFakeStore(5)
+	publisher := &fakePublisher{}
+
+	relay := NewRelay(store, publisher, zap.NewNop(), 2)
+	n, err := relay.Flush(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, n)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, publisher.keys)
+	assert.Empty(t, store.events)
+}
+
+func TestRelay_StopsOnFailureAndRetries(t *testing.T) {
+	store := newFakeStore(4)
+	publisher := &fakePublisher{failOn: "c"}
+
+	relay := NewRelay(store, publisher, zap.NewNop(), 10)
+	n, err := relay.Flush(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, store.failures[3])
+	// Событие "d" не публикуется раньше "c"
+	assert.Equal(t, []string{"a", "b"}, publisher.keys)
+	assert.Len(t, store.events, 2)
+
+	publisher.failOn = ""
+	n, err = relay.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, publisher.keys)
+}
+
+func TestRelay_AckFailureRepublishes(t *testing.T) {
+	store := newFakeStore(1)
+	store.ackErr = errors.New("db is down")
+	publisher := &fakePublisher{}
+
+	relay := NewRelay(store, publisher, zap.NewNop(), 10)
+	_, err := relay.Flush(context.Background())
+	assert.Error(t, err)
+
+	store.ackErr = nil
+	_, err = relay.Flush(context.Background())
+	require.NoError(t, err)
+
+	// Доставка "как минимум один раз": потребитель отбросит дубль по ключу
+	assert.Equal(t, []string{"a", "a"}, publisher.keys)
+}
+
+func TestRelay_Run(t *testing.T) {
+	store := newFakeStore(3)
+	publisher := &fakePublisher{}
+	relay := NewRelay(store, publisher, zap.NewNop(), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.events) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
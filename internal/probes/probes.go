@@ -0,0 +1,189 @@
+package probes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Условия готовности сервиса
+const (
+	Migrations = "migrations"
+	Database   = "database"
+	Exchange   = "exchange"
+)
+
+// Status хранит состояние liveness и readiness сервиса.
+// Сервис готов, когда выполнены все зарегистрированные условия.
+type Status struct {
+	mu         sync.RWMutex
+	conditions map[string]error
+	fatal      error
+	listeners  []func(ready bool)
+
+	// notifyMu упорядочивает оповещения: готовность вычисляется и рассылается под одной блокировкой,
+	// поэтому последним обработчики получают актуальное состояние
+	notifyMu sync.Mutex
+}
+
+var errPending = errors.New("pending")
+
+// New создает статус с набором условий, изначально не выполненных
+func New(conditions ...string) *Status {
+	s := &Status{conditions: make(map[string]error, len(conditions))}
+	for _, c := range conditions {
+		s.conditions[c] = errPending
+	}
+	return s
+}
+
+// SetReady отмечает условие выполненным
+func (s *Status) SetReady(condition string) {
+	s.set(condition, nil)
+}
+
+// SetNotReady отмечает условие невыполненным с указанием причины
+func (s *Status) SetNotReady(condition string, reason error) {
+	if reason == nil {
+		reason = errPending
+	}
+	s.set(condition, reason)
+}
+
+// Fail переводит сервис в нерабочее состояние: liveness-проба начинает возвращать ошибку
+func (s *Status) Fail(err error) {
+	s.mu.Lock()
+	s.fatal = err
+	s.mu.Unlock()
+	s.notify()
+}
+
+// OnChange регистрирует обработчик изменения готовности. Обработчик сразу вызывается с текущим состоянием.
+// Обработчики вызываются последовательно и не должны менять статус.
+func (s *Status) OnChange(fn func(ready bool)) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.mu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.mu.Unlock()
+	fn(s.Ready())
+}
+
+// Ready сообщает, выполнены ли все условия готовности
+func (s *Status) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.fatal != nil {
+		return false
+	}
+	for _, err := range s.conditions {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Alive возвращает ошибку, если сервис не может продолжать работу
+func (s *Status) Alive() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fatal
+}
+
+// Pending возвращает невыполненные условия с причинами
+func (s *Status) Pending() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pending := make(map[string]string)
+	for c, err := range s.conditions {
+		if err != nil {
+			pending[c] = err.Error()
+		}
+	}
+	return pending
+}
+
+// Watch периодически выполняет check и обновляет условие до отмены контекста
+func (s *Status) Watch(ctx context.Context, condition string, interval time.Duration, check func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := check(ctx); err != nil {
+			s.SetNotReady(condition, err)
+		} else {
+			s.SetReady(condition)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LivezHandler - HTTP обработчик liveness-пробы
+func (s *Status) LivezHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err := s.Alive(); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "failed", "error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	})
+}
+
+// ReadyzHandler - HTTP обработчик readiness-пробы
+func (s *Status) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if s.Ready() {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ready"})
+			return
+		}
+
+		body := map[string]interface{}{"status": "not ready", "pending": s.Pending()}
+		if err := s.Alive(); err != nil {
+			body["error"] = err.Error()
+		}
+		writeJSON(w, http.StatusServiceUnavailable, body)
+	})
+}
+
+func (s *Status) set(condition string, err error) {
+	s.mu.Lock()
+	prev, known := s.conditions[condition]
+	s.conditions[condition] = err
+	s.mu.Unlock()
+
+	if !known || (prev == nil) != (err == nil) {
+		s.notify()
+	}
+}
+
+func (s *Status) notify() {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	ready := s.Ready()
+
+	s.mu.RLock()
+	listeners := make([]func(bool), len(s.listeners))
+	copy(listeners, s.listeners)
+	s.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(ready)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
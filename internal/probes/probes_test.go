@@ -0,0 +1,136 @@
+package probes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_Ready(t *testing.T) {
+	status := New(Migrations, Database)
+	assert.False(t, status.Ready())
+	assert.Len(t, status.Pending(), 2)
+
+	status.SetReady(Migrations)
+	assert.False(t, status.Ready())
+
+	status.SetReady(Database)
+	assert.True(t, status.Ready())
+
+	status.SetNotReady(Database, errors.New("connection refused"))
+	assert.False(t, status.Ready())
+	assert.Equal(t, map[string]string{Database: "connection refused"}, status.Pending())
+}
+
+func TestStatus_Fail(t *testing.T) {
+	status := New()
+	assert.True(t, status.Ready())
+	assert.NoError(t, status.Alive())
+
+	status.Fail(errors.New("serve failed"))
+	assert.False(t, status.Ready())
+	assert.EqualError(t, status.Alive(), "serve failed")
+}
+
+func TestStatus_OnChange(t *testing.T) {
+	status := New(Exchange)
+
+	var changes []bool
+	status.OnChange(func(ready bool) { changes = append(changes, ready) })
+
+	status.SetReady(Exchange)
+	status.SetReady(Exchange) // повторная отметка не вызывает обработчик
+	status.SetNotReady(Exchange, nil)
+
+	assert.Equal(t, []bool{false, true, false}, changes)
+}
+
+func TestStatus_OnChangeConcurrent(t *testing.T) {
+	status := New(Database, Exchange)
+
+	// Обработчики вызываются последовательно, поэтому запись без блокировки безопасна
+	var last bool
+	status.OnChange(func(ready bool) { last = ready })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			status.SetReady(Database)
+			status.SetNotReady(Database, nil)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				status.SetReady(Exchange)
+			} else {
+				status.SetNotReady(Exchange, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	status.SetReady(Exchange)
+	status.SetReady(Database)
+
+	// Последнее оповещение соответствует итоговому состоянию
+	assert.True(t, status.Ready())
+	assert.True(t, last)
+}
+
+func TestStatus_Watch(t *testing.T) {
+	status := New(Database)
+
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		status.Watch(ctx, Database, 10*time.Millisecond, func(context.Context) error {
+			if calls.Add(1) == 1 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		close(done)
+	}()
+
+	require.Eventually(t, status.Ready, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestHandlers(t *testing.T) {
+	status := New(Exchange)
+
+	get := func(h http.Handler) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, _ := get(status.LivezHandler())
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body := get(status.ReadyzHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body["pending"], Exchange)
+
+	status.SetReady(Exchange)
+	code, _ = get(status.ReadyzHandler())
+	assert.Equal(t, http.StatusOK, code)
+
+	status.Fail(errors.New("boom"))
+	code, _ = get(status.LivezHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -0,0 +1,146 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/metrics"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Loader заново читает и проверяет конфигурацию
+type Loader func() (config.Config, error)
+
+// Applier применяет к компоненту параметры, изменяемые без перезапуска.
+// Вызывается после каждой перезагрузки, изменившей хотя бы один такой параметр.
+type Applier func(cfg config.Config)
+
+// Reloader перечитывает конфигурацию по SIGHUP и при изменении файла конфигурации.
+// Параметры, изменяемые без перезапуска, атомарно заменяются и передаются подписчикам;
+// изменения остальных параметров отклоняются с предупреждением в логе.
+type Reloader struct {
+	logger   *zap.Logger
+	load     Loader
+	appliers []Applier
+
+	mu      sync.Mutex // Исключает параллельные перезагрузки
+	current atomic.Pointer[config.Config]
+}
+
+// New создает Reloader с действующей конфигурацией initial
+func New(logger *zap.Logger, load Loader, initial config.Config) *Reloader {
+	r := &Reloader{logger: logger, load: load}
+	r.current.Store(&initial)
+	return r
+}
+
+// Subscribe добавляет подписчика на изменения. Вызывается до Run.
+func (r *Reloader) Subscribe(apply Applier) {
+	r.appliers = append(r.appliers, apply)
+}
+
+// Current возвращает действующую конфигурацию
+func (r *Reloader) Current() config.Config {
+	return *r.current.Load()
+}
+
+// Reload перечитывает конфигурацию и применяет изменения, допустимые без перезапуска.
+// При ошибке загрузки или проверки действующая конфигурация не меняется.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated, err := r.load()
+	if err != nil {
+		metrics.ConfigReloads.WithLabelValues("error").Inc()
+		r.logger.Error("Configuration reload failed, keeping current settings", zap.Error(err))
+		return err
+	}
+
+	current := r.Current()
+	runtime, restart := config.Changes(current, updated)
+	if len(restart) > 0 {
+		for _, key := range restart {
+			metrics.ConfigReloadRejected.WithLabelValues(key).Inc()
+		}
+		r.logger.Warn("Configuration changes require a restart and were not applied", zap.Strings("keys", restart))
+	}
+
+	// Изменились только параметры, требующие перезапуска: применять нечего
+	if len(runtime) == 0 && len(restart) > 0 {
+		metrics.ConfigReloads.WithLabelValues("rejected").Inc()
+		r.logger.Warn("Configuration reload rejected, nothing was applied")
+		return nil
+	}
+
+	if len(runtime) > 0 {
+		next := config.ApplyRuntime(current, updated)
+		r.current.Store(&next)
+		for _, apply := range r.appliers {
+			apply(next)
+		}
+	}
+
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+	metrics.ConfigLastReload.SetToCurrentTime()
+	r.logger.Info("Configuration reloaded", zap.Strings("applied", runtime))
+	return nil
+}
+
+// Run перезагружает конфигурацию по SIGHUP и, если задан path и interval > 0,
+// при изменении времени модификации или размера файла. Возвращается при отмене ctx.
+func (r *Reloader) Run(ctx context.Context, path string, interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time
+	var last os.FileInfo
+	if path != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		last, _ = os.Stat(path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			r.logger.Info("Received SIGHUP, reloading configuration")
+			_ = r.Reload()
+		case <-tick:
+			info, err := os.Stat(path)
+			// Редакторы заменяют файл переименованием, поэтому его временное отсутствие не ошибка
+			if err != nil || !changed(last, info) {
+				continue
+			}
+			last = info
+			r.logger.Info("Configuration file changed, reloading", zap.String("path", path))
+			_ = r.Reload()
+		}
+	}
+}
+
+func changed(prev, next os.FileInfo) bool {
+	return prev == nil || !prev.ModTime().Equal(next.ModTime()) || prev.Size() != next.Size()
+}
+
+// LogLevel возвращает подписчика, меняющего уровень логирования
+func LogLevel(level zap.AtomicLevel) Applier {
+	return func(cfg config.Config) {
+		// Уровень уже проверен при загрузке конфигурации
+		if l, err := zapcore.ParseLevel(cfg.LogLevel); err == nil {
+			level.SetLevel(l)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/metrics"
+)
+
+// stubLoader возвращает заданную конфигурацию и считает вызовы
+type stubLoader struct {
+	mu    sync.Mutex
+	cfg   config.Config
+	err   error
+	calls int
+}
+
+func (l *stubLoader) load() (config.Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	return l.cfg, l.err
+}
+
+func (l *stubLoader) set(cfg config.Config, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg, l.err = cfg, err
+}
+
+func (l *stubLoader) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls
+}
+
+func initialConfig() config.Config {
+	return config.Config{
+		LogLevel:              "info",
+		BinanceAPIURL:         "http://old.api",
+		ExchangeRetryInterval: 5 * time.Second,
+		GRPCPort:              50051,
+	}
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Run("applies runtime settings", func(t *testing.T) {
+		loader := &stubLoader{}
+		r := New(zap.NewNop(), loader.load, initialConfig())
+		var applied []config.Config
+		r.Subscribe(func(cfg config.Config) { applied = append(applied, cfg) })
+
+		updated := initialConfig()
+		updated.BinanceAPIURL = "http://new.api"
+		updated.ExchangeRetryInterval = time.Second
+		loader.set(updated, nil)
+
+		require.NoError(t, r.Reload())
+		require.Len(t, applied, 1)
+		assert.Equal(t, "http://new.api", applied[0].BinanceAPIURL)
+		assert.Equal(t, updated, r.Current())
+	})
+
+	t.Run("rejects restart-only settings", func(t *testing.T) {
+		loader := &stubLoader{}
+		r := New(zap.NewNop(), loader.load, initialConfig())
+		calls := 0
+		r.Subscribe(func(config.Config) { calls++ })
+
+		updated := initialConfig()
+		updated.GRPCPort = 6000
+		updated.LogLevel = "debug"
+		loader.set(updated, nil)
+
+		require.NoError(t, r.Reload())
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 50051, r.Current().GRPCPort)
+		assert.Equal(t, "debug", r.Current().LogLevel)
+	})
+
+	t.Run("only restart-only settings", func(t *testing.T) {
+		loader := &stubLoader{}
+		r := New(zap.NewNop(), loader.load, initialConfig())
+		r.Subscribe(func(config.Config) { t.Fatal("unexpected apply") })
+
+		updated := initialConfig()
+		updated.GRPCPort = 6000
+		loader.set(updated, nil)
+
+		rejected := testutil.ToFloat64(metrics.ConfigReloads.WithLabelValues("rejected"))
+		success := testutil.ToFloat64(metrics.ConfigReloads.WithLabelValues("success"))
+		require.NoError(t, r.Reload())
+		assert.Equal(t, rejected+1, testutil.ToFloat64(metrics.ConfigReloads.WithLabelValues("rejected")))
+		assert.Equal(t, success, testutil.ToFloat64(metrics.ConfigReloads.WithLabelValues("success")))
+		assert.Equal(t, initialConfig(), r.Current())
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		loader := &stubLoader{cfg: initialConfig()}
+		r := New(zap.NewNop(), loader.load, initialConfig())
+		r.Subscribe(func(config.Config) { t.Fatal("unexpected apply") })
+
+		require.NoError(t, r.Reload())
+	})
+
+	t.Run("invalid configuration keeps current", func(t *testing.T) {
+		loader := &stubLoader{}
+		r := New(zap.NewNop(), loader.load, initialConfig())
+		r.Subscribe(func(config.Config) { t.Fatal("unexpected apply") })
+
+		loader.set(config.Config{}, errors.New("invalid configuration: app.log_level: unknown level"))
+		assert.ErrorContains(t, r.Reload(), "unknown level")
+		assert.Equal(t, initialConfig(), r.Current())
+	})
+}
+
+func TestReloader_RunFileChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("a: 1\n"), 0o600))
+
+	loader := &stubLoader{cfg: initialConfig()}
+	r := New(zap.NewNop(), loader.load, initialConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, path, 10*time.Millisecond)
+	}()
+
+	// Без изменений файла перезагрузки нет
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, loader.count())
+
+	require.NoError(t, os.WriteFile(path, []byte("a: 12\n"), 0o600))
+	require.Eventually(t, func() bool { return loader.count() == 1 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestReloader_RunSIGHUP(t *testing.T) {
+	// Собственная подписка не дает сигналу завершить процесс, пока Run не начал его слушать
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	loader := &stubLoader{cfg: initialConfig()}
+	r := New(zap.NewNop(), loader.load, initialConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, "", 0)
+	}()
+
+	require.Eventually(t, func() bool {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+		return loader.count() > 0
+	}, time.Second, 20*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestLogLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	apply := LogLevel(level)
+
+	apply(config.Config{LogLevel: "debug"})
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	apply(config.Config{LogLevel: "bogus"})
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
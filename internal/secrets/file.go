@@ -0,0 +1,35 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileProvider читает поля секрета из файлов, например смонтированных секретов Docker и Kubernetes.
+// Файлы перечитываются при каждом обращении, поэтому замененный при ротации файл виден без перезапуска.
+type FileProvider struct {
+	files map[string]string // Поле секрета -> путь к файлу
+}
+
+// NewFileProvider создает провайдер; files сопоставляет полям секрета (FieldUsername, FieldPassword) пути к файлам
+func NewFileProvider(files map[string]string) *FileProvider {
+	return &FileProvider{files: files}
+}
+
+// Get возвращает содержимое файлов без завершающего перевода строки; путь секрета не используется
+func (p *FileProvider) Get(ctx context.Context, _ string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(p.files))
+	for field, path := range p.files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", field, err)
+		}
+		fields[field] = strings.TrimRight(string(data), "\r\n")
+	}
+	return fields, nil
+}
@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider_Get(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	password := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(password, []byte("p1\n"), 0o600))
+
+	provider := NewFileProvider(map[string]string{FieldPassword: password})
+	fields, err := provider.Get(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{FieldPassword: "p1"}, fields)
+
+	// Замененный файл читается при следующем обращении
+	require.NoError(t, os.WriteFile(password, []byte("p2\r\n"), 0o600))
+	fields, err = provider.Get(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "p2", fields[FieldPassword])
+
+	_, err = NewFileProvider(map[string]string{FieldUsername: filepath.Join(dir, "missing")}).Get(ctx, "")
+	assert.ErrorContains(t, err, "read username")
+}
@@ -0,0 +1,137 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Provider внешнее хранилище секретов
+type Provider interface {
+	// Get возвращает поля секрета по пути
+	Get(ctx context.Context, path string) (map[string]string, error)
+}
+
+// Поля секрета с учетными данными БД
+const (
+	FieldUsername = "username"
+	FieldPassword = "password"
+)
+
+// Credentials учетные данные БД
+type Credentials struct {
+	User     string
+	Password string
+}
+
+// DBCredentials читает учетные данные БД из секрета path.
+// Отсутствующее в секрете поле берется из fallback.
+func DBCredentials(ctx context.Context, provider Provider, path string, fallback Credentials) (Credentials, error) {
+	fields, err := provider.Get(ctx, path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("get secret %s: %w", path, err)
+	}
+
+	creds := fallback
+	if v := fields[FieldUsername]; v != "" {
+		creds.User = v
+	}
+	if v := fields[FieldPassword]; v != "" {
+		creds.Password = v
+	}
+	if creds.User == "" || creds.Password == "" {
+		return Credentials{}, fmt.Errorf("secret %s: %s and %s are required", path, FieldUsername, FieldPassword)
+	}
+	return creds, nil
+}
+
+// Rotator периодически перечитывает учетные данные БД и при их изменении вызывает onChange,
+// например для пересоздания пула соединений. При ошибке onChange попытка повторяется на следующей проверке.
+// Без провайдера учетными данными считается fallback, который обновляется при перезагрузке конфигурации.
+type Rotator struct {
+	provider Provider
+	path     string
+	logger   *zap.Logger
+	onChange func(ctx context.Context, creds Credentials) error
+
+	mu       sync.Mutex
+	fallback Credentials
+	current  Credentials
+}
+
+// NewRotator создает Rotator; current - учетные данные, с которыми уже открыто соединение.
+// provider может быть nil.
+func NewRotator(
+	provider Provider,
+	path string,
+	fallback, current Credentials,
+	logger *zap.Logger,
+	onChange func(ctx context.Context, creds Credentials) error,
+) *Rotator {
+	return &Rotator{
+		provider: provider,
+		path:     path,
+		fallback: fallback,
+		logger:   logger,
+		onChange: onChange,
+		current:  current,
+	}
+}
+
+// SetFallback заменяет учетные данные из конфигурации; они применяются при следующей проверке
+func (r *Rotator) SetFallback(fallback Credentials) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = fallback
+}
+
+// Refresh перечитывает учетные данные и применяет их, если они изменились.
+// Возвращает true, если учетные данные были заменены.
+func (r *Rotator) Refresh(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	creds := r.fallback
+	if r.provider != nil {
+		var err error
+		if creds, err = DBCredentials(ctx, r.provider, r.path, r.fallback); err != nil {
+			metrics.SecretRefreshes.WithLabelValues("error").Inc()
+			return false, err
+		}
+	}
+	if creds == r.current {
+		metrics.SecretRefreshes.WithLabelValues("unchanged").Inc()
+		return false, nil
+	}
+
+	if err := r.onChange(ctx, creds); err != nil {
+		metrics.SecretRefreshes.WithLabelValues("error").Inc()
+		return false, fmt.Errorf("apply rotated credentials: %w", err)
+	}
+	r.current = creds
+	metrics.SecretRefreshes.WithLabelValues("rotated").Inc()
+	r.logger.Info("Database credentials rotated", zap.String("user", creds.User))
+	return true, nil
+}
+
+// Run проверяет учетные данные каждые interval до отмены ctx
+func (r *Rotator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Refresh(ctx); err != nil {
+				r.logger.Warn("Failed to refresh database credentials", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubProvider отдает заданные поля секрета
+type stubProvider struct {
+	mu     sync.Mutex
+	fields map[string]string
+	err    error
+}
+
+func (p *stubProvider) Get(_ context.Context, _ string) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fields, p.err
+}
+
+func (p *stubProvider) set(fields map[string]string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fields, p.err = fields, err
+}
+
+func TestDBCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	creds, err := DBCredentials(ctx, &stubProvider{fields: map[string]string{"username": "app", "password": "p1"}},
+		"db", Credentials{})
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{User: "app", Password: "p1"}, creds)
+
+	// Пользователь может остаться в конфигурации, а пароль храниться в секрете
+	creds, err = DBCredentials(ctx, &stubProvider{fields: map[string]string{"password": "p1"}},
+		"db", Credentials{User: "cfg-user"})
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{User: "cfg-user", Password: "p1"}, creds)
+
+	_, err = DBCredentials(ctx, &stubProvider{fields: map[string]string{"username": "app"}}, "db", Credentials{})
+	assert.EqualError(t, err, "secret db: username and password are required")
+
+	_, err = DBCredentials(ctx, &stubProvider{err: errors.New("sealed")}, "db", Credentials{})
+	assert.EqualError(t, err, "get secret db: sealed")
+}
+
+func TestRotator_Refresh(t *testing.T) {
+	ctx := context.Background()
+	provider := &stubProvider{fields: map[string]string{"username": "app", "password": "p1"}}
+
+	var applied []Credentials
+	var applyErr error
+	r := NewRotator(provider, "db", Credentials{}, Credentials{User: "app", Password: "p1"}, zap.NewNop(),
+		func(_ context.Context, creds Credentials) error {
+			if applyErr != nil {
+				return applyErr
+			}
+			applied = append(applied, creds)
+			return nil
+		})
+
+	rotated, err := r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	provider.set(map[string]string{"username": "app", "password": "p2"}, nil)
+	applyErr = errors.New("ping failed")
+	rotated, err = r.Refresh(ctx)
+	assert.ErrorContains(t, err, "apply rotated credentials: ping failed")
+	assert.False(t, rotated)
+
+	// Неудачная замена повторяется на следующей проверке
+	applyErr = nil
+	rotated, err = r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, []Credentials{{User: "app", Password: "p2"}}, applied)
+
+	provider.set(nil, errors.New("sealed"))
+	_, err = r.Refresh(ctx)
+	assert.Error(t, err)
+}
+
+func TestRotator_WithoutProvider(t *testing.T) {
+	ctx := context.Background()
+	var applied []Credentials
+	r := NewRotator(nil, "", Credentials{User: "app", Password: "p1"}, Credentials{User: "app", Password: "p1"},
+		zap.NewNop(), func(_ context.Context, creds Credentials) error {
+			applied = append(applied, creds)
+			return nil
+		})
+
+	rotated, err := r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	// Новый пароль из перезагруженной конфигурации применяется при следующей проверке
+	r.SetFallback(Credentials{User: "app", Password: "p2"})
+	rotated, err = r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, []Credentials{{User: "app", Password: "p2"}}, applied)
+}
+
+func TestRotator_Run(t *testing.T) {
+	provider := &stubProvider{fields: map[string]string{"username": "app", "password": "p2"}}
+	rotated := make(chan Credentials, 1)
+	r := NewRotator(provider, "db", Credentials{}, Credentials{User: "app", Password: "p1"}, zap.NewNop(),
+		func(_ context.Context, creds Credentials) error {
+			rotated <- creds
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx, 5*time.Millisecond)
+
+	select {
+	case creds := <-rotated:
+		assert.Equal(t, "p2", creds.Password)
+	case <-time.After(time.Second):
+		t.Fatal("credentials were not rotated")
+	}
+}
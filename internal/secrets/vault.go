@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultKV читает секреты из движка KV версии 2 HashiCorp Vault через HTTP API
+type VaultKV struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+// NewVaultKV создает клиент Vault; mount - путь, по которому подключен движок KV (обычно secret)
+func NewVaultKV(addr, token, mount string, client *http.Client) *VaultKV {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &VaultKV{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		client: client,
+	}
+}
+
+// vaultKVResponse ответ GET /v1/<mount>/data/<path>
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Get возвращает последнюю версию секрета; нестроковые поля приводятся к строке
+func (v *VaultKV) Get(ctx context.Context, path string) (map[string]string, error) {
+	endpoint := v.addr + "/v1/" + url.PathEscape(v.mount) + "/data/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	var body vaultKVResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("decode vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+
+	fields := make(map[string]string, len(body.Data.Data))
+	for k, val := range body.Data.Data {
+		if s, ok := val.(string); ok {
+			fields[k] = s
+		} else {
+			fields[k] = fmt.Sprint(val)
+		}
+	}
+	return fields, nil
+}
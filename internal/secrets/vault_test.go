@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vaultStub имитирует HTTP API движка KV v2
+func vaultStub(t *testing.T, token string, secrets map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		data, ok := secrets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": 3},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultKV_Get(t *testing.T) {
+	srv := vaultStub(t, "root", map[string]map[string]interface{}{
+		"/v1/secret/data/usdt/db": {"username": "app", "password": "s3cret", "port": 5432},
+	})
+	ctx := context.Background()
+
+	t.Run("reads secret", func(t *testing.T) {
+		fields, err := NewVaultKV(srv.URL+"/", "root", "/secret/", nil).Get(ctx, "/usdt/db")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"username": "app", "password": "s3cret", "port": "5432"}, fields)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		_, err := NewVaultKV(srv.URL, "wrong", "secret", nil).Get(ctx, "usdt/db")
+		assert.EqualError(t, err, "vault returned status 403: permission denied")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := NewVaultKV(srv.URL, "root", "secret", nil).Get(ctx, "usdt/other")
+		assert.ErrorContains(t, err, "vault returned status 404")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		_, err := NewVaultKV("http://127.0.0.1:1", "root", "secret", nil).Get(ctx, "usdt/db")
+		assert.ErrorContains(t, err, "vault request")
+	})
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/convert"
+	"gRPC-USDT/internal/metrics"
+)
+
+// SetConverter включает пересчет сумм по сохраненным курсам.
+// Вызывается до начала обслуживания запросов.
+func (s *RateService) SetConverter(converter *convert.Converter) {
+	s.converter = converter
+}
+
+// Convert пересчитывает сумму между активами по последнему или ближайшему по времени курсу
+func (s *RateService) Convert(ctx context.Context, req *proto.ConvertRequest) (*proto.ConvertResponse, error) {
+	if s.converter == nil {
+		return nil, status.Error(codes.Unimplemented, "conversion is not configured")
+	}
+
+	start := time.Now()
+
+	tr := otel.GetTracerProvider().Tracer("rate-service")
+	ctx, span := tr.Start(ctx, "convert-service")
+	defer span.End()
+
+	var at time.Time
+	if req.GetAt() != "" {
+		parsed, err := time.Parse(time.RFC3339, req.GetAt())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "at must be RFC3339: %v", err)
+		}
+		at = parsed
+	}
+
+	result, err := s.converter.Convert(ctx, req.GetAmount(), req.GetFrom(), req.GetTo(), at)
+	switch {
+	case errors.Is(err, convert.ErrInvalidAmount), errors.Is(err, convert.ErrNoRoute):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, convert.ErrNoRate):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		s.logger.Error("Error converting amount", zap.Error(err))
+		return nil, status.Error(codes.Internal, "rate storage error")
+	}
+
+	metrics.RateExchangeCalls.WithLabelValues("Convert").Inc()
+	metrics.RateExchangeLatency.WithLabelValues("Convert").Observe(time.Since(start).Seconds())
+
+	resp := &proto.ConvertResponse{
+		Amount:    result.Amount,
+		Rate:      result.Rate,
+		Timestamp: result.Time.Format(time.RFC3339),
+	}
+	for _, leg := range result.Legs {
+		resp.Legs = append(resp.Legs, &proto.ConversionLeg{
+			Symbol:    leg.Symbol,
+			Side:      leg.Side,
+			Price:     leg.Price,
+			Timestamp: leg.Time.Format(time.RFC3339),
+			AmountIn:  leg.AmountIn,
+			AmountOut: leg.AmountOut,
+		})
+	}
+	return resp, nil
+}
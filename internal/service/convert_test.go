@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/convert"
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+func TestRateService_Convert(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := storage.NewMemoryStorage(0)
+	require.NoError(t, store.SaveRates(context.Background(), []models.Rate{
+		{Ask: 101, Bid: 100, AskAmount: 1, BidAmount: 1, Time: ts},
+		{Ask: 111, Bid: 110, AskAmount: 1, BidAmount: 1, Time: ts.Add(time.Hour)},
+	}))
+
+	service := NewRateService(nil, zap.NewNop(), &config.Config{}, nil)
+	service.SetConverter(convert.NewConverter(convert.Market{Base: "BTC", Quote: "USDT", Source: store}))
+	ctx := context.Background()
+
+	t.Run("latest rate", func(t *testing.T) {
+		resp, err := service.Convert(ctx, &proto.ConvertRequest{From: "BTC", To: "USDT", Amount: 0.5})
+		require.NoError(t, err)
+		assert.Equal(t, 55.0, resp.Amount)
+		assert.Equal(t, 110.0, resp.Rate)
+		assert.Equal(t, ts.Add(time.Hour).Format(time.RFC3339), resp.Timestamp)
+		require.Len(t, resp.Legs, 1)
+		assert.Equal(t, "BTCUSDT", resp.Legs[0].Symbol)
+		assert.Equal(t, convert.SideBid, resp.Legs[0].Side)
+	})
+
+	t.Run("historical rate", func(t *testing.T) {
+		resp, err := service.Convert(ctx, &proto.ConvertRequest{
+			From: "USDT", To: "BTC", Amount: 202, At: ts.Add(time.Minute).Format(time.RFC3339),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2.0, resp.Amount)
+		assert.Equal(t, ts.Format(time.RFC3339), resp.Timestamp)
+		assert.Equal(t, convert.SideAsk, resp.Legs[0].Side)
+		assert.Equal(t, 101.0, resp.Legs[0].Price)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, req := range []*proto.ConvertRequest{
+			{From: "BTC", To: "USDT", Amount: -1},
+			{From: "BTC", To: "EUR", Amount: 1},
+			{From: "BTC", To: "USDT", Amount: 1, At: "yesterday"},
+		} {
+			_, err := service.Convert(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+		}
+	})
+
+	t.Run("no stored rates", func(t *testing.T) {
+		empty := NewRateService(nil, zap.NewNop(), &config.Config{}, nil)
+		empty.SetConverter(convert.NewConverter(convert.Market{
+			Base: "BTC", Quote: "USDT", Source: storage.NewMemoryStorage(0),
+		}))
+		_, err := empty.Convert(ctx, &proto.ConvertRequest{From: "BTC", To: "USDT", Amount: 1})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		_, err := NewRateService(nil, zap.NewNop(), &config.Config{}, nil).
+			Convert(ctx, &proto.ConvertRequest{From: "BTC", To: "USDT", Amount: 1})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
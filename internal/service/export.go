@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/export"
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/storage"
+)
+
+// RateExporter построчно выгружает историю курсов, не загружая ее в память целиком
+type RateExporter interface {
+	ExportRates(ctx context.Context, q storage.ExportQuery, fn func(row export.Row) error) error
+}
+
+// exportChunkSize максимальный размер части файла в одном сообщении потока
+const exportChunkSize = 64 * 1024
+
+// SetRateExporter включает выгрузку истории курсов.
+// Вызывается до начала обслуживания запросов.
+func (s *RateService) SetRateExporter(exporter RateExporter) {
+	s.exporter = exporter
+}
+
+// ExportRates передает курсы за период файлом выбранного формата, разбитым на части.
+// Если выгрузка прервалась, поток завершается ошибкой, и полученные части нужно отбросить.
+func (s *RateService) ExportRates(
+	req *proto.ExportRatesRequest,
+	stream grpc.ServerStreamingServer[proto.ExportRatesChunk],
+) error {
+	if s.exporter == nil {
+		return status.Error(codes.Unimplemented, "rate export is not configured")
+	}
+
+	start := time.Now()
+
+	tr := otel.GetTracerProvider().Tracer("rate-service")
+	ctx, span := tr.Start(stream.Context(), "export-rates-service")
+	defer span.End()
+
+	q, err := exportQuery(req, start)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	format, err := exportFormat(req.GetFormat())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	chunks := &chunkWriter{send: func(data []byte) error {
+		return stream.Send(&proto.ExportRatesChunk{Data: data})
+	}}
+	writer, err := export.NewWriter(chunks, format)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	count := 0
+	err = s.exporter.ExportRates(ctx, q, func(row export.Row) error {
+		count++
+		return writer.Write(row)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = chunks.Flush()
+	}
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case chunks.err != nil:
+		// Ошибка отправки уже содержит статус gRPC
+		return chunks.err
+	default:
+		s.logger.Error("Error exporting rates", zap.Error(err), zap.Int("rows", count))
+		return status.Error(codes.Internal, "rate storage error")
+	}
+
+	metrics.RatesExported.WithLabelValues(string(format)).Add(float64(count))
+	metrics.RateExchangeCalls.WithLabelValues("ExportRates").Inc()
+	metrics.RateExchangeLatency.WithLabelValues("ExportRates").Observe(time.Since(start).Seconds())
+	return nil
+}
+
+// exportQuery определяет период выгрузки; без to выгружаются курсы до текущего момента
+func exportQuery(req *proto.ExportRatesRequest, now time.Time) (storage.ExportQuery, error) {
+	q := storage.ExportQuery{Symbol: req.GetSymbol(), To: now}
+	var err error
+	if req.GetFrom() != "" {
+		if q.From, err = time.Parse(time.RFC3339, req.GetFrom()); err != nil {
+			return storage.ExportQuery{}, errors.New("from must be RFC3339")
+		}
+	}
+	if req.GetTo() != "" {
+		if q.To, err = time.Parse(time.RFC3339, req.GetTo()); err != nil {
+			return storage.ExportQuery{}, errors.New("to must be RFC3339")
+		}
+	}
+	if !q.From.IsZero() && !q.From.Before(q.To) {
+		return storage.ExportQuery{}, errors.New("from must be before to")
+	}
+	return q, nil
+}
+
+func exportFormat(format proto.ExportFormat) (export.Format, error) {
+	switch format {
+	case proto.ExportFormat_EXPORT_FORMAT_UNSPECIFIED, proto.ExportFormat_EXPORT_FORMAT_CSV:
+		return export.FormatCSV, nil
+	case proto.ExportFormat_EXPORT_FORMAT_JSONL:
+		return export.FormatJSONL, nil
+	case proto.ExportFormat_EXPORT_FORMAT_PARQUET:
+		return export.FormatParquet, nil
+	default:
+		return "", errors.New("unknown export format")
+	}
+}
+
+// chunkWriter накапливает выгрузку и отправляет ее частями не больше exportChunkSize
+type chunkWriter struct {
+	buf  []byte
+	send func(data []byte) error
+	err  error // Первая ошибка отправки; после нее запись не выполняется
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		if c.buf == nil {
+			c.buf = make([]byte, 0, exportChunkSize)
+		}
+		take := min(exportChunkSize-len(c.buf), len(p))
+		c.buf = append(c.buf, p[:take]...)
+		p = p[take:]
+		if len(c.buf) == exportChunkSize {
+			if err := c.Flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush отправляет накопленную часть. Отправленный буфер не переиспользуется:
+// сообщение может быть сериализовано уже после возврата из Send.
+func (c *chunkWriter) Flush() error {
+	if c.err != nil || len(c.buf) == 0 {
+		return c.err
+	}
+	c.err = c.send(c.buf)
+	c.buf = nil
+	return c.err
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/export"
+	"gRPC-USDT/internal/storage"
+)
+
+type fakeExporter struct {
+	rows  []export.Row
+	err   error
+	query storage.ExportQuery
+}
+
+func (f *fakeExporter) ExportRates(_ context.Context, q storage.ExportQuery, fn func(row export.Row) error) error {
+	f.query = q
+	for _, row := range f.rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return f.err
+}
+
+type fakeExportStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks [][]byte
+	err    error
+}
+
+func (f *fakeExportStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeExportStream) Send(chunk *proto.ExportRatesChunk) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.chunks = append(f.chunks, chunk.GetData())
+	return nil
+}
+
+func (f *fakeExportStream) data() []byte {
+	return bytes.Join(f.chunks, nil)
+}
+
+func exportRows(n int) []export.Row {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]export.Row, n)
+	for i := range rows {
+		rows[i] = export.Row{Timestamp: base.Add(time.Duration(i) * time.Second), Symbol: "BTCUSDT",
+			Source: "binance", Ask: 42000.5, Bid: 41999.5, AskAmount: 1, BidAmount: 2}
+	}
+	return rows
+}
+
+func TestRateService_ExportRates(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	newService := func(exporter RateExporter) *RateService {
+		service := NewRateService(nil, zap.NewNop(), &config.Config{}, nil)
+		service.SetRateExporter(exporter)
+		return service
+	}
+
+	t.Run("csv in chunks", func(t *testing.T) {
+		exporter := &fakeExporter{rows: exportRows(3000)}
+		stream := &fakeExportStream{ctx: context.Background()}
+		err := newService(exporter).ExportRates(&proto.ExportRatesRequest{
+			From:   "2024-01-01T00:00:00Z",
+			To:     "2024-02-01T00:00:00Z",
+			Symbol: "BTCUSDT",
+		}, stream)
+		require.NoError(t, err)
+
+		assert.Equal(t, storage.ExportQuery{
+			Symbol: "BTCUSDT",
+			From:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}, exporter.query)
+		require.Greater(t, len(stream.chunks), 1)
+		for _, chunk := range stream.chunks {
+			assert.LessOrEqual(t, len(chunk), exportChunkSize)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(stream.data()), "\n"), "\n")
+		require.Len(t, lines, 3001)
+		assert.Equal(t, "timestamp,symbol,source,ask,bid,ask_amount,bid_amount", lines[0])
+		assert.Equal(t, "2024-01-01T00:00:00Z,BTCUSDT,binance,42000.5,41999.5,1,2", lines[1])
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		stream := &fakeExportStream{ctx: context.Background()}
+		err := newService(&fakeExporter{rows: exportRows(1)}).ExportRates(&proto.ExportRatesRequest{
+			Format: proto.ExportFormat_EXPORT_FORMAT_JSONL,
+		}, stream)
+		require.NoError(t, err)
+		assert.Equal(t, `{"timestamp":"2024-01-01T00:00:00Z","symbol":"BTCUSDT","source":"binance",`+
+			`"ask":42000.5,"bid":41999.5,"ask_amount":1,"bid_amount":2}`+"\n", string(stream.data()))
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		stream := &fakeExportStream{ctx: context.Background()}
+		err := newService(&fakeExporter{rows: exportRows(10)}).ExportRates(&proto.ExportRatesRequest{
+			Format: proto.ExportFormat_EXPORT_FORMAT_PARQUET,
+		}, stream)
+		require.NoError(t, err)
+
+		data := stream.data()
+		rows, err := parquet.Read[export.Row](bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		assert.Len(t, rows, 10)
+	})
+
+	t.Run("to defaults to now", func(t *testing.T) {
+		exporter := &fakeExporter{}
+		before := time.Now()
+		err := newService(exporter).ExportRates(&proto.ExportRatesRequest{}, &fakeExportStream{ctx: context.Background()})
+		require.NoError(t, err)
+		assert.True(t, exporter.query.From.IsZero())
+		assert.False(t, exporter.query.To.Before(before))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, req := range []*proto.ExportRatesRequest{
+			{From: "yesterday"},
+			{To: "today"},
+			{From: "2024-02-01T00:00:00Z", To: "2024-01-01T00:00:00Z"},
+			{Format: proto.ExportFormat(42)},
+		} {
+			err := newService(&fakeExporter{}).ExportRates(req, &fakeExportStream{ctx: context.Background()})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		exporter := &fakeExporter{rows: exportRows(1), err: errors.New("connection reset")}
+		err := newService(exporter).ExportRates(&proto.ExportRatesRequest{}, &fakeExportStream{ctx: context.Background()})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		sendErr := status.Error(codes.Unavailable, "transport closing")
+		stream := &fakeExportStream{ctx: context.Background(), err: sendErr}
+		err := newService(&fakeExporter{rows: exportRows(1)}).ExportRates(&proto.ExportRatesRequest{}, stream)
+		assert.Equal(t, sendErr, err)
+	})
+
+	t.Run("client canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		exporter := &fakeExporter{err: context.Canceled}
+		err := newService(exporter).ExportRates(&proto.ExportRatesRequest{}, &fakeExportStream{ctx: ctx})
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		err := newService(nil).ExportRates(&proto.ExportRatesRequest{}, &fakeExportStream{ctx: context.Background()})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
@@ -8,13 +8,16 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
 
 	"gRPC-USDT/api/proto"
 	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/convert"
 	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/twap"
 
 	"go.uber.org/zap"
 )
@@ -29,6 +32,18 @@ type RateStorage interface {
 	SaveRate(ctx context.Context, ask, bid, askAmount, bidAmount float64, ts time.Time) error
 }
 
+// RateObserver получает каждый успешно сохраненный курс.
+// Вызывается синхронно в обработчике запроса, поэтому не должен блокироваться.
+type RateObserver interface {
+	OnRate(ctx context.Context, rate models.Rate)
+}
+
+// RateValidator проверяет курс перед сохранением.
+// Непустая причина означает, что курс отклонен и не должен попасть в хранилище.
+type RateValidator interface {
+	Validate(ctx context.Context, rate models.Rate) (reason string, err error)
+}
+
 // DefaultHTTPClient реализация HTTPClient по умолчанию
 type DefaultHTTPClient struct{}
 
@@ -43,6 +58,14 @@ type RateService struct {
 	logger     *zap.Logger
 	cfg        *config.Config
 	httpClient HTTPClient
+	observers  []RateObserver
+	validator  RateValidator
+	converter  *convert.Converter
+	twap       *twap.Calculator
+	exporter   RateExporter
+
+	// Адрес биржи меняется при перезагрузке конфигурации во время обслуживания запросов
+	binanceURL atomic.Pointer[string]
 }
 
 // NewRateService создает новый экземпляр RateService
@@ -55,12 +78,32 @@ func NewRateService(
 	if httpClient == nil {
 		httpClient = &DefaultHTTPClient{}
 	}
-	return &RateService{
+	s := &RateService{
 		storage:    storage,
 		logger:     logger,
 		cfg:        cfg,
 		httpClient: httpClient,
 	}
+	s.SetBinanceAPIURL(cfg.BinanceAPIURL)
+	return s
+}
+
+// SetBinanceAPIURL атомарно заменяет адрес биржи.
+// Безопасен во время обслуживания запросов: текущие запросы завершаются со старым адресом.
+func (s *RateService) SetBinanceAPIURL(url string) {
+	s.binanceURL.Store(&url)
+}
+
+// AddRateObserver подписывает наблюдателя на сохраненные курсы.
+// Вызывается до начала обслуживания запросов.
+func (s *RateService) AddRateObserver(observer RateObserver) {
+	s.observers = append(s.observers, observer)
+}
+
+// SetRateValidator включает проверку курсов перед сохранением.
+// Вызывается до начала обслуживания запросов.
+func (s *RateService) SetRateValidator(validator RateValidator) {
+	s.validator = validator
 }
 
 // GetRateFromExchange получает курс от биржи и сохраняет его
@@ -74,63 +117,107 @@ func (s *RateService) GetRateFromExchange(
 	ctx, serviceSpan := tr.Start(ctx, "get-rate-from-exchange-service")
 	defer serviceSpan.End()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", s.cfg.BinanceAPIURL, nil)
+	rate, err := s.FetchRate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	analytics := rate.Analytics()
+
+	if s.validator != nil {
+		reason, err := s.validator.Validate(ctx, rate)
+		if err != nil {
+			s.logger.Error("Error validating rate", zap.Error(err))
+			return nil, fmt.Errorf("validate rate failed: %w", err)
+		}
+		if reason != "" {
+			metrics.RateExchangeCalls.WithLabelValues("GetRateFromExchange").Inc()
+			metrics.RateExchangeLatency.WithLabelValues("GetRateFromExchange").Observe(time.Since(start).Seconds())
+			return rateResponse(rate, analytics, reason), nil
+		}
+	}
+
+	if err := s.storage.SaveRate(ctx, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time); err != nil {
+		s.logger.Error("Error saving rate", zap.Error(err))
+		return nil, fmt.Errorf("save rate failed: %w", err)
+	}
+	s.logger.Info("Rate saved successfully")
+
+	for _, observer := range s.observers {
+		observer.OnRate(ctx, rate)
+	}
+
+	metrics.RateExchangeCalls.WithLabelValues("GetRateFromExchange").Inc()
+	metrics.RateExchangeLatency.WithLabelValues("GetRateFromExchange").Observe(time.Since(start).Seconds())
+
+	return rateResponse(rate, analytics, ""), nil
+}
+
+// FetchRate получает текущий курс от биржи без проверки и сохранения
+func (s *RateService) FetchRate(ctx context.Context) (models.Rate, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", *s.binanceURL.Load(), nil)
 	if err != nil {
 		s.logger.Error("Error creating request", zap.Error(err))
-		return nil, fmt.Errorf("create request failed: %w", err)
+		return models.Rate{}, fmt.Errorf("create request failed: %w", err)
 	}
 
 	resp, err := s.httpClient.Do(httpReq)
 	if err != nil {
 		s.logger.Error("Error fetching rates", zap.Error(err))
-		return nil, fmt.Errorf("fetch rates failed: %w", err)
+		return models.Rate{}, fmt.Errorf("fetch rates failed: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("binance API returned status: %s", resp.Status)
+		return models.Rate{}, fmt.Errorf("binance API returned status: %s", resp.Status)
 	}
 
 	var depthResponse models.BinanceDepthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&depthResponse); err != nil {
 		s.logger.Error("Error decoding response", zap.Error(err))
-		return nil, fmt.Errorf("decode response failed: %w", err)
+		return models.Rate{}, fmt.Errorf("decode response failed: %w", err)
 	}
 
 	if len(depthResponse.Asks) == 0 || len(depthResponse.Bids) == 0 {
-		return nil, fmt.Errorf("empty response from binance")
+		return models.Rate{}, fmt.Errorf("empty response from binance")
 	}
 
-	bestAsk, bidVolume, err := processOrder(depthResponse.Asks[0])
+	// Объем лучшей цены относится к той же стороне стакана, что и цена
+	bestAsk, askVolume, err := processOrder(depthResponse.Asks[0])
 	if err != nil {
-		return nil, fmt.Errorf("ask processing failed: %w", err)
+		return models.Rate{}, fmt.Errorf("ask processing failed: %w", err)
 	}
 
-	bestBid, askVolume, err := processOrder(depthResponse.Bids[0])
+	bestBid, bidVolume, err := processOrder(depthResponse.Bids[0])
 	if err != nil {
-		return nil, fmt.Errorf("bid processing failed: %w", err)
-	}
-
-	timestamp := time.Now()
-	if err := s.storage.SaveRate(ctx, bestAsk, bestBid, askVolume, bidVolume, timestamp); err != nil {
-		s.logger.Error("Error saving rate", zap.Error(err))
-		return nil, fmt.Errorf("save rate failed: %w", err)
+		return models.Rate{}, fmt.Errorf("bid processing failed: %w", err)
 	}
-	s.logger.Info("Rate saved successfully")
 
-	metrics.RateExchangeCalls.WithLabelValues("GetRateFromExchange").Inc()
-	metrics.RateExchangeLatency.WithLabelValues("GetRateFromExchange").Observe(time.Since(start).Seconds())
+	return models.Rate{
+		Ask:       bestAsk,
+		Bid:       bestBid,
+		AskAmount: askVolume,
+		BidAmount: bidVolume,
+		Time:      time.Now(),
+	}, nil
+}
 
+// rateResponse собирает ответ; курс с непустой причиной аномалии не сохранен
+func rateResponse(rate models.Rate, analytics models.Analytics, anomaly string) *proto.GetRateFromExchangeResponse {
 	return &proto.GetRateFromExchangeResponse{
-		Success:   true,
-		Ask:       float32(bestAsk),
-		Bid:       float32(bestBid),
-		AskAmount: float32(askVolume),
-		BidAmount: float32(bidVolume),
-		Timestamp: timestamp.Format(time.RFC3339),
-	}, nil
+		Success:   anomaly == "",
+		Ask:       float32(rate.Ask),
+		Bid:       float32(rate.Bid),
+		AskAmount: float32(rate.AskAmount),
+		BidAmount: float32(rate.BidAmount),
+		Timestamp: rate.Time.Format(time.RFC3339),
+		Mid:       analytics.Mid,
+		Spread:    analytics.Spread,
+		SpreadBps: analytics.SpreadBps,
+		Imbalance: analytics.Imbalance,
+		Anomaly:   anomaly,
+	}
 }
 
 func processOrder(order []string) (price, volume float64, err error) {
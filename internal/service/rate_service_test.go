@@ -3,10 +3,16 @@ package service
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
+	"reflect"
+	"strconv"
 	"testing"
+	"testing/quick"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -20,6 +26,7 @@ import (
 	"gRPC-USDT/api/proto"
 	"gRPC-USDT/internal/config"
 	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
 )
 
 // MockHTTPClient мок для HTTPClient
@@ -102,8 +109,12 @@ func TestRateService_GetRateFromExchange(t *testing.T) {
 				Success:   true,
 				Ask:       100.0,
 				Bid:       99.0,
-				AskAmount: 2.0,
-				BidAmount: 1.0,
+				AskAmount: 1.0,
+				BidAmount: 2.0,
+				Mid:       99.5,
+				Spread:    1.0,
+				SpreadBps: 1.0 / 99.5 * 10000,
+				Imbalance: 1.0 / 3,
 			},
 		},
 		{
@@ -199,6 +210,10 @@ func TestRateService_GetRateFromExchange(t *testing.T) {
 				assert.Equal(t, tt.wantResp.Bid, resp.Bid)
 				assert.Equal(t, tt.wantResp.AskAmount, resp.AskAmount)
 				assert.Equal(t, tt.wantResp.BidAmount, resp.BidAmount)
+				assert.InDelta(t, tt.wantResp.Mid, resp.Mid, 1e-9)
+				assert.InDelta(t, tt.wantResp.Spread, resp.Spread, 1e-9)
+				assert.InDelta(t, tt.wantResp.SpreadBps, resp.SpreadBps, 1e-9)
+				assert.InDelta(t, tt.wantResp.Imbalance, resp.Imbalance, 1e-9)
 			}
 
 			mockHTTP.AssertExpectations(t)
@@ -214,6 +229,311 @@ func TestRateService_GetRateFromExchange(t *testing.T) {
 	}
 }
 
+// recordingObserver запоминает полученные курсы
+type recordingObserver struct {
+	rates []models.Rate
+}
+
+func (o *recordingObserver) OnRate(_ context.Context, rate models.Rate) {
+	o.rates = append(o.rates, rate)
+}
+
+func TestRateService_Observers(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	newResp := func() *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"asks": [["100.0", "1.0"]], "bids": [["99.0", "2.0"]]}`))),
+		}
+	}
+
+	t.Run("notified after save", func(t *testing.T) {
+		mockHTTP := new(MockHTTPClient)
+		mockHTTP.On("Do", mock.Anything).Return(newResp(), nil)
+		mockStorage := new(MockRateStorage)
+		mockStorage.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		observer := &recordingObserver{}
+		service := NewRateService(mockStorage, zap.NewNop(), &config.Config{BinanceAPIURL: "https://test-api.com"}, mockHTTP)
+		service.AddRateObserver(observer)
+
+		resp, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+		require.NoError(t, err)
+
+		require.Len(t, observer.rates, 1)
+		assert.Equal(t, 100.0, observer.rates[0].Ask)
+		assert.Equal(t, 99.0, observer.rates[0].Bid)
+		assert.Equal(t, 1.0, observer.rates[0].AskAmount)
+		assert.Equal(t, 2.0, observer.rates[0].BidAmount)
+		assert.Equal(t, resp.Timestamp, observer.rates[0].Time.Format(time.RFC3339))
+	})
+
+	t.Run("not notified when save fails", func(t *testing.T) {
+		mockHTTP := new(MockHTTPClient)
+		mockHTTP.On("Do", mock.Anything).Return(newResp(), nil)
+		mockStorage := new(MockRateStorage)
+		mockStorage.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("db connection failed"))
+
+		observer := &recordingObserver{}
+		service := NewRateService(mockStorage, zap.NewNop(), &config.Config{BinanceAPIURL: "https://test-api.com"}, mockHTTP)
+		service.AddRateObserver(observer)
+
+		_, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+		assert.Error(t, err)
+		assert.Empty(t, observer.rates)
+	})
+}
+
+func TestRateService_FetchRate(t *testing.T) {
+	mockHTTP := new(MockHTTPClient)
+	mockHTTP.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"asks": [["100.0", "1.0"]], "bids": [["99.0", "2.0"]]}`))),
+	}, nil)
+	// Курс только запрашивается: SaveRate у мока не настроен и вызов завершил бы тест паникой
+	mockStorage := new(MockRateStorage)
+	observer := &recordingObserver{}
+	service := NewRateService(mockStorage, zap.NewNop(), &config.Config{BinanceAPIURL: "https://test-api.com"}, mockHTTP)
+	service.AddRateObserver(observer)
+
+	rate, err := service.FetchRate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, rate.Ask)
+	assert.Equal(t, 2.0, rate.BidAmount)
+	mockStorage.AssertNotCalled(t, "SaveRate")
+	assert.Empty(t, observer.rates)
+}
+
+func TestRateService_SetBinanceAPIURL(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	newResp := func() *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"asks": [["100.0", "1.0"]], "bids": [["99.0", "2.0"]]}`))),
+		}
+	}
+	mockHTTP := new(MockHTTPClient)
+	mockHTTP.On("Do", mock.Anything).Return(newResp(), nil).Once()
+	mockHTTP.On("Do", mock.Anything).Return(newResp(), nil).Once()
+	mockStorage := new(MockRateStorage)
+	mockStorage.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	service := NewRateService(mockStorage, zap.NewNop(), &config.Config{BinanceAPIURL: "https://test-api.com"}, mockHTTP)
+	_, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+	require.NoError(t, err)
+
+	service.SetBinanceAPIURL("https://other-api.com")
+	_, err = service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+	require.NoError(t, err)
+
+	calls := mockHTTP.Calls
+	require.Len(t, calls, 2)
+	assert.Equal(t, "https://test-api.com", calls[0].Arguments.Get(0).(*http.Request).URL.String())
+	assert.Equal(t, "https://other-api.com", calls[1].Arguments.Get(0).(*http.Request).URL.String())
+}
+
+// stubValidator возвращает заданную причину отклонения
+type stubValidator struct {
+	reason string
+	err    error
+	rates  []models.Rate
+}
+
+func (v *stubValidator) Validate(_ context.Context, rate models.Rate) (string, error) {
+	v.rates = append(v.rates, rate)
+	return v.reason, v.err
+}
+
+func TestRateService_Validator(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	newService := func(validator RateValidator) (*RateService, *MockRateStorage, *recordingObserver) {
+		mockHTTP := new(MockHTTPClient)
+		mockHTTP.On("Do", mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"asks": [["100.0", "1.0"]], "bids": [["99.0", "2.0"]]}`))),
+		}, nil)
+		mockStorage := new(MockRateStorage)
+		mockStorage.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		observer := &recordingObserver{}
+		service := NewRateService(mockStorage, zap.NewNop(), &config.Config{BinanceAPIURL: "https://test-api.com"}, mockHTTP)
+		service.SetRateValidator(validator)
+		service.AddRateObserver(observer)
+		return service, mockStorage, observer
+	}
+
+	t.Run("accepted rate is saved", func(t *testing.T) {
+		validator := &stubValidator{}
+		service, mockStorage, observer := newService(validator)
+
+		resp, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+		assert.Empty(t, resp.Anomaly)
+		require.Len(t, validator.rates, 1)
+		assert.Equal(t, 100.0, validator.rates[0].Ask)
+		mockStorage.AssertNumberOfCalls(t, "SaveRate", 1)
+		assert.Len(t, observer.rates, 1)
+	})
+
+	t.Run("quarantined rate is not saved", func(t *testing.T) {
+		service, mockStorage, observer := newService(&stubValidator{reason: "jump"})
+
+		resp, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "jump", resp.Anomaly)
+		assert.Equal(t, float32(100.0), resp.Ask)
+		mockStorage.AssertNotCalled(t, "SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything)
+		assert.Empty(t, observer.rates)
+	})
+
+	t.Run("validator error", func(t *testing.T) {
+		service, mockStorage, _ := newService(&stubValidator{reason: "jump", err: errors.New("quarantine failed")})
+
+		_, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+		assert.ErrorContains(t, err, "validate rate failed")
+		mockStorage.AssertNotCalled(t, "SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything)
+	})
+}
+
+// orderBook сгенерированный стакан: asks по возрастанию цены, bids по убыванию
+type orderBook struct {
+	Asks [][2]float64
+	Bids [][2]float64
+}
+
+// Generate строит корректный стакан с лучшим bid ниже лучшего ask
+func (orderBook) Generate(r *rand.Rand, _ int) reflect.Value {
+	// Цены и объемы с двумя знаками, как у Binance, чтобы они без потерь проходили через JSON
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	volume := func() float64 { return round(0.01 + r.Float64()*100) }
+
+	bestBid := round(1 + r.Float64()*100000)
+	bestAsk := round(bestBid + 0.01 + r.Float64()*100)
+
+	var book orderBook
+	for i, levels := 0, 1+r.Intn(5); i < levels; i++ {
+		book.Asks = append(book.Asks, [2]float64{round(bestAsk + float64(i)*0.01), volume()})
+		book.Bids = append(book.Bids, [2]float64{round(bestBid - float64(i)*0.01), volume()})
+	}
+	return reflect.ValueOf(book)
+}
+
+// body кодирует стакан в формате ответа Binance
+func (b orderBook) body() []byte {
+	encode := func(levels [][2]float64) [][]string {
+		out := make([][]string, 0, len(levels))
+		for _, level := range levels {
+			out = append(out, []string{
+				strconv.FormatFloat(level[0], 'f', -1, 64),
+				strconv.FormatFloat(level[1], 'f', -1, 64),
+			})
+		}
+		return out
+	}
+	data, _ := json.Marshal(map[string][][]string{"asks": encode(b.Asks), "bids": encode(b.Bids)})
+	return data
+}
+
+// bookHTTPClient отвечает заданным стаканом
+type bookHTTPClient struct {
+	book orderBook
+}
+
+func (c *bookHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(c.book.body()))}, nil
+}
+
+// recordingStorage запоминает сохраненные курсы
+type recordingStorage struct {
+	rates []models.Rate
+}
+
+func (s *recordingStorage) SaveRate(_ context.Context, ask, bid, askAmount, bidAmount float64, ts time.Time) error {
+	s.rates = append(s.rates, models.Rate{Ask: ask, Bid: bid, AskAmount: askAmount, BidAmount: bidAmount, Time: ts})
+	return nil
+}
+
+// fetchBook прогоняет стакан через сервис и возвращает ответ и сохраненный курс
+func fetchBook(t *testing.T, book orderBook) (*proto.GetRateFromExchangeResponse, models.Rate, []models.Rate) {
+	store := &recordingStorage{}
+	observer := &recordingObserver{}
+	service := NewRateService(store, zap.NewNop(), &config.Config{BinanceAPIURL: "https://test-api.com"},
+		&bookHTTPClient{book: book})
+	service.AddRateObserver(observer)
+
+	resp, err := service.GetRateFromExchange(context.Background(), &proto.GetRateFromExchangeRequest{})
+	require.NoError(t, err)
+	require.Len(t, store.rates, 1)
+	return resp, store.rates[0], observer.rates
+}
+
+func TestRateService_SideInvariants(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	// Цена и объем каждой стороны берутся из лучшего уровня той же стороны
+	sides := func(book orderBook) bool {
+		resp, saved, observed := fetchBook(t, book)
+		bestAsk, bestBid := book.Asks[0], book.Bids[0]
+
+		return saved.Ask == bestAsk[0] && saved.AskAmount == bestAsk[1] &&
+			saved.Bid == bestBid[0] && saved.BidAmount == bestBid[1] &&
+			resp.Ask == float32(bestAsk[0]) && resp.AskAmount == float32(bestAsk[1]) &&
+			resp.Bid == float32(bestBid[0]) && resp.BidAmount == float32(bestBid[1]) &&
+			len(observed) == 1 && observed[0] == saved
+	}
+	require.NoError(t, quick.Check(sides, &quick.Config{MaxCount: 200}))
+
+	// Производные показатели согласованы со сторонами стакана
+	analytics := func(book orderBook) bool {
+		resp, saved, _ := fetchBook(t, book)
+
+		imbalanceSign := math.Copysign(1, resp.Imbalance)
+		if resp.Imbalance == 0 {
+			imbalanceSign = 0
+		}
+		volumeSign := 0.0
+		switch {
+		case saved.BidAmount > saved.AskAmount:
+			volumeSign = 1
+		case saved.BidAmount < saved.AskAmount:
+			volumeSign = -1
+		}
+
+		return resp.Success &&
+			resp.Spread > 0 && resp.SpreadBps > 0 &&
+			saved.Bid < resp.Mid && resp.Mid < saved.Ask &&
+			resp.Imbalance >= -1 && resp.Imbalance <= 1 &&
+			imbalanceSign == volumeSign
+	}
+	require.NoError(t, quick.Check(analytics, &quick.Config{MaxCount: 200}))
+
+	// Обмен объемов сторон меняет знак дисбаланса и не меняет цены
+	mirrored := func(book orderBook) bool {
+		resp, _, _ := fetchBook(t, book)
+
+		swapped := orderBook{
+			Asks: [][2]float64{{book.Asks[0][0], book.Bids[0][1]}},
+			Bids: [][2]float64{{book.Bids[0][0], book.Asks[0][1]}},
+		}
+		swappedResp, _, _ := fetchBook(t, swapped)
+
+		return math.Abs(resp.Imbalance+swappedResp.Imbalance) < 1e-12 &&
+			resp.Mid == swappedResp.Mid && resp.Spread == swappedResp.Spread
+	}
+	require.NoError(t, quick.Check(mirrored, &quick.Config{MaxCount: 200}))
+}
+
 func TestProcessOrder(t *testing.T) {
 	tests := []struct {
 		name      string
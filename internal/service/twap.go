@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/twap"
+)
+
+// SetTWAPCalculator включает расчет TWAP по сохраненным курсам.
+// Вызывается до начала обслуживания запросов.
+func (s *RateService) SetTWAPCalculator(calculator *twap.Calculator) {
+	s.twap = calculator
+}
+
+// GetTWAP рассчитывает средневзвешенную по времени среднюю цену за окно
+func (s *RateService) GetTWAP(ctx context.Context, req *proto.GetTWAPRequest) (*proto.GetTWAPResponse, error) {
+	if s.twap == nil {
+		return nil, status.Error(codes.Unimplemented, "TWAP is not configured")
+	}
+
+	start := time.Now()
+
+	tr := otel.GetTracerProvider().Tracer("rate-service")
+	ctx, span := tr.Start(ctx, "get-twap-service")
+	defer span.End()
+
+	from, to, err := twapWindow(req, start, s.twap.MaxWindow())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.twap.Calculate(ctx, from, to, gapPolicy(req.GetGapPolicy()))
+	switch {
+	case errors.Is(err, twap.ErrInvalidWindow):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, twap.ErrNoSamples):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, twap.ErrGap):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		s.logger.Error("Error calculating TWAP", zap.Error(err))
+		return nil, status.Error(codes.Internal, "rate storage error")
+	}
+
+	metrics.RateExchangeCalls.WithLabelValues("GetTWAP").Inc()
+	metrics.RateExchangeLatency.WithLabelValues("GetTWAP").Observe(time.Since(start).Seconds())
+
+	resp := &proto.GetTWAPResponse{
+		Twap:           result.TWAP,
+		SampleCount:    int64(result.Samples),
+		From:           from.Format(time.RFC3339),
+		To:             to.Format(time.RFC3339),
+		CoveredSeconds: result.Covered.Seconds(),
+		MaxGapSeconds:  s.twap.MaxGap().Seconds(),
+	}
+	for _, gap := range result.Gaps {
+		resp.Gaps = append(resp.Gaps, &proto.PriceGap{
+			Start:   gap.Start.Format(time.RFC3339),
+			End:     gap.End.Format(time.RFC3339),
+			Seconds: gap.End.Sub(gap.Start).Seconds(),
+		})
+	}
+	return resp, nil
+}
+
+// twapWindow определяет окно расчета: последние window_seconds или явные from и to.
+// window_seconds сравнивается с maxWindow до перевода в time.Duration, чтобы умножение не переполнилось.
+func twapWindow(req *proto.GetTWAPRequest, now time.Time, maxWindow time.Duration) (time.Time, time.Time, error) {
+	if req.GetWindowSeconds() < 0 {
+		return time.Time{}, time.Time{}, errors.New("window_seconds must not be negative")
+	}
+	if maxWindow <= 0 {
+		maxWindow = math.MaxInt64
+	}
+	if req.GetWindowSeconds() > int64(maxWindow/time.Second) {
+		return time.Time{}, time.Time{}, fmt.Errorf("window_seconds exceeds maximum %s", maxWindow)
+	}
+	if req.GetWindowSeconds() > 0 {
+		return now.Add(-time.Duration(req.GetWindowSeconds()) * time.Second), now, nil
+	}
+
+	if req.GetFrom() == "" {
+		return time.Time{}, time.Time{}, errors.New("window_seconds or from is required")
+	}
+	from, err := time.Parse(time.RFC3339, req.GetFrom())
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("from must be RFC3339")
+	}
+	to := now
+	if req.GetTo() != "" {
+		if to, err = time.Parse(time.RFC3339, req.GetTo()); err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be RFC3339")
+		}
+	}
+	return from, to, nil
+}
+
+func gapPolicy(policy proto.GapPolicy) twap.GapPolicy {
+	switch policy {
+	case proto.GapPolicy_GAP_POLICY_CARRY_FORWARD:
+		return twap.GapCarryForward
+	case proto.GapPolicy_GAP_POLICY_FAIL:
+		return twap.GapFail
+	default:
+		return twap.GapExclude
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+	"gRPC-USDT/internal/twap"
+)
+
+func TestRateService_GetTWAP(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := storage.NewMemoryStorage(0)
+	require.NoError(t, store.SaveRates(context.Background(), []models.Rate{
+		{Ask: 101, Bid: 99, Time: base},
+		{Ask: 201, Bid: 199, Time: base.Add(5 * time.Minute)},
+		{Ask: 301, Bid: 299, Time: base.Add(6 * time.Minute)},
+	}))
+
+	service := NewRateService(nil, zap.NewNop(), &config.Config{}, nil)
+	service.SetTWAPCalculator(twap.NewCalculator(store, time.Minute, time.Hour))
+	ctx := context.Background()
+	window := &proto.GetTWAPRequest{
+		From: base.Format(time.RFC3339),
+		To:   base.Add(7 * time.Minute).Format(time.RFC3339),
+	}
+
+	t.Run("fixed window with gap excluded", func(t *testing.T) {
+		resp, err := service.GetTWAP(ctx, window)
+		require.NoError(t, err)
+		assert.InDelta(t, 200.0, resp.Twap, 1e-9)
+		assert.Equal(t, int64(3), resp.SampleCount)
+		assert.Equal(t, 180.0, resp.CoveredSeconds)
+		assert.Equal(t, 60.0, resp.MaxGapSeconds)
+		require.Len(t, resp.Gaps, 1)
+		assert.Equal(t, base.Format(time.RFC3339), resp.Gaps[0].Start)
+		assert.Equal(t, 300.0, resp.Gaps[0].Seconds)
+	})
+
+	t.Run("gap policy fail", func(t *testing.T) {
+		req := &proto.GetTWAPRequest{From: window.From, To: window.To, GapPolicy: proto.GapPolicy_GAP_POLICY_FAIL}
+		_, err := service.GetTWAP(ctx, req)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("recent window without rates", func(t *testing.T) {
+		_, err := service.GetTWAP(ctx, &proto.GetTWAPRequest{WindowSeconds: 900})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, req := range []*proto.GetTWAPRequest{
+			{},
+			{WindowSeconds: -1},
+			{From: "yesterday"},
+			{From: window.From, To: "today"},
+			{From: window.To, To: window.From},
+			{WindowSeconds: 2 * 3600},
+			{WindowSeconds: math.MaxInt64},
+		} {
+			_, err := service.GetTWAP(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+		}
+	})
+
+	t.Run("window seconds overflow without maximum", func(t *testing.T) {
+		unlimited := NewRateService(nil, zap.NewNop(), &config.Config{}, nil)
+		unlimited.SetTWAPCalculator(twap.NewCalculator(store, time.Minute, 0))
+		_, err := unlimited.GetTWAP(ctx, &proto.GetTWAPRequest{WindowSeconds: math.MaxInt64 / 1000})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.ErrorContains(t, err, "window_seconds exceeds maximum")
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		_, err := NewRateService(nil, zap.NewNop(), &config.Config{}, nil).GetTWAP(ctx, window)
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gRPC-USDT/internal/models"
+)
+
+// ErrAlertRuleNotFound возвращается, если правила оповещения с таким id нет
+var ErrAlertRuleNotFound = errors.New("alert rule not found")
+
+const alertRuleColumns = `id, name, condition, threshold, webhook_url, cooldown_seconds, enabled,
+                          last_fired_at, created_at, updated_at`
+
+// CreateAlertRule сохраняет новое правило и возвращает его с присвоенным id
+func (s *Storage) CreateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	query := `INSERT INTO alert_rules(name, condition, threshold, webhook_url, cooldown_seconds, enabled)
+              VALUES($1, $2, $3, $4, $5, $6) RETURNING ` + alertRuleColumns
+
+	rules, err := s.queryAlertRules(ctx, query,
+		rule.Name, string(rule.Condition), rule.Threshold, rule.WebhookURL, int64(rule.Cooldown/time.Second), rule.Enabled)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("create alert rule failed: %w", err)
+	}
+	if len(rules) == 0 {
+		return models.AlertRule{}, errors.New("create alert rule failed: no row returned")
+	}
+	return rules[0], nil
+}
+
+func (s *Storage) GetAlertRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	rules, err := s.queryAlertRules(ctx, "SELECT "+alertRuleColumns+" FROM alert_rules WHERE id = $1", id)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("get alert rule failed: %w", err)
+	}
+	if len(rules) == 0 {
+		return models.AlertRule{}, ErrAlertRuleNotFound
+	}
+	return rules[0], nil
+}
+
+func (s *Storage) ListAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	rules, err := s.queryAlertRules(ctx, "SELECT "+alertRuleColumns+" FROM alert_rules ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("list alert rules failed: %w", err)
+	}
+	return rules, nil
+}
+
+// UpdateAlertRule заменяет изменяемые поля правила; время последнего срабатывания сохраняется
+func (s *Storage) UpdateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	query := `UPDATE alert_rules SET name = $2, condition = $3, threshold = $4, webhook_url = $5,
+              cooldown_seconds = $6, enabled = $7, updated_at = now()
+              WHERE id = $1 RETURNING ` + alertRuleColumns
+
+	rules, err := s.queryAlertRules(ctx, query, rule.ID,
+		rule.Name, string(rule.Condition), rule.Threshold, rule.WebhookURL, int64(rule.Cooldown/time.Second), rule.Enabled)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("update alert rule failed: %w", err)
+	}
+	if len(rules) == 0 {
+		return models.AlertRule{}, ErrAlertRuleNotFound
+	}
+	return rules[0], nil
+}
+
+func (s *Storage) DeleteAlertRule(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM alert_rules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("delete alert rule failed: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+// MarkAlertFired запоминает время срабатывания, чтобы окно тишины переживало перезапуск
+func (s *Storage) MarkAlertFired(ctx context.Context, id int64, firedAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE alert_rules SET last_fired_at = $2 WHERE id = $1", id, firedAt); err != nil {
+		return fmt.Errorf("mark alert fired failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) queryAlertRules(ctx context.Context, query string, args ...interface{}) ([]models.AlertRule, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		var (
+			rule      models.AlertRule
+			condition string
+			cooldown  int64
+			lastFired sql.NullTime
+		)
+		if err := rows.Scan(&rule.ID, &rule.Name, &condition, &rule.Threshold, &rule.WebhookURL, &cooldown,
+			&rule.Enabled, &lastFired, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rule.Condition = models.AlertCondition(condition)
+		rule.Cooldown = time.Duration(cooldown) * time.Second
+		rule.LastFiredAt = lastFired.Time
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gRPC-USDT/internal/models"
+)
+
+var alertRuleRowColumns = []string{"id", "name", "condition", "threshold", "webhook_url", "cooldown_seconds",
+	"enabled", "last_fired_at", "created_at", "updated_at"}
+
+func TestStorage_AlertRules(t *testing.T) {
+	db, mok, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	s := &Storage{db: &DefaultDatabaseConnector{db: db}}
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rule := models.AlertRule{
+		Name:       "above",
+		Condition:  models.AlertPriceAbove,
+		Threshold:  100,
+		WebhookURL: "https://hooks.example.com",
+		Cooldown:   5 * time.Minute,
+		Enabled:    true,
+	}
+
+	mok.ExpectQuery("INSERT INTO alert_rules").
+		WithArgs("above", "price_above", 100.0, "https://hooks.example.com", int64(300), true).
+		WillReturnRows(sqlmock.NewRows(alertRuleRowColumns).
+			AddRow(int64(1), "above", "price_above", 100.0, "https://hooks.example.com", int64(300), true, nil, now, now))
+
+	created, err := s.CreateAlertRule(ctx, rule)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+	assert.Equal(t, models.AlertPriceAbove, created.Condition)
+	assert.Equal(t, 5*time.Minute, created.Cooldown)
+	assert.True(t, created.LastFiredAt.IsZero())
+
+	mok.ExpectQuery("FROM alert_rules WHERE id").WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows(alertRuleRowColumns))
+	_, err = s.GetAlertRule(ctx, 2)
+	assert.ErrorIs(t, err, ErrAlertRuleNotFound)
+
+	mok.ExpectQuery("UPDATE alert_rules SET name").
+		WillReturnRows(sqlmock.NewRows(alertRuleRowColumns))
+	_, err = s.UpdateAlertRule(ctx, models.AlertRule{ID: 2})
+	assert.ErrorIs(t, err, ErrAlertRuleNotFound)
+
+	mok.ExpectExec("DELETE FROM alert_rules").WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, s.DeleteAlertRule(ctx, 2), ErrAlertRuleNotFound)
+
+	mok.ExpectExec("UPDATE alert_rules SET last_fired_at").WithArgs(int64(1), now).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.MarkAlertFired(ctx, 1, now))
+
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// Размер пачки ограничен числом параметров запроса: у Postgres их не более 65535,
+// а каждый курс в многострочном INSERT (см. Storage.SaveRates) занимает rateInsertColumns параметров
+const (
+	postgresMaxParams = 65535
+	rateInsertColumns = 7
+	maxBatchSize      = postgresMaxParams / rateInsertColumns
+)
+
+// ErrBufferFull возвращается, если буфер записи не освободился за время ожидания
+var ErrBufferFull = errors.New("write-behind buffer is full")
+
+// ErrWriterClosed возвращается при записи в остановленный буфер
+var ErrWriterClosed = errors.New("write-behind buffer is closed")
+
+// BatchSaver сохраняет пачку курсов одной операцией
+type BatchSaver interface {
+	SaveRates(ctx context.Context, rates []models.Rate) error
+}
+
+// BatchConfig настройки буфера отложенной записи
+type BatchConfig struct {
+	BatchSize      int           // Размер пачки, при достижении которого буфер сбрасывается
+	FlushInterval  time.Duration // Максимальное время ожидания перед сбросом неполной пачки
+	QueueSize      int           // Емкость буфера; при заполнении SaveRate ждет свободного места
+	EnqueueTimeout time.Duration // Сколько SaveRate ждет места в буфере
+	FlushTimeout   time.Duration // Таймаут одного сброса в хранилище
+	MaxRetries     int           // Повторы неудачного сброса, после которых пачка отбрасывается
+	RetryBackoff   time.Duration // Пауза перед первым повтором, удваивается с каждым следующим
+}
+
+// BatchWriter буферизует курсы и сохраняет их пачками в фоне (write-behind)
+type BatchWriter struct {
+	saver  BatchSaver
+	logger *zap.Logger
+	cfg    BatchConfig
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan models.Rate
+	done   chan struct{}
+	err    error // Ошибка последнего сброса при остановке; читается после закрытия done
+}
+
+// NewBatchWriter создает буфер и запускает фоновый сброс
+func NewBatchWriter(saver BatchSaver, logger *zap.Logger, cfg BatchConfig) *BatchWriter {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.BatchSize > maxBatchSize {
+		cfg.BatchSize = maxBatchSize
+	}
+	if cfg.QueueSize < cfg.BatchSize {
+		cfg.QueueSize = cfg.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.EnqueueTimeout <= 0 {
+		cfg.EnqueueTimeout = 500 * time.Millisecond
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = 30 * time.Second
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	w := &BatchWriter{
+		saver:  saver,
+		logger: logger,
+		cfg:    cfg,
+		queue:  make(chan models.Rate, cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// SaveRate ставит курс в очередь на запись. Если буфер заполнен, ждет EnqueueTimeout или отмены контекста.
+func (w *BatchWriter) SaveRate(
+	ctx context.Context,
+	ask, bid, askAmount, bidAmount float64,
+	ts time.Time,
+) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	rate := models.Rate{Ask: ask, Bid: bid, AskAmount: askAmount, BidAmount: bidAmount, Time: ts}
+
+	// Быстрый путь: место в буфере есть
+	select {
+	case w.queue <- rate:
+		metrics.WriteQueueDepth.Set(float64(len(w.queue)))
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(w.cfg.EnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case w.queue <- rate:
+		metrics.WriteQueueDepth.Set(float64(len(w.queue)))
+		return nil
+	case <-timer.C:
+		metrics.WriteRejected.Inc()
+		return ErrBufferFull
+	case <-ctx.Done():
+		metrics.WriteRejected.Inc()
+		return fmt.Errorf("enqueue rate: %w", ctx.Err())
+	}
+}
+
+// Close прекращает прием курсов и дожидается сброса оставшихся в буфере.
+// Если последнюю пачку не удалось сохранить, возвращает ошибку сброса.
+func (w *BatchWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		if w.err != nil {
+			return fmt.Errorf("final flush failed: %w", w.err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("final flush did not complete: %w", ctx.Err())
+	}
+}
+
+func (w *BatchWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Rate, 0, w.cfg.BatchSize)
+	for {
+		select {
+		case rate, ok := <-w.queue:
+			if !ok {
+				w.err = w.flush(batch)
+				return
+			}
+			batch = append(batch, rate)
+			if len(batch) >= w.cfg.BatchSize {
+				_ = w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				_ = w.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush сохраняет пачку, повторяя неудачный сброс до MaxRetries раз с удвоением паузы.
+// Пока идут повторы, новые курсы копятся в буфере, а при его заполнении SaveRate отказывает.
+// Пачка, не сохраненная после всех повторов, отбрасывается; возвращается последняя ошибка.
+func (w *BatchWriter) flush(batch []models.Rate) error {
+	metrics.WriteQueueDepth.Set(float64(len(w.queue)))
+	if len(batch) == 0 {
+		return nil
+	}
+
+	backoff := w.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := w.save(batch)
+		if err == nil {
+			w.logger.Debug("Rates flushed", zap.Int("batch_size", len(batch)))
+			return nil
+		}
+		if attempt >= w.cfg.MaxRetries {
+			metrics.WriteDropped.Add(float64(len(batch)))
+			w.logger.Error("Error flushing rates, batch dropped",
+				zap.Int("batch_size", len(batch)), zap.Int("attempts", attempt+1), zap.Error(err))
+			return err
+		}
+		w.logger.Warn("Error flushing rates, retrying", zap.Int("batch_size", len(batch)),
+			zap.Int("attempt", attempt+1), zap.Duration("retry_in", backoff), zap.Error(err))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// save выполняет одну попытку сброса пачки
+func (w *BatchWriter) save(batch []models.Rate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.FlushTimeout)
+	defer cancel()
+
+	tr := otel.GetTracerProvider().Tracer("storage-write-behind")
+	ctx, span := tr.Start(ctx, "FlushRates")
+	defer span.End()
+	span.SetAttributes(attribute.Int("batch.size", len(batch)))
+
+	start := time.Now()
+	err := w.saver.SaveRates(ctx, batch)
+	metrics.WriteFlushLatency.Observe(time.Since(start).Seconds())
+	metrics.WriteBatchSize.Observe(float64(len(batch)))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "flush failed")
+		metrics.WriteFlushErrors.Inc()
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gRPC-USDT/internal/models"
+)
+
+// fakeBatchSaver запоминает сохраненные пачки
+type fakeBatchSaver struct {
+	mu      sync.Mutex
+	batches [][]models.Rate
+	err     error
+	fails   int // Сколько первых вызовов завершаются ошибкой err; 0 - все
+	block   chan struct{}
+}
+
+func (f *fakeBatchSaver) SaveRates(_ context.Context, rates []models.Rate) error {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]models.Rate(nil), rates...))
+	if f.fails > 0 && len(f.batches) > f.fails {
+		return nil
+	}
+	return f.err
+}
+
+func (f *fakeBatchSaver) sizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sizes := make([]int, 0, len(f.batches))
+	for _, b := range f.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func saveN(t *testing.T, w *BatchWriter, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, w.SaveRate(context.Background(), 100+float64(i), 99, 1, 2, time.Now()))
+	}
+}
+
+func TestBatchWriter_FlushBySize(t *testing.T) {
+	saver := &fakeBatchSaver{}
+	w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{BatchSize: 3, QueueSize: 10, FlushInterval: time.Hour})
+
+	saveN(t, w, 7)
+	require.Eventually(t, func() bool { return len(saver.sizes()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{3, 3}, saver.sizes())
+
+	// Остаток сбрасывается при остановке
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []int{3, 3, 1}, saver.sizes())
+}
+
+func TestBatchWriter_FlushByInterval(t *testing.T) {
+	saver := &fakeBatchSaver{}
+	w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer func() { _ = w.Close(context.Background()) }()
+
+	saveN(t, w, 2)
+	require.Eventually(t, func() bool { return len(saver.sizes()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{2}, saver.sizes())
+}
+
+func TestBatchWriter_PreservesOrder(t *testing.T) {
+	saver := &fakeBatchSaver{}
+	w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{BatchSize: 2, FlushInterval: time.Hour})
+
+	saveN(t, w, 5)
+	require.NoError(t, w.Close(context.Background()))
+
+	var asks []float64
+	for _, b := range saver.batches {
+		for _, r := range b {
+			asks = append(asks, r.Ask)
+		}
+	}
+	assert.Equal(t, []float64{100, 101, 102, 103, 104}, asks)
+}
+
+func TestBatchWriter_Backpressure(t *testing.T) {
+	saver := &fakeBatchSaver{block: make(chan struct{})}
+	w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{
+		BatchSize:      1,
+		QueueSize:      1,
+		FlushInterval:  time.Hour,
+		EnqueueTimeout: 20 * time.Millisecond,
+	})
+
+	// Первый курс забирает фоновый сброс (и блокируется), второй занимает буфер
+	saveN(t, w, 1)
+	require.Eventually(t, func() bool { return len(w.queue) == 0 }, time.Second, time.Millisecond)
+	saveN(t, w, 1)
+
+	err := w.SaveRate(context.Background(), 1, 1, 1, 1, time.Now())
+	assert.ErrorIs(t, err, ErrBufferFull)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.cfg.EnqueueTimeout = time.Hour
+	err = w.SaveRate(ctx, 1, 1, 1, 1, time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(saver.block)
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []int{1, 1}, saver.sizes())
+}
+
+func TestBatchWriter_Closed(t *testing.T) {
+	w := NewBatchWriter(&fakeBatchSaver{}, zap.NewNop(), BatchConfig{})
+	require.NoError(t, w.Close(context.Background()))
+	require.NoError(t, w.Close(context.Background()))
+
+	err := w.SaveRate(context.Background(), 1, 1, 1, 1, time.Now())
+	assert.ErrorIs(t, err, ErrWriterClosed)
+}
+
+func TestBatchWriter_FlushError(t *testing.T) {
+	t.Run("dropped after retries", func(t *testing.T) {
+		saver := &fakeBatchSaver{err: errors.New("db down")}
+		w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{BatchSize: 10, FlushInterval: time.Hour,
+			MaxRetries: 1, RetryBackoff: time.Millisecond})
+
+		saveN(t, w, 2)
+		// Последняя пачка сохраняется дважды, ее ошибка возвращается при остановке
+		assert.ErrorContains(t, w.Close(context.Background()), "final flush failed: db down")
+		assert.Equal(t, []int{2, 2}, saver.sizes())
+	})
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		saver := &fakeBatchSaver{err: errors.New("connection reset"), fails: 2}
+		w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{BatchSize: 3, FlushInterval: time.Hour,
+			MaxRetries: 3, RetryBackoff: time.Millisecond})
+
+		saveN(t, w, 3)
+		require.NoError(t, w.Close(context.Background()))
+		assert.Equal(t, []int{3, 3, 3}, saver.sizes())
+	})
+}
+
+func TestBatchWriter_CloseDeadline(t *testing.T) {
+	saver := &fakeBatchSaver{block: make(chan struct{})}
+	defer close(saver.block)
+	w := NewBatchWriter(saver, zap.NewNop(), BatchConfig{BatchSize: 1})
+	saveN(t, w, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Close(ctx), context.DeadlineExceeded)
+}
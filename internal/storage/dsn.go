@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ConnConfig параметры подключения к Postgres.
+// Если задан URL, он используется целиком, а Host, Port, Name и параметры TLS игнорируются;
+// User и Password, если заданы, заменяют учетные данные из URL (например, полученные из хранилища секретов).
+type ConnConfig struct {
+	URL string
+
+	Host     string
+	Port     int
+	Name     string
+	User     string
+	Password string
+
+	SSLMode     string
+	SSLRootCert string // CA для проверки сертификата сервера
+	SSLCert     string // Клиентский сертификат
+	SSLKey      string // Ключ клиентского сертификата
+
+	SearchPath      string
+	ApplicationName string
+}
+
+// DSN строит строку подключения в формате URL. Одна и та же строка используется пулом и миграциями.
+func (c ConnConfig) DSN() (string, error) {
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return "", fmt.Errorf("parse database url: %w", err)
+		}
+		if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			return "", fmt.Errorf("database url: unsupported scheme %q", u.Scheme)
+		}
+		if c.User != "" || c.Password != "" {
+			u.User = url.UserPassword(c.User, c.Password)
+		}
+		return u.String(), nil
+	}
+
+	q := url.Values{}
+	for key, value := range map[string]string{
+		"sslmode":          c.SSLMode,
+		"sslrootcert":      c.SSLRootCert,
+		"sslcert":          c.SSLCert,
+		"sslkey":           c.SSLKey,
+		"search_path":      c.SearchPath,
+		"application_name": c.ApplicationName,
+	} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: q.Encode(),
+	}
+	return u.String(), nil
+}
+
+// migrationDSN добавляет к строке подключения параметры драйвера миграций, сохраняя параметры TLS и сессии.
+// partitioning передается миграциям настройкой сессии rates.partitioning.
+func migrationDSN(dsn string, partitioning bool) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parse dsn: %w", err)
+	}
+	q := u.Query()
+	q.Set("x-migrations-table", "schema_migrations")
+	if partitioning {
+		q.Set("options", strings.TrimSpace(q.Get("options")+" -c rates.partitioning=monthly"))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"gRPC-USDT/internal/export"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExportQuery фильтр выгрузки истории курсов
+type ExportQuery struct {
+	Symbol string    // Пара, пустая - пара хранилища
+	From   time.Time // Начало периода (включительно), нулевое значение - без ограничения
+	To     time.Time // Конец периода (не включительно), нулевое значение - без ограничения
+}
+
+// exportFetchSize число курсов, которые выгрузка получает из курсора за одно обращение к базе
+var exportFetchSize = 1000
+
+// ExportRates передает в fn курсы за период в порядке возрастания времени. Курсы читаются
+// серверным курсором порциями по exportFetchSize, поэтому память не зависит от размера выгрузки.
+// Ошибка fn прерывает выгрузку и возвращается как есть.
+func (s *Storage) ExportRates(ctx context.Context, q ExportQuery, fn func(row export.Row) error) (err error) {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	if q.Symbol == "" {
+		q.Symbol = s.symbol
+	}
+	query, args := buildExportQuery(q)
+
+	tr := otel.GetTracerProvider().Tracer("storage-postgres")
+	ctx, span := tr.Start(ctx, "ExportRates",
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			attribute.String("db.operation", "SELECT"),
+			attribute.String("db.statement", query),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "export rates failed")
+		}
+		span.End()
+	}()
+
+	// Курсор существует только внутри транзакции; при ошибке он закрывается вместе с ней
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE rates_export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("declare export cursor: %w", err)
+	}
+	fetch := fmt.Sprintf("FETCH %d FROM rates_export", exportFetchSize)
+	for {
+		n, err := fetchExport(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			break
+		}
+	}
+	return tx.Commit()
+}
+
+// fetchExport передает в fn очередную порцию курсов курсора и возвращает ее размер
+func fetchExport(ctx context.Context, tx *sql.Tx, fetch string, fn func(row export.Row) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("fetch export cursor: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	n := 0
+	for rows.Next() {
+		var row export.Row
+		if err := rows.Scan(&row.Timestamp, &row.Symbol, &row.Source,
+			&row.Ask, &row.Bid, &row.AskAmount, &row.BidAmount); err != nil {
+			return n, fmt.Errorf("fetch export cursor: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("fetch export cursor: %w", err)
+	}
+	return n, nil
+}
+
+func buildExportQuery(q ExportQuery) (string, []interface{}) {
+	conditions := []string{"symbol = $1"}
+	args := []interface{}{q.Symbol}
+	if !q.From.IsZero() {
+		args = append(args, q.From)
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+	if !q.To.IsZero() {
+		args = append(args, q.To)
+		conditions = append(conditions, fmt.Sprintf("timestamp < $%d", len(args)))
+	}
+	query := "SELECT timestamp, symbol, source, ask, bid, ask_amount, bid_amount FROM rates WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY timestamp"
+	return query, args
+}
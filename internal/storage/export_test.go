@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gRPC-USDT/internal/export"
+)
+
+var exportColumns = []string{"timestamp", "symbol", "source", "ask", "bid", "ask_amount", "bid_amount"}
+
+func TestStorage_ExportRates(t *testing.T) {
+	previous := exportFetchSize
+	exportFetchSize = 2
+	t.Cleanup(func() { exportFetchSize = previous })
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	const declare = "DECLARE rates_export NO SCROLL CURSOR FOR SELECT timestamp, symbol, source, ask, bid, " +
+		"ask_amount, bid_amount FROM rates WHERE symbol = $1 AND timestamp >= $2 AND timestamp < $3 ORDER BY timestamp"
+
+	newExportStorage := func(t *testing.T) (*Storage, sqlmock.Sqlmock) {
+		db, mok, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		s := &Storage{db: &DefaultDatabaseConnector{db: db}}
+		s.SetSymbol("BTCUSDT", DefaultSource)
+		return s, mok
+	}
+
+	t.Run("fetches cursor in batches", func(t *testing.T) {
+		s, mok := newExportStorage(t)
+		mok.ExpectBegin()
+		mok.ExpectExec(declare).WithArgs("ETHUSDT", from, to).WillReturnResult(sqlmock.NewResult(0, 0))
+		mok.ExpectQuery("FETCH 2 FROM rates_export").WillReturnRows(sqlmock.NewRows(exportColumns).
+			AddRow(from, "ETHUSDT", "binance", 2.5, 2.4, 1.0, 2.0).
+			AddRow(from.Add(time.Second), "ETHUSDT", "binance", 2.6, 2.5, 3.0, 4.0))
+		mok.ExpectQuery("FETCH 2 FROM rates_export").WillReturnRows(sqlmock.NewRows(exportColumns).
+			AddRow(from.Add(2*time.Second), "ETHUSDT", "binance", 2.7, 2.6, 5.0, 6.0))
+		mok.ExpectCommit()
+
+		var rows []export.Row
+		err := s.ExportRates(context.Background(), ExportQuery{Symbol: "ETHUSDT", From: from, To: to},
+			func(row export.Row) error {
+				rows = append(rows, row)
+				return nil
+			})
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.Equal(t, export.Row{Timestamp: from, Symbol: "ETHUSDT", Source: "binance",
+			Ask: 2.5, Bid: 2.4, AskAmount: 1, BidAmount: 2}, rows[0])
+		assert.Equal(t, 2.7, rows[2].Ask)
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+
+	t.Run("default symbol without period", func(t *testing.T) {
+		s, mok := newExportStorage(t)
+		mok.ExpectBegin()
+		mok.ExpectExec("DECLARE rates_export NO SCROLL CURSOR FOR SELECT timestamp, symbol, source, ask, bid, " +
+			"ask_amount, bid_amount FROM rates WHERE symbol = $1 ORDER BY timestamp").
+			WithArgs("BTCUSDT").WillReturnResult(sqlmock.NewResult(0, 0))
+		mok.ExpectQuery("FETCH 2 FROM rates_export").WillReturnRows(sqlmock.NewRows(exportColumns))
+		mok.ExpectCommit()
+
+		err := s.ExportRates(context.Background(), ExportQuery{}, func(export.Row) error {
+			t.Fatal("unexpected row")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+
+	t.Run("consumer error rolls back", func(t *testing.T) {
+		s, mok := newExportStorage(t)
+		mok.ExpectBegin()
+		mok.ExpectExec(declare).WithArgs("BTCUSDT", from, to).WillReturnResult(sqlmock.NewResult(0, 0))
+		mok.ExpectQuery("FETCH 2 FROM rates_export").WillReturnRows(sqlmock.NewRows(exportColumns).
+			AddRow(from, "BTCUSDT", "binance", 1.0, 1.0, 1.0, 1.0))
+		mok.ExpectRollback()
+
+		sendErr := errors.New("client gone")
+		err := s.ExportRates(context.Background(), ExportQuery{From: from, To: to}, func(export.Row) error {
+			return sendErr
+		})
+		assert.ErrorIs(t, err, sendErr)
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+
+	t.Run("declare error", func(t *testing.T) {
+		s, mok := newExportStorage(t)
+		mok.ExpectBegin()
+		mok.ExpectExec(declare).WithArgs("BTCUSDT", from, to).WillReturnError(sql.ErrConnDone)
+		mok.ExpectRollback()
+
+		err := s.ExportRates(context.Background(), ExportQuery{From: from, To: to}, func(export.Row) error {
+			return nil
+		})
+		assert.ErrorContains(t, err, "declare export cursor")
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+}
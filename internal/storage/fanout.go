@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// sinkQueueSize - емкость очереди записей одного дополнительного приемника
+	sinkQueueSize = 1000
+	// defaultSinkTimeout - таймаут записи в приемник, если Sink.Timeout не задан
+	defaultSinkTimeout = 5 * time.Second
+)
+
+// Sink дополнительный приемник курсов для FanOutStorage
+type Sink struct {
+	Name    string
+	Writer  RateWriter
+	Timeout time.Duration // Таймаут одной записи; по умолчанию defaultSinkTimeout
+}
+
+// sinkWrite - запись, ожидающая отправки в дополнительный приемник
+type sinkWrite struct {
+	ctx  context.Context
+	save func(ctx context.Context, w RateWriter) error
+}
+
+// FanOutStorage пишет каждый курс в основное хранилище, а после успешной записи - во все дополнительные приемники.
+// Результат записи определяет только основное хранилище. Приемники пишутся в фоне через ограниченные очереди,
+// поэтому медленный приемник не задерживает запрос: при переполнении очереди курс для него отбрасывается,
+// а отказы логируются и учитываются в метриках.
+type FanOutStorage struct {
+	primary     RateWriter
+	primaryName string
+	sinks       []Sink
+	logger      *zap.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queues []chan sinkWrite
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+// NewFanOutStorage создает хранилище с основным приемником primary и дополнительными sinks
+// и запускает фоновую запись в приемники. Остановить ее нужно через Close.
+func NewFanOutStorage(primaryName string, primary RateWriter, logger *zap.Logger, sinks ...Sink) *FanOutStorage {
+	f := &FanOutStorage{
+		primary:     primary,
+		primaryName: primaryName,
+		sinks:       sinks,
+		logger:      logger,
+		queues:      make([]chan sinkWrite, len(sinks)),
+		done:        make(chan struct{}),
+	}
+	for i := range sinks {
+		if f.sinks[i].Timeout <= 0 {
+			f.sinks[i].Timeout = defaultSinkTimeout
+		}
+		f.queues[i] = make(chan sinkWrite, sinkQueueSize)
+		f.wg.Add(1)
+		go f.run(f.sinks[i], f.queues[i])
+	}
+	go func() {
+		f.wg.Wait()
+		close(f.done)
+	}()
+	return f
+}
+
+func (f *FanOutStorage) SaveRate(
+	ctx context.Context,
+	ask, bid, askAmount, bidAmount float64,
+	ts time.Time,
+) error {
+	return f.write(ctx, func(ctx context.Context, w RateWriter) error {
+		return w.SaveRate(ctx, ask, bid, askAmount, bidAmount, ts)
+	})
+}
+
+func (f *FanOutStorage) SaveRates(ctx context.Context, rates []models.Rate) error {
+	return f.write(ctx, func(ctx context.Context, w RateWriter) error {
+		return w.SaveRates(ctx, rates)
+	})
+}
+
+// Close прекращает прием записей в приемники и дожидается отправки уже поставленных в очередь
+func (f *FanOutStorage) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		for _, queue := range f.queues {
+			close(queue)
+		}
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sink writes did not complete: %w", ctx.Err())
+	}
+}
+
+func (f *FanOutStorage) write(ctx context.Context, save func(ctx context.Context, w RateWriter) error) error {
+	if err := observeSink(f.primaryName, func() error { return save(ctx, f.primary) }); err != nil {
+		return err
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Запись в приемник переживает завершение запроса, но сохраняет значения контекста, например спан
+	job := sinkWrite{ctx: context.WithoutCancel(ctx), save: save}
+	for i, queue := range f.queues {
+		reason := "closed"
+		if !f.closed {
+			select {
+			case queue <- job:
+				continue
+			default:
+				reason = "queue is full"
+			}
+		}
+		metrics.SinkWrites.WithLabelValues(f.sinks[i].Name, "dropped").Inc()
+		f.logger.Warn("Storage sink write dropped", zap.String("sink", f.sinks[i].Name), zap.String("reason", reason))
+	}
+	return nil
+}
+
+func (f *FanOutStorage) run(sink Sink, queue <-chan sinkWrite) {
+	defer f.wg.Done()
+	for job := range queue {
+		ctx, cancel := context.WithTimeout(job.ctx, sink.Timeout)
+		err := observeSink(sink.Name, func() error { return job.save(ctx, sink.Writer) })
+		cancel()
+		if err != nil {
+			f.logger.Warn("Storage sink write failed", zap.String("sink", sink.Name), zap.Error(err))
+		}
+	}
+}
+
+func observeSink(name string, save func() error) error {
+	start := time.Now()
+	err := save()
+	metrics.SinkWriteLatency.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.SinkWrites.WithLabelValues(name, result).Inc()
+	return err
+}
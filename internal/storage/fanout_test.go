@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gRPC-USDT/internal/models"
+)
+
+func TestFanOutStorage_SaveRate(t *testing.T) {
+	rate := testRate(1)
+
+	t.Run("all sinks receive the rate", func(t *testing.T) {
+		primary := &MockRateWriter{}
+		primary.On("SaveRate", mock.Anything, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time).Return(nil)
+		secondary := &MockRateWriter{}
+		secondary.On("SaveRate", mock.Anything, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time).Return(nil)
+
+		f := NewFanOutStorage("postgres", primary, zap.NewNop(), Sink{Name: "tsdb", Writer: secondary})
+		assert.NoError(t, f.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		require.NoError(t, f.Close(context.Background()))
+
+		primary.AssertExpectations(t)
+		secondary.AssertExpectations(t)
+	})
+
+	t.Run("secondary sink failure is tolerated", func(t *testing.T) {
+		primary := &MockRateWriter{}
+		primary.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		secondary := &MockRateWriter{}
+		secondary.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("tsdb is down"))
+
+		f := NewFanOutStorage("postgres", primary, zap.NewNop(), Sink{Name: "tsdb", Writer: secondary})
+		assert.NoError(t, f.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		require.NoError(t, f.Close(context.Background()))
+		secondary.AssertExpectations(t)
+	})
+
+	t.Run("primary failure is returned", func(t *testing.T) {
+		primary := &MockRateWriter{}
+		primary.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("db is down"))
+		secondary := &MockRateWriter{}
+
+		f := NewFanOutStorage("postgres", primary, zap.NewNop(), Sink{Name: "tsdb", Writer: secondary})
+		err := f.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time)
+		assert.EqualError(t, err, "db is down")
+		require.NoError(t, f.Close(context.Background()))
+
+		// Курс, не сохраненный основным хранилищем, в приемники не попадает
+		secondary.AssertNotCalled(t, "SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("slow sink does not block the write", func(t *testing.T) {
+		primary := &MockRateWriter{}
+		primary.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		slow := &blockingWriter{release: make(chan struct{})}
+
+		f := NewFanOutStorage("postgres", primary, zap.NewNop(), Sink{Name: "tsdb", Writer: slow, Timeout: time.Hour})
+		assert.NoError(t, f.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		require.Eventually(t, func() bool { return slow.calls.Load() == 1 }, time.Second, time.Millisecond)
+		for i := 0; i < sinkQueueSize+10; i++ {
+			assert.NoError(t, f.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		}
+		close(slow.release)
+		require.NoError(t, f.Close(context.Background()))
+
+		// Курсы сверх очереди отбрасываются: первый ожидал в приемнике, остальные - в очереди
+		assert.Equal(t, int32(sinkQueueSize+1), slow.calls.Load())
+	})
+
+	t.Run("sink write is bounded by timeout", func(t *testing.T) {
+		primary := &MockRateWriter{}
+		primary.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		hung := &blockingWriter{}
+
+		f := NewFanOutStorage("postgres", primary, zap.NewNop(), Sink{Name: "tsdb", Writer: hung, Timeout: 10 * time.Millisecond})
+		// Отмена контекста запроса не прерывает запись в приемник
+		ctx, cancel := context.WithCancel(context.Background())
+		assert.NoError(t, f.SaveRate(ctx, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		cancel()
+
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+		defer closeCancel()
+		require.NoError(t, f.Close(closeCtx))
+		assert.Equal(t, int32(1), hung.calls.Load())
+	})
+}
+
+// blockingWriter ждет release или отмены контекста записи
+type blockingWriter struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (w *blockingWriter) SaveRate(ctx context.Context, _, _, _, _ float64, _ time.Time) error {
+	w.calls.Add(1)
+	select {
+	case <-w.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *blockingWriter) SaveRates(ctx context.Context, _ []models.Rate) error {
+	return w.SaveRate(ctx, 0, 0, 0, 0, time.Time{})
+}
+
+func TestFanOutStorage_SaveRates(t *testing.T) {
+	rates := []models.Rate{testRate(1), testRate(2)}
+
+	primary := &MockRateWriter{}
+	primary.On("SaveRates", mock.Anything, rates).Return(nil)
+	first := &MockRateWriter{}
+	first.On("SaveRates", mock.Anything, rates).Return(errors.New("tsdb is down"))
+	second := &MockRateWriter{}
+	second.On("SaveRates", mock.Anything, rates).Return(nil)
+
+	f := NewFanOutStorage("postgres", primary, zap.NewNop(),
+		Sink{Name: "influx", Writer: first},
+		Sink{Name: "remote-write", Writer: second},
+	)
+	assert.NoError(t, f.SaveRates(context.Background(), rates))
+	require.NoError(t, f.Close(context.Background()))
+
+	primary.AssertExpectations(t)
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+)
+
+// MemoryStorage хранит курсы в памяти процесса.
+// Предназначено для локального запуска и тестов: данные теряются при остановке.
+type MemoryStorage struct {
+	mu         sync.RWMutex
+	rates      []models.Rate // Отсортированы по времени
+	quarantine []QuarantinedRate
+	maxRates   int
+}
+
+// NewMemoryStorage создает хранилище в памяти.
+// При maxRates > 0 хранится не больше maxRates последних курсов.
+func NewMemoryStorage(maxRates int) *MemoryStorage {
+	return &MemoryStorage{maxRates: maxRates}
+}
+
+// Migrate ничего не делает: схема хранилища в памяти не требует миграций
+func (m *MemoryStorage) Migrate(string) error {
+	return nil
+}
+
+func (m *MemoryStorage) SaveRate(
+	ctx context.Context,
+	ask, bid, askAmount, bidAmount float64,
+	ts time.Time,
+) error {
+	return m.SaveRates(ctx, []models.Rate{{
+		Ask:       ask,
+		Bid:       bid,
+		AskAmount: askAmount,
+		BidAmount: bidAmount,
+		Time:      ts,
+	}})
+}
+
+func (m *MemoryStorage) SaveRates(ctx context.Context, rates []models.Rate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rate := range rates {
+		// Курсы почти всегда приходят по возрастанию времени, поэтому ищем позицию с конца
+		i := len(m.rates)
+		for i > 0 && m.rates[i-1].Time.After(rate.Time) {
+			i--
+		}
+		m.rates = append(m.rates, models.Rate{})
+		copy(m.rates[i+1:], m.rates[i:])
+		m.rates[i] = rate
+	}
+
+	if m.maxRates > 0 && len(m.rates) > m.maxRates {
+		m.rates = append(m.rates[:0:0], m.rates[len(m.rates)-m.maxRates:]...)
+	}
+
+	metrics.DBSaves.Add(float64(len(rates)))
+	return nil
+}
+
+// GetRates возвращает курсы за период в порядке возрастания времени (убывания при q.Descending)
+func (m *MemoryStorage) GetRates(ctx context.Context, q RateQuery) ([]models.Rate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	start := 0
+	if !q.From.IsZero() {
+		start = sort.Search(len(m.rates), func(i int) bool {
+			return !m.rates[i].Time.Before(q.From)
+		})
+	}
+	end := len(m.rates)
+	if !q.To.IsZero() {
+		end = sort.Search(len(m.rates), func(i int) bool {
+			return !m.rates[i].Time.Before(q.To)
+		})
+	}
+	window := m.rates[start:max(start, end)]
+	var rates []models.Rate
+	for i := range window {
+		rate := window[i]
+		if q.Descending {
+			rate = window[len(window)-1-i]
+		}
+		if q.Limit > 0 && len(rates) >= q.Limit {
+			break
+		}
+		if q.matchAnalytics(rate) {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// LatestRate возвращает последний сохраненный курс
+func (m *MemoryStorage) LatestRate(ctx context.Context) (models.Rate, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Rate{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.rates) == 0 {
+		return models.Rate{}, ErrNotFound
+	}
+	return m.rates[len(m.rates)-1], nil
+}
+
+// NearestRate возвращает курс, ближайший по времени к at
+func (m *MemoryStorage) NearestRate(ctx context.Context, at time.Time) (models.Rate, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Rate{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Кандидаты - последний курс не позже at и первый курс позже него
+	i := sort.Search(len(m.rates), func(i int) bool {
+		return m.rates[i].Time.After(at)
+	})
+	var candidates []models.Rate
+	if i > 0 {
+		candidates = append(candidates, m.rates[i-1])
+	}
+	if i < len(m.rates) {
+		candidates = append(candidates, m.rates[i])
+	}
+	return nearestRate(candidates, at)
+}
+
+// Ping всегда успешен: хранилище в памяти доступно, пока жив процесс
+func (m *MemoryStorage) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gRPC-USDT/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testHistory проверяет общий для всех бэкендов контракт истории курсов
+func testHistory(t *testing.T, store Interface) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := store.LatestRate(ctx)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.NearestRate(ctx, base)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Второй курс приходит с опозданием и должен встать по времени
+	require.NoError(t, store.SaveRate(ctx, 100, 99, 1, 2, base))
+	require.NoError(t, store.SaveRate(ctx, 102, 101, 1, 2, base.Add(2*time.Minute)))
+	require.NoError(t, store.SaveRates(ctx, []models.Rate{
+		{Ask: 101, Bid: 100, AskAmount: 1, BidAmount: 2, Time: base.Add(time.Minute)},
+		{Ask: 103, Bid: 102, AskAmount: 1, BidAmount: 2, Time: base.Add(3 * time.Minute)},
+	}))
+
+	latest, err := store.LatestRate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 103.0, latest.Ask)
+	assert.True(t, latest.Time.Equal(base.Add(3*time.Minute)))
+
+	all, err := store.GetRates(ctx, RateQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{100, 101, 102, 103}, asksOf(all))
+
+	window, err := store.GetRates(ctx, RateQuery{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{101, 102}, asksOf(window))
+	assert.True(t, window[0].Time.Equal(base.Add(time.Minute)))
+	assert.Equal(t, 2.0, window[0].BidAmount)
+
+	limited, err := store.GetRates(ctx, RateQuery{From: base.Add(time.Minute), Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{101}, asksOf(limited))
+
+	before, err := store.GetRates(ctx, RateQuery{To: base.Add(150 * time.Second), Limit: 2, Descending: true})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{102, 101}, asksOf(before))
+
+	empty, err := store.GetRates(ctx, RateQuery{From: base.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	nearest, err := store.NearestRate(ctx, base.Add(80*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 101.0, nearest.Ask)
+	nearest, err = store.NearestRate(ctx, base.Add(100*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 102.0, nearest.Ask)
+	// При равном удалении выбирается более ранний курс
+	nearest, err = store.NearestRate(ctx, base.Add(90*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 101.0, nearest.Ask)
+	nearest, err = store.NearestRate(ctx, base.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, nearest.Ask)
+	nearest, err = store.NearestRate(ctx, base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 103.0, nearest.Ask)
+
+	// Широкий спред и перевес ask - отбирается фильтрами по производным показателям
+	require.NoError(t, store.SaveRate(ctx, 110, 90, 3, 1, base.Add(2*time.Hour)))
+	minBps, maxImbalance := 500.0, 0.0
+	wide, err := store.GetRates(ctx, RateQuery{MinSpreadBps: &minBps})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{110}, asksOf(wide))
+
+	askHeavy, err := store.GetRates(ctx, RateQuery{MaxImbalance: &maxImbalance})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{110}, asksOf(askHeavy))
+
+	narrow, err := store.GetRates(ctx, RateQuery{MaxSpreadBps: &minBps, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{100, 101}, asksOf(narrow))
+}
+
+func asksOf(rates []models.Rate) []float64 {
+	asks := make([]float64, 0, len(rates))
+	for _, rate := range rates {
+		asks = append(asks, rate.Ask)
+	}
+	return asks
+}
+
+func TestMemoryStorage_History(t *testing.T) {
+	store := NewMemoryStorage(0)
+	require.NoError(t, store.Migrate(""))
+	require.NoError(t, store.Ping(context.Background()))
+
+	testHistory(t, store)
+	assert.NoError(t, store.Close())
+}
+
+func TestMemoryStorage_MaxRates(t *testing.T) {
+	store := NewMemoryStorage(2)
+	base := time.Now()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.SaveRate(context.Background(), float64(i), 0, 0, 0, base.Add(time.Duration(i)*time.Second)))
+	}
+
+	rates, err := store.GetRates(context.Background(), RateQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3, 4}, asksOf(rates))
+}
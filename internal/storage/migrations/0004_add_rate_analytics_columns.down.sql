@@ -0,0 +1,5 @@
+ALTER TABLE rates
+    DROP COLUMN IF EXISTS imbalance,
+    DROP COLUMN IF EXISTS spread_bps,
+    DROP COLUMN IF EXISTS spread,
+    DROP COLUMN IF EXISTS mid;
@@ -0,0 +1,11 @@
+-- Производные показатели курса вычисляются самой базой, формулы совпадают с models.Rate.Analytics
+ALTER TABLE rates
+    ADD COLUMN IF NOT EXISTS mid NUMERIC GENERATED ALWAYS AS ((ask + bid) / 2) STORED,
+    ADD COLUMN IF NOT EXISTS spread NUMERIC GENERATED ALWAYS AS (ask - bid) STORED,
+    ADD COLUMN IF NOT EXISTS spread_bps NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN ask + bid > 0 THEN (ask - bid) / ((ask + bid) / 2) * 10000 ELSE 0 END
+    ) STORED,
+    ADD COLUMN IF NOT EXISTS imbalance NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN ask_amount + bid_amount > 0
+            THEN (bid_amount - ask_amount) / (ask_amount + bid_amount) ELSE 0 END
+    ) STORED;
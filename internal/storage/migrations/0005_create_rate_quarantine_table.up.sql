@@ -0,0 +1,13 @@
+-- Курсы, отклоненные проверкой на аномалии; в rates они не попадают
+CREATE TABLE IF NOT EXISTS rate_quarantine (
+    id BIGSERIAL PRIMARY KEY,
+    ask DECIMAL(10, 2) NOT NULL,
+    bid DECIMAL(10, 2) NOT NULL,
+    ask_amount DECIMAL(15, 2) NOT NULL,
+    bid_amount DECIMAL(15, 2) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL,
+    quarantined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS rate_quarantine_timestamp_idx ON rate_quarantine (timestamp);
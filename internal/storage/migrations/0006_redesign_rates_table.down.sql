@@ -0,0 +1,66 @@
+-- Возврат к раскладке 0004; колонки symbol и source теряются, значения цен округляются до прежней точности
+ALTER TABLE rates
+    DROP CONSTRAINT IF EXISTS rates_symbol_source_timestamp_key,
+    DROP COLUMN IF EXISTS imbalance,
+    DROP COLUMN IF EXISTS spread_bps,
+    DROP COLUMN IF EXISTS spread,
+    DROP COLUMN IF EXISTS mid;
+DROP INDEX IF EXISTS rates_symbol_timestamp_idx;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'rates'::regclass) THEN
+        RETURN;
+    END IF;
+
+    CREATE TABLE rates_plain (
+        id BIGINT PRIMARY KEY DEFAULT nextval('rates_id_seq'),
+        ask NUMERIC(20, 8) NOT NULL,
+        bid NUMERIC(20, 8) NOT NULL,
+        ask_amount NUMERIC(28, 8) NOT NULL,
+        bid_amount NUMERIC(28, 8) NOT NULL,
+        timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+        symbol TEXT NOT NULL DEFAULT 'BTCUSDT',
+        source TEXT NOT NULL DEFAULT 'binance'
+    );
+    INSERT INTO rates_plain SELECT id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source FROM rates;
+
+    ALTER SEQUENCE rates_id_seq OWNED BY rates_plain.id;
+    -- Секции удаляются вместе с секционированной таблицей
+    DROP TABLE rates;
+    ALTER TABLE rates_plain RENAME TO rates;
+    ALTER INDEX rates_plain_pkey RENAME TO rates_pkey;
+END
+$$;
+
+ALTER TABLE rates
+    DROP COLUMN IF EXISTS symbol,
+    DROP COLUMN IF EXISTS source,
+    ALTER COLUMN id TYPE INTEGER,
+    ALTER COLUMN ask TYPE DECIMAL(10, 2),
+    ALTER COLUMN bid TYPE DECIMAL(10, 2),
+    ALTER COLUMN ask_amount TYPE DECIMAL(15, 2),
+    ALTER COLUMN bid_amount TYPE DECIMAL(15, 2),
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN timestamp SET DEFAULT CURRENT_TIMESTAMP;
+ALTER SEQUENCE rates_id_seq AS INTEGER;
+
+ALTER TABLE rates
+    ADD COLUMN IF NOT EXISTS mid NUMERIC GENERATED ALWAYS AS ((ask + bid) / 2) STORED,
+    ADD COLUMN IF NOT EXISTS spread NUMERIC GENERATED ALWAYS AS (ask - bid) STORED,
+    ADD COLUMN IF NOT EXISTS spread_bps NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN ask + bid > 0 THEN (ask - bid) / ((ask + bid) / 2) * 10000 ELSE 0 END
+    ) STORED,
+    ADD COLUMN IF NOT EXISTS imbalance NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN ask_amount + bid_amount > 0
+            THEN (bid_amount - ask_amount) / (ask_amount + bid_amount) ELSE 0 END
+    ) STORED;
+
+ALTER TABLE rate_quarantine
+    ALTER COLUMN ask TYPE DECIMAL(10, 2),
+    ALTER COLUMN bid TYPE DECIMAL(10, 2),
+    ALTER COLUMN ask_amount TYPE DECIMAL(15, 2),
+    ALTER COLUMN bid_amount TYPE DECIMAL(15, 2),
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN quarantined_at TYPE TIMESTAMP USING quarantined_at AT TIME ZONE 'UTC',
+    ALTER COLUMN quarantined_at SET DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,109 @@
+-- Новая раскладка таблицы rates: время с часовым поясом, точность до 8 знаков, пара и источник курса,
+-- индекс для выборок по периоду и уникальность для дедупликации повторно сохраненных курсов.
+-- При rates.partitioning = 'monthly' (см. DB_RATES_PARTITIONING) таблица секционируется по месяцам.
+
+-- Вычисляемые колонки из 0004 зависят от цен и объемов, поэтому пересоздаются вокруг смены типов
+ALTER TABLE rates
+    DROP COLUMN IF EXISTS imbalance,
+    DROP COLUMN IF EXISTS spread_bps,
+    DROP COLUMN IF EXISTS spread,
+    DROP COLUMN IF EXISTS mid;
+
+-- Курсы в TIMESTAMP без часового пояса считаются сохраненными по UTC. Если сервер БД или сервис работали
+-- в другом поясе, время старых курсов сместится на его смещение; такие курсы нужно поправить после миграции.
+-- До появления колонок symbol и source сервис отслеживал только BTCUSDT с Binance.
+DO $$
+BEGIN
+    RAISE NOTICE 'rates: existing TIMESTAMP values are interpreted as UTC';
+END
+$$;
+
+ALTER SEQUENCE rates_id_seq AS BIGINT;
+ALTER TABLE rates
+    ALTER COLUMN id TYPE BIGINT,
+    ALTER COLUMN ask TYPE NUMERIC(20, 8),
+    ALTER COLUMN bid TYPE NUMERIC(20, 8),
+    ALTER COLUMN ask_amount TYPE NUMERIC(28, 8),
+    ALTER COLUMN bid_amount TYPE NUMERIC(28, 8),
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN timestamp SET DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS symbol TEXT NOT NULL DEFAULT 'BTCUSDT',
+    ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'binance';
+
+-- Повторно сохраненные курсы (например, после воспроизведения журнала) удаляются до создания ограничения
+DELETE FROM rates AS duplicate
+    USING rates AS original
+    WHERE duplicate.symbol = original.symbol
+      AND duplicate.source = original.source
+      AND duplicate.timestamp = original.timestamp
+      AND duplicate.id > original.id;
+
+DO $$
+DECLARE
+    month_start TIMESTAMP;
+BEGIN
+    IF coalesce(current_setting('rates.partitioning', true), '') <> 'monthly' THEN
+        RETURN;
+    END IF;
+
+    -- Первичный ключ секционированной таблицы обязан включать ключ секционирования, поэтому id остается без него
+    CREATE TABLE rates_partitioned (
+        id BIGINT NOT NULL DEFAULT nextval('rates_id_seq'),
+        ask NUMERIC(20, 8) NOT NULL,
+        bid NUMERIC(20, 8) NOT NULL,
+        ask_amount NUMERIC(28, 8) NOT NULL,
+        bid_amount NUMERIC(28, 8) NOT NULL,
+        timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+        symbol TEXT NOT NULL DEFAULT 'BTCUSDT',
+        source TEXT NOT NULL DEFAULT 'binance'
+    ) PARTITION BY RANGE (timestamp);
+
+    -- Секции по месяцам UTC от первого курса до трех месяцев вперед (как PARTITIONS_AHEAD по умолчанию);
+    -- имя rates_pYYYYMM
+    FOR month_start IN
+        SELECT generate_series(
+            date_trunc('month', coalesce(min(timestamp), now()) AT TIME ZONE 'UTC'),
+            date_trunc('month', greatest(max(timestamp), now()) AT TIME ZONE 'UTC') + INTERVAL '3 months',
+            INTERVAL '1 month')
+        FROM rates
+    LOOP
+        EXECUTE format('CREATE TABLE %I PARTITION OF rates_partitioned FOR VALUES FROM (%L) TO (%L)',
+            'rates_p' || to_char(month_start, 'YYYYMM'),
+            month_start AT TIME ZONE 'UTC',
+            (month_start + INTERVAL '1 month') AT TIME ZONE 'UTC');
+    END LOOP;
+    -- Курсы вне созданных секций не теряются, но попадают в rates_default. Следующие месяцы создает
+    -- PartitionMaintainer (PARTITIONS_ENABLED); без него после последней секции все курсы пишутся в rates_default.
+    CREATE TABLE rates_default PARTITION OF rates_partitioned DEFAULT;
+
+    INSERT INTO rates_partitioned (id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source)
+        SELECT id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source FROM rates;
+
+    ALTER SEQUENCE rates_id_seq OWNED BY rates_partitioned.id;
+    DROP TABLE rates;
+    ALTER TABLE rates_partitioned RENAME TO rates;
+END
+$$;
+
+ALTER TABLE rates
+    ADD COLUMN IF NOT EXISTS mid NUMERIC GENERATED ALWAYS AS ((ask + bid) / 2) STORED,
+    ADD COLUMN IF NOT EXISTS spread NUMERIC GENERATED ALWAYS AS (ask - bid) STORED,
+    ADD COLUMN IF NOT EXISTS spread_bps NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN ask + bid > 0 THEN (ask - bid) / ((ask + bid) / 2) * 10000 ELSE 0 END
+    ) STORED,
+    ADD COLUMN IF NOT EXISTS imbalance NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN ask_amount + bid_amount > 0
+            THEN (bid_amount - ask_amount) / (ask_amount + bid_amount) ELSE 0 END
+    ) STORED,
+    ADD CONSTRAINT rates_symbol_source_timestamp_key UNIQUE (symbol, source, timestamp);
+
+CREATE INDEX IF NOT EXISTS rates_symbol_timestamp_idx ON rates (symbol, timestamp);
+
+ALTER TABLE rate_quarantine
+    ALTER COLUMN ask TYPE NUMERIC(20, 8),
+    ALTER COLUMN bid TYPE NUMERIC(20, 8),
+    ALTER COLUMN ask_amount TYPE NUMERIC(28, 8),
+    ALTER COLUMN bid_amount TYPE NUMERIC(28, 8),
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN quarantined_at TYPE TIMESTAMPTZ USING quarantined_at AT TIME ZONE 'UTC',
+    ALTER COLUMN quarantined_at SET DEFAULT now();
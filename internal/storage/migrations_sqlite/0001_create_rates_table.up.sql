@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ask REAL NOT NULL,
+    bid REAL NOT NULL,
+    ask_amount REAL NOT NULL,
+    bid_amount REAL NOT NULL,
+    timestamp INTEGER NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS rates_timestamp_idx ON rates (timestamp);
@@ -0,0 +1,4 @@
+ALTER TABLE rates DROP COLUMN imbalance;
+ALTER TABLE rates DROP COLUMN spread_bps;
+ALTER TABLE rates DROP COLUMN spread;
+ALTER TABLE rates DROP COLUMN mid;
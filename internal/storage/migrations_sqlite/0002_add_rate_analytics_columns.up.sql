@@ -0,0 +1,10 @@
+-- SQLite позволяет добавлять только виртуальные вычисляемые колонки
+ALTER TABLE rates ADD COLUMN mid REAL GENERATED ALWAYS AS ((ask + bid) / 2) VIRTUAL;
+ALTER TABLE rates ADD COLUMN spread REAL GENERATED ALWAYS AS (ask - bid) VIRTUAL;
+ALTER TABLE rates ADD COLUMN spread_bps REAL GENERATED ALWAYS AS (
+    CASE WHEN ask + bid > 0 THEN (ask - bid) / ((ask + bid) / 2) * 10000 ELSE 0 END
+) VIRTUAL;
+ALTER TABLE rates ADD COLUMN imbalance REAL GENERATED ALWAYS AS (
+    CASE WHEN ask_amount + bid_amount > 0
+        THEN (bid_amount - ask_amount) / (ask_amount + bid_amount) ELSE 0 END
+) VIRTUAL;
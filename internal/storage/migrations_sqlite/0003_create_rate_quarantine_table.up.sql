@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS rate_quarantine (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ask REAL NOT NULL,
+    bid REAL NOT NULL,
+    ask_amount REAL NOT NULL,
+    bid_amount REAL NOT NULL,
+    timestamp INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    quarantined_at INTEGER NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS rate_quarantine_timestamp_idx ON rate_quarantine (timestamp);
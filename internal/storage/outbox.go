@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gRPC-USDT/internal/models"
+)
+
+// OutboxEvent событие о сохраненном курсе, ожидающее публикации
+type OutboxEvent struct {
+	ID             int64
+	IdempotencyKey string // Одинаков для одного и того же курса, потребители по нему отбрасывают дубли
+	Topic          string
+	Payload        []byte // JSON RateEvent
+	CreatedAt      time.Time
+	Attempts       int
+}
+
+// RateEvent содержимое события о сохраненном курсе
+type RateEvent struct {
+	IdempotencyKey string    `json:"idempotency_key"`
+	Ask            float64   `json:"ask"`
+	Bid            float64   `json:"bid"`
+	AskAmount      float64   `json:"ask_amount"`
+	BidAmount      float64   `json:"bid_amount"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// execer общий интерфейс *sql.Tx и DatabaseConnector для выполнения запросов
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// EnableOutbox включает запись событий в таблицу rate_outbox в той же транзакции, что и курсы
+func (s *Storage) EnableOutbox(topic string) {
+	s.outboxTopic = topic
+}
+
+// OutboxKey возвращает ключ идемпотентности курса
+func OutboxKey(rate models.Rate) string {
+	sum := sha256.Sum256([]byte(rateKey(rate)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// withOutbox выполняет insert курсов; при включенном outbox - в транзакции вместе с событиями
+func (s *Storage) withOutbox(ctx context.Context, rates []models.Rate, insert func(exec execer) error) (err error) {
+	if s.outboxTopic == "" {
+		return insert(s.db)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := insert(tx); err != nil {
+		return err
+	}
+	if err := s.insertOutbox(ctx, tx, rates); err != nil {
+		return fmt.Errorf("insert outbox events: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Storage) insertOutbox(ctx context.Context, exec execer, rates []models.Rate) error {
+	var query strings.Builder
+	query.WriteString("INSERT INTO rate_outbox(idempotency_key, topic, payload) VALUES ")
+	args := make([]interface{}, 0, len(rates)*3)
+	for i, rate := range rates {
+		key := OutboxKey(rate)
+		payload, err := json.Marshal(RateEvent{
+			IdempotencyKey: key,
+			Ask:            rate.Ask,
+			Bid:            rate.Bid,
+			AskAmount:      rate.AskAmount,
+			BidAmount:      rate.BidAmount,
+			Timestamp:      rate.Time,
+		})
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * 3
+		fmt.Fprintf(&query, "($%d, $%d, $%d)", n+1, n+2, n+3)
+		args = append(args, key, s.outboxTopic, payload)
+	}
+	// Повторное сохранение того же курса (например, при воспроизведении журнала) не порождает новое событие
+	query.WriteString(" ON CONFLICT (idempotency_key) DO NOTHING")
+
+	_, err := exec.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// FetchOutbox возвращает до limit неопубликованных событий в порядке записи
+func (s *Storage) FetchOutbox(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	const query = `SELECT id, idempotency_key, topic, payload, created_at, attempts FROM rate_outbox
+                   ORDER BY id LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("fetch outbox failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		if err := rows.Scan(&e.ID, &e.IdempotencyKey, &e.Topic, &e.Payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, fmt.Errorf("fetch outbox failed: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch outbox failed: %w", err)
+	}
+	return events, nil
+}
+
+// AckOutbox удаляет опубликованные события
+func (s *Storage) AckOutbox(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := "DELETE FROM rate_outbox WHERE id IN (" + strings.Join(placeholders, ", ") + ")"
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("ack outbox failed: %w", err)
+	}
+	return nil
+}
+
+// FailOutbox отмечает неудачную попытку публикации события
+func (s *Storage) FailOutbox(ctx context.Context, id int64, cause error) error {
+	const query = `UPDATE rate_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+
+	if _, err := s.db.ExecContext(ctx, query, id, cause.Error()); err != nil {
+		return fmt.Errorf("fail outbox failed: %w", err)
+	}
+	return nil
+}
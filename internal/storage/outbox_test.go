@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"gRPC-USDT/internal/models"
+)
+
+func newOutboxStorage(t *testing.T) (*Storage, sqlmock.Sqlmock) {
+	db, mok, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	s := &Storage{db: &DefaultDatabaseConnector{db: db}}
+	s.SetSymbol(DefaultSymbol, DefaultSource)
+	s.EnableOutbox("usdt.rates")
+	return s, mok
+}
+
+func TestStorage_SaveRateWithOutbox(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	const (
+		rateQuery = `INSERT INTO rates(ask, bid, ask_amount, bid_amount, timestamp, symbol, source)
+                   VALUES($1, $2, $3, $4, $5, $6, $7)
+                   ON CONFLICT (symbol, source, timestamp) DO NOTHING`
+		outboxQuery = "INSERT INTO rate_outbox(idempotency_key, topic, payload) VALUES ($1, $2, $3) " +
+			"ON CONFLICT (idempotency_key) DO NOTHING"
+	)
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate := models.Rate{Ask: 1.1, Bid: 2.2, AskAmount: 3.3, BidAmount: 4.4, Time: ts}
+
+	t.Run("rate and event in one transaction", func(t *testing.T) {
+		s, mok := newOutboxStorage(t)
+
+		mok.ExpectBegin()
+		mok.ExpectExec(rateQuery).WithArgs(1.1, 2.2, 3.3, 4.4, ts, "BTCUSDT", "binance").WillReturnResult(sqlmock.NewResult(1, 1))
+		mok.ExpectExec(outboxQuery).
+			WithArgs(OutboxKey(rate), "usdt.rates", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mok.ExpectCommit()
+
+		require.NoError(t, s.SaveRate(context.Background(), 1.1, 2.2, 3.3, 4.4, ts))
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+
+	t.Run("outbox failure rolls back the rate", func(t *testing.T) {
+		s, mok := newOutboxStorage(t)
+
+		mok.ExpectBegin()
+		mok.ExpectExec(rateQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+		mok.ExpectExec(outboxQuery).WillReturnError(errors.New("outbox error"))
+		mok.ExpectRollback()
+
+		err := s.SaveRate(context.Background(), 1.1, 2.2, 3.3, 4.4, ts)
+		assert.ErrorContains(t, err, "insert outbox events")
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+
+	t.Run("batch writes one event per rate", func(t *testing.T) {
+		s, mok := newOutboxStorage(t)
+		second := models.Rate{Ask: 5.5, Bid: 6.6, AskAmount: 7.7, BidAmount: 8.8, Time: ts.Add(time.Second)}
+
+		mok.ExpectBegin()
+		mok.ExpectExec("INSERT INTO rates(ask, bid, ask_amount, bid_amount, timestamp, symbol, source) VALUES " +
+			"($1, $2, $3, $4, $5, $6, $7), ($8, $9, $10, $11, $12, $13, $14) " +
+			"ON CONFLICT (symbol, source, timestamp) DO NOTHING").WillReturnResult(sqlmock.NewResult(2, 2))
+		mok.ExpectExec("INSERT INTO rate_outbox(idempotency_key, topic, payload) VALUES ($1, $2, $3), ($4, $5, $6) "+
+			"ON CONFLICT (idempotency_key) DO NOTHING").
+			WithArgs(OutboxKey(rate), "usdt.rates", sqlmock.AnyArg(), OutboxKey(second), "usdt.rates", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 2))
+		mok.ExpectCommit()
+
+		require.NoError(t, s.SaveRates(context.Background(), []models.Rate{rate, second}))
+		assert.NoError(t, mok.ExpectationsWereMet())
+	})
+}
+
+func TestOutboxKey(t *testing.T) {
+	rate := testRate(1)
+	assert.Equal(t, OutboxKey(rate), OutboxKey(rate))
+	assert.Len(t, OutboxKey(rate), 32)
+
+	other := rate
+	other.Ask++
+	assert.NotEqual(t, OutboxKey(rate), OutboxKey(other))
+}
+
+func TestStorage_OutboxRelayQueries(t *testing.T) {
+	db, mok, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	s := &Storage{db: &DefaultDatabaseConnector{db: db}}
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mok.ExpectQuery("FROM rate_outbox").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "idempotency_key", "topic", "payload", "created_at", "attempts"}).
+			AddRow(int64(7), "key", "usdt.rates", []byte(`{"ask":1}`), created, 2))
+	mok.ExpectExec("DELETE FROM rate_outbox WHERE id IN").WithArgs(int64(7), int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mok.ExpectExec("UPDATE rate_outbox SET attempts").WithArgs(int64(9), "boom").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	events, err := s.FetchOutbox(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []OutboxEvent{{
+		ID: 7, IdempotencyKey: "key", Topic: "usdt.rates", Payload: []byte(`{"ask":1}`), CreatedAt: created, Attempts: 2,
+	}}, events)
+
+	require.NoError(t, s.AckOutbox(context.Background(), []int64{7, 8}))
+	require.NoError(t, s.AckOutbox(context.Background(), nil))
+	require.NoError(t, s.FailOutbox(context.Background(), 9, errors.New("boom")))
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
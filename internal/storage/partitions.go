@@ -0,0 +1,411 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gRPC-USDT/internal/export"
+	"gRPC-USDT/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Действия с секциями старше срока хранения
+const (
+	RetentionDetach = "detach" // Секция отсоединяется от rates и остается в базе отдельной таблицей
+	RetentionDrop   = "drop"   // Секция удаляется вместе с данными
+)
+
+// ErrNotPartitioned возвращается, если таблица rates не секционирована (см. DB_RATES_PARTITIONING)
+var ErrNotPartitioned = errors.New("rates table is not partitioned")
+
+// partitionName секция rates за месяц, как ее создает миграция 0006: rates_pYYYYMM
+var partitionName = regexp.MustCompile(`^rates_p(\d{6})$`)
+
+// defaultPartition секция по умолчанию, созданная миграцией 0006 для курсов вне месячных секций
+const defaultPartition = "rates_default"
+
+// PartitionConfig настройки обслуживания месячных секций таблицы rates
+type PartitionConfig struct {
+	Ahead           int           // Сколько следующих месяцев, кроме текущего, создавать заранее
+	RetentionMonths int           // Сколько полных месяцев до текущего хранить, 0 - хранить все
+	RetentionAction string        // RetentionDetach или RetentionDrop
+	ArchiveDir      string        // Непустой - перед отсоединением секция выгружается в этот каталог
+	ArchiveFormat   export.Format // Формат архива; CSV дополнительно сжимается gzip
+	DryRun          bool          // Только записать в лог запланированные действия
+}
+
+// PartitionAction действие над секцией: create, archive, detach или drop
+type PartitionAction struct {
+	Operation string
+	Partition string
+}
+
+// PartitionMaintainer создает секции rates заранее и архивирует, отсоединяет или удаляет устаревшие
+type PartitionMaintainer struct {
+	db     DatabaseConnector
+	cfg    PartitionConfig
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewPartitionMaintainer создает обслуживающий процесс для секций таблицы rates хранилища store
+func NewPartitionMaintainer(store *Storage, cfg PartitionConfig, logger *zap.Logger) *PartitionMaintainer {
+	if cfg.RetentionAction == "" {
+		cfg.RetentionAction = RetentionDetach
+	}
+	if cfg.ArchiveFormat == "" {
+		cfg.ArchiveFormat = export.FormatCSV
+	}
+	return &PartitionMaintainer{db: store.db, cfg: cfg, logger: logger, now: time.Now}
+}
+
+// Maintain выполняет одну проверку: создает недостающие секции и обрабатывает устаревшие.
+// Возвращает выполненные действия, в режиме dry-run - запланированные. Ошибка одной секции
+// не останавливает обработку остальных; без успешного архива секция не отсоединяется.
+func (m *PartitionMaintainer) Maintain(ctx context.Context) ([]PartitionAction, error) {
+	partitioned, err := m.partitioned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !partitioned {
+		return nil, ErrNotPartitioned
+	}
+
+	existing, hasDefault, err := m.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		actions []PartitionAction
+		errs    []error
+	)
+	run := func(operation, partition string, fn func() error) bool {
+		action := PartitionAction{Operation: operation, Partition: partition}
+		if m.cfg.DryRun {
+			m.logger.Info("Partition maintenance dry run", zap.String("operation", operation),
+				zap.String("partition", partition))
+			metrics.PartitionOperations.WithLabelValues(operation, "dry_run").Inc()
+			actions = append(actions, action)
+			return true
+		}
+		if err := fn(); err != nil {
+			metrics.PartitionOperations.WithLabelValues(operation, "error").Inc()
+			errs = append(errs, fmt.Errorf("%s %s: %w", operation, partition, err))
+			return false
+		}
+		m.logger.Info("Partition maintained", zap.String("operation", operation), zap.String("partition", partition))
+		metrics.PartitionOperations.WithLabelValues(operation, "ok").Inc()
+		actions = append(actions, action)
+		return true
+	}
+
+	current := monthStart(m.now())
+	for i := 0; i <= m.cfg.Ahead; i++ {
+		month := current.AddDate(0, i, 0)
+		name := monthPartition(month)
+		if _, ok := existing[name]; ok {
+			continue
+		}
+		if run("create", name, func() error { return m.create(ctx, name, month, hasDefault) }) && !m.cfg.DryRun {
+			existing[name] = month
+		}
+	}
+
+	if m.cfg.RetentionMonths > 0 {
+		cutoff := current.AddDate(0, -m.cfg.RetentionMonths, 0)
+		for _, name := range sortedPartitions(existing) {
+			if !existing[name].Before(cutoff) {
+				continue
+			}
+			if m.cfg.ArchiveDir != "" && !run("archive", name, func() error { return m.archive(ctx, name) }) {
+				continue
+			}
+
+			operation, query := "detach", "ALTER TABLE rates DETACH PARTITION "+name
+			if m.cfg.RetentionAction == RetentionDrop {
+				operation, query = "drop", "DROP TABLE "+name
+			}
+			if run(operation, name, func() error {
+				_, err := m.db.ExecContext(ctx, query)
+				return err
+			}) && !m.cfg.DryRun {
+				delete(existing, name)
+			}
+		}
+	}
+
+	metrics.PartitionCount.Set(float64(len(existing)))
+	if len(errs) > 0 {
+		return actions, errors.Join(errs...)
+	}
+	metrics.PartitionLastMaintenance.SetToCurrentTime()
+	return actions, nil
+}
+
+// Run обслуживает секции сразу и затем каждые interval до отмены ctx.
+// Если таблица rates не секционирована, завершается после первой проверки.
+func (m *PartitionMaintainer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := m.Maintain(ctx)
+		if errors.Is(err, ErrNotPartitioned) {
+			m.logger.Warn("Partition maintenance disabled, rates table is not partitioned")
+			return
+		}
+		if err != nil {
+			m.logger.Warn("Partition maintenance failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *PartitionMaintainer) partitioned(ctx context.Context) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass('rates'))`
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("check rates partitioning: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var partitioned bool
+	if rows.Next() {
+		if err := rows.Scan(&partitioned); err != nil {
+			return false, fmt.Errorf("check rates partitioning: %w", err)
+		}
+	}
+	return partitioned, rows.Err()
+}
+
+// partitions возвращает месячные секции rates и начало их месяца, а также признак наличия секции по умолчанию
+func (m *PartitionMaintainer) partitions(ctx context.Context) (map[string]time.Time, bool, error) {
+	const query = `SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+                   WHERE i.inhparent = 'rates'::regclass`
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, false, fmt.Errorf("list rates partitions: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	partitions := make(map[string]time.Time)
+	hasDefault := false
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, false, fmt.Errorf("list rates partitions: %w", err)
+		}
+		if name == defaultPartition {
+			hasDefault = true
+			continue
+		}
+		match := partitionName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		month, err := time.Parse("200601", match[1])
+		if err != nil {
+			continue
+		}
+		partitions[name] = month
+	}
+	return partitions, hasDefault, rows.Err()
+}
+
+// create создает секцию за месяц. Postgres не создает секцию, если в секции по умолчанию уже есть курсы
+// из ее диапазона, поэтому такие курсы переносятся: в одной транзакции секция по умолчанию отсоединяется,
+// создается новая секция, курсы месяца перемещаются в нее, и секция по умолчанию присоединяется обратно.
+func (m *PartitionMaintainer) create(ctx context.Context, name string, month time.Time, hasDefault bool) (err error) {
+	from, to := month, month.AddDate(0, 1, 0)
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF rates FOR VALUES FROM ('%s') TO ('%s')",
+		name, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if !hasDefault {
+		_, err := m.db.ExecContext(ctx, create)
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var pending bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+defaultPartition+
+		" WHERE timestamp >= $1 AND timestamp < $2)", from, to).Scan(&pending)
+	if err != nil {
+		return fmt.Errorf("check default partition: %w", err)
+	}
+	if !pending {
+		if _, err := tx.ExecContext(ctx, create); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	const columns = "id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source"
+	moved := int64(0)
+	for _, step := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{query: "ALTER TABLE rates DETACH PARTITION " + defaultPartition},
+		{query: create},
+		{query: "WITH moved AS (DELETE FROM " + defaultPartition + " WHERE timestamp >= $1 AND timestamp < $2 " +
+			"RETURNING " + columns + ") INSERT INTO rates (" + columns + ") SELECT " + columns + " FROM moved",
+			args: []interface{}{from, to}},
+		{query: "ALTER TABLE rates ATTACH PARTITION " + defaultPartition + " DEFAULT"},
+	} {
+		result, err := tx.ExecContext(ctx, step.query, step.args...)
+		if err != nil {
+			return err
+		}
+		if step.args != nil {
+			moved, _ = result.RowsAffected()
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	m.logger.Info("Rates moved from default partition", zap.String("partition", name), zap.Int64("rows", moved))
+	return nil
+}
+
+// archive выгружает секцию в файл <ArchiveDir>/<секция>.csv.gz или .parquet.
+// Файл сначала пишется во временный и переименовывается после успешной записи.
+func (m *PartitionMaintainer) archive(ctx context.Context, name string) (err error) {
+	path := filepath.Join(m.cfg.ArchiveDir, name+archiveExtension(m.cfg.ArchiveFormat))
+	if err := os.MkdirAll(m.cfg.ArchiveDir, 0o755); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+
+	file, err := os.CreateTemp(m.cfg.ArchiveDir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	var out io.Writer = file
+	var gz *gzip.Writer
+	if m.cfg.ArchiveFormat == export.FormatCSV {
+		gz = gzip.NewWriter(file)
+		out = gz
+	}
+	writer, err := export.NewWriter(out, m.cfg.ArchiveFormat)
+	if err != nil {
+		return err
+	}
+
+	count, err := m.copyPartition(ctx, name, writer)
+	if err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("write archive: %w", err)
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync archive: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("rename archive: %w", err)
+	}
+
+	metrics.PartitionArchivedRows.Add(float64(count))
+	m.logger.Info("Partition archived", zap.String("partition", name), zap.String("path", path),
+		zap.Int("rows", count))
+	return nil
+}
+
+// copyPartition построчно читает секцию, не загружая ее в память целиком
+func (m *PartitionMaintainer) copyPartition(ctx context.Context, name string, writer export.Writer) (int, error) {
+	query := "SELECT timestamp, symbol, source, ask, bid, ask_amount, bid_amount FROM " + name + " ORDER BY timestamp"
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("read partition: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	count := 0
+	for rows.Next() {
+		var row export.Row
+		if err := rows.Scan(&row.Timestamp, &row.Symbol, &row.Source,
+			&row.Ask, &row.Bid, &row.AskAmount, &row.BidAmount); err != nil {
+			return count, fmt.Errorf("read partition: %w", err)
+		}
+		if err := writer.Write(row); err != nil {
+			return count, fmt.Errorf("write archive: %w", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("read partition: %w", err)
+	}
+	return count, nil
+}
+
+func archiveExtension(format export.Format) string {
+	if format == export.FormatCSV {
+		return ".csv.gz"
+	}
+	return "." + string(format)
+}
+
+// monthStart возвращает начало месяца t по UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthPartition(month time.Time) string {
+	return "rates_p" + month.Format("200601")
+}
+
+func sortedPartitions(partitions map[string]time.Time) []string {
+	names := make([]string, 0, len(partitions))
+	for name := range partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gRPC-USDT/internal/export"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	partitionedQuery    = `SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass('rates'))`
+	listPartitionsQuery = `SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+                   WHERE i.inhparent = 'rates'::regclass`
+	createAprilQuery = "CREATE TABLE IF NOT EXISTS rates_p202404 PARTITION OF rates " +
+		"FOR VALUES FROM ('2024-04-01T00:00:00Z') TO ('2024-05-01T00:00:00Z')"
+	archiveDecemberQuery = "SELECT timestamp, symbol, source, ask, bid, ask_amount, bid_amount FROM rates_p202312 " +
+		"ORDER BY timestamp"
+	defaultAprilQuery = "SELECT EXISTS (SELECT 1 FROM rates_default WHERE timestamp >= $1 AND timestamp < $2)"
+)
+
+var (
+	april = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	may   = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newPartitionMaintainer(t *testing.T, cfg PartitionConfig) (*PartitionMaintainer, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mok, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	m := NewPartitionMaintainer(&Storage{db: &DefaultDatabaseConnector{db: db}}, cfg, zap.NewNop())
+	m.now = func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) }
+	return m, mok
+}
+
+func expectPartitions(mok sqlmock.Sqlmock) {
+	mok.ExpectQuery(partitionedQuery).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mok.ExpectQuery(listPartitionsQuery).WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+		AddRow("rates_p202312").AddRow("rates_p202401").AddRow("rates_p202403").AddRow("rates_default"))
+}
+
+func TestPartitionMaintainer_CreateArchiveDetach(t *testing.T) {
+	dir := t.TempDir()
+	m, mok := newPartitionMaintainer(t, PartitionConfig{Ahead: 1, RetentionMonths: 2, ArchiveDir: dir})
+
+	ts := time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC)
+	expectPartitions(mok)
+	mok.ExpectBegin()
+	mok.ExpectQuery(defaultAprilQuery).WithArgs(april, may).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mok.ExpectExec(createAprilQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mok.ExpectCommit()
+	mok.ExpectQuery(archiveDecemberQuery).WillReturnRows(sqlmock.NewRows(
+		[]string{"timestamp", "symbol", "source", "ask", "bid", "ask_amount", "bid_amount"}).
+		AddRow(ts, "BTCUSDT", "binance", 42000.5, 41999.5, 1.0, 2.0))
+	mok.ExpectExec("ALTER TABLE rates DETACH PARTITION rates_p202312").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	actions, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PartitionAction{
+		{Operation: "create", Partition: "rates_p202404"},
+		{Operation: "archive", Partition: "rates_p202312"},
+		{Operation: "detach", Partition: "rates_p202312"},
+	}, actions)
+	assert.NoError(t, mok.ExpectationsWereMet())
+
+	file, err := os.Open(filepath.Join(dir, "rates_p202312.csv.gz"))
+	require.NoError(t, err)
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "timestamp,symbol,source,ask,bid,ask_amount,bid_amount\n"+
+		"2023-12-31T23:59:00Z,BTCUSDT,binance,42000.5,41999.5,1,2\n", string(content))
+
+	// Временные файлы не остаются
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestPartitionMaintainer_CreateMovesDefaultRows(t *testing.T) {
+	m, mok := newPartitionMaintainer(t, PartitionConfig{Ahead: 1})
+
+	// Курсы апреля уже попали в rates_default: секция создается с их переносом
+	expectPartitions(mok)
+	mok.ExpectBegin()
+	mok.ExpectQuery(defaultAprilQuery).WithArgs(april, may).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mok.ExpectExec("ALTER TABLE rates DETACH PARTITION rates_default").WillReturnResult(sqlmock.NewResult(0, 0))
+	mok.ExpectExec(createAprilQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mok.ExpectExec("WITH moved AS (DELETE FROM rates_default WHERE timestamp >= $1 AND timestamp < $2 "+
+		"RETURNING id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source) "+
+		"INSERT INTO rates (id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source) "+
+		"SELECT id, ask, bid, ask_amount, bid_amount, timestamp, symbol, source FROM moved").
+		WithArgs(april, may).WillReturnResult(sqlmock.NewResult(0, 42))
+	mok.ExpectExec("ALTER TABLE rates ATTACH PARTITION rates_default DEFAULT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mok.ExpectCommit()
+
+	actions, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PartitionAction{{Operation: "create", Partition: "rates_p202404"}}, actions)
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
+
+func TestPartitionMaintainer_CreateMoveFailureRollsBack(t *testing.T) {
+	m, mok := newPartitionMaintainer(t, PartitionConfig{Ahead: 1})
+
+	expectPartitions(mok)
+	mok.ExpectBegin()
+	mok.ExpectQuery(defaultAprilQuery).WithArgs(april, may).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mok.ExpectExec("ALTER TABLE rates DETACH PARTITION rates_default").WillReturnError(errors.New("lock timeout"))
+	mok.ExpectRollback()
+
+	actions, err := m.Maintain(context.Background())
+	assert.ErrorContains(t, err, "create rates_p202404: lock timeout")
+	assert.Empty(t, actions)
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
+
+func TestPartitionMaintainer_DryRun(t *testing.T) {
+	m, mok := newPartitionMaintainer(t, PartitionConfig{
+		Ahead: 1, RetentionMonths: 2, RetentionAction: RetentionDrop, ArchiveDir: t.TempDir(), DryRun: true,
+	})
+
+	// В режиме dry-run выполняются только чтения каталога
+	expectPartitions(mok)
+
+	actions, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PartitionAction{
+		{Operation: "create", Partition: "rates_p202404"},
+		{Operation: "archive", Partition: "rates_p202312"},
+		{Operation: "drop", Partition: "rates_p202312"},
+	}, actions)
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
+
+func TestPartitionMaintainer_Drop(t *testing.T) {
+	m, mok := newPartitionMaintainer(t, PartitionConfig{RetentionMonths: 1, RetentionAction: RetentionDrop})
+
+	expectPartitions(mok)
+	mok.ExpectExec("DROP TABLE rates_p202312").WillReturnResult(sqlmock.NewResult(0, 0))
+	mok.ExpectExec("DROP TABLE rates_p202401").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	actions, err := m.Maintain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PartitionAction{
+		{Operation: "drop", Partition: "rates_p202312"},
+		{Operation: "drop", Partition: "rates_p202401"},
+	}, actions)
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
+
+func TestPartitionMaintainer_ArchiveFailureKeepsPartition(t *testing.T) {
+	dir := t.TempDir()
+	m, mok := newPartitionMaintainer(t, PartitionConfig{
+		RetentionMonths: 2, ArchiveDir: dir, ArchiveFormat: export.FormatParquet,
+	})
+
+	expectPartitions(mok)
+	mok.ExpectQuery(archiveDecemberQuery).WillReturnError(errors.New("connection reset"))
+
+	actions, err := m.Maintain(context.Background())
+	assert.ErrorContains(t, err, "archive rates_p202312: read partition: connection reset")
+	assert.Empty(t, actions)
+	assert.NoError(t, mok.ExpectationsWereMet())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestPartitionMaintainer_NotPartitioned(t *testing.T) {
+	m, mok := newPartitionMaintainer(t, PartitionConfig{Ahead: 3})
+
+	mok.ExpectQuery(partitionedQuery).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	_, err := m.Maintain(context.Background())
+	assert.ErrorIs(t, err, ErrNotPartitioned)
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
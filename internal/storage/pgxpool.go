@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gRPC-USDT/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// PoolConfig настройки пула соединений pgxpool
+type PoolConfig struct {
+	MaxConns               int32
+	MinConns               int32
+	MaxConnLifetime        time.Duration
+	MaxConnIdleTime        time.Duration
+	StatementCacheCapacity int
+	QueryExecMode          string
+}
+
+// queryExecModes соответствие названий режимов выполнения запросов константам pgx
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// PgxPoolConnector - реализация DatabaseConnector поверх нативного пула pgxpool.
+// *sql.DB строится из пула через stdlib, поэтому весь SQL Storage работает без изменений,
+// а нативный пул используется для проверки соединения и статистики пула.
+type PgxPoolConnector struct {
+	cfg  PoolConfig
+	pool *pgxpool.Pool
+	db   *sql.DB
+}
+
+// NewPgxPoolConnector создает коннектор с заданными настройками пула
+func NewPgxPoolConnector(cfg PoolConfig) *PgxPoolConnector {
+	return &PgxPoolConnector{cfg: cfg}
+}
+
+// Open создает пул соединений. driverName игнорируется: пул всегда использует pgx.
+func (p *PgxPoolConnector) Open(_, dataSourceName string) (*sql.DB, error) {
+	if p.db != nil {
+		return p.db, nil
+	}
+
+	poolConfig, err := p.poolConfig(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	p.pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create pool: %w", err)
+	}
+	p.db = stdlib.OpenDBFromPool(p.pool)
+	return p.db, nil
+}
+
+func (p *PgxPoolConnector) poolConfig(dataSourceName string) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("parse pool config: %w", err)
+	}
+
+	if p.cfg.MaxConns > 0 {
+		poolConfig.MaxConns = p.cfg.MaxConns
+	}
+	if p.cfg.MinConns > 0 {
+		poolConfig.MinConns = p.cfg.MinConns
+	}
+	if poolConfig.MinConns > poolConfig.MaxConns {
+		return nil, fmt.Errorf("min connections (%d) exceed max connections (%d)", poolConfig.MinConns, poolConfig.MaxConns)
+	}
+	if p.cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = p.cfg.MaxConnLifetime
+	}
+	if p.cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = p.cfg.MaxConnIdleTime
+	}
+
+	if p.cfg.QueryExecMode != "" {
+		mode, ok := queryExecModes[p.cfg.QueryExecMode]
+		if !ok {
+			return nil, fmt.Errorf("unknown query exec mode %q", p.cfg.QueryExecMode)
+		}
+		poolConfig.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if p.cfg.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = p.cfg.StatementCacheCapacity
+	}
+
+	return poolConfig, nil
+}
+
+func (p *PgxPoolConnector) Ping() error {
+	return p.PingContext(context.Background())
+}
+
+// PingContext проверяет соединение, прерываясь при отмене ctx
+func (p *PgxPoolConnector) PingContext(ctx context.Context) error {
+	if p.pool == nil {
+		return errors.New("database not initialized")
+	}
+	return p.pool.Ping(ctx)
+}
+
+func (p *PgxPoolConnector) Close() error {
+	if p.pool == nil {
+		return nil
+	}
+	err := p.db.Close()
+	p.pool.Close()
+	return err
+}
+
+func (p *PgxPoolConnector) ExecContext(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) (sql.Result, error) {
+	if p.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return p.db.ExecContext(ctx, query, args...)
+}
+
+func (p *PgxPoolConnector) QueryContext(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) (*sql.Rows, error) {
+	if p.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return p.db.QueryContext(ctx, query, args...)
+}
+
+func (p *PgxPoolConnector) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if p.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return p.db.BeginTx(ctx, opts)
+}
+
+// Stats возвращает снимок статистики пула для метрик
+func (p *PgxPoolConnector) Stats() metrics.DBPoolStats {
+	if p.pool == nil {
+		return metrics.DBPoolStats{}
+	}
+
+	stat := p.pool.Stat()
+	return metrics.DBPoolStats{
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		TotalConns:           stat.TotalConns(),
+		MaxConns:             stat.MaxConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gRPC-USDT/internal/models"
+)
+
+// QuarantinedRate курс, не прошедший проверку на аномалии
+type QuarantinedRate struct {
+	Rate          models.Rate
+	Reason        string
+	QuarantinedAt time.Time
+}
+
+// QuarantineRate сохраняет отклоненный курс в таблицу rate_quarantine вместо rates
+func (s *Storage) QuarantineRate(ctx context.Context, rate models.Rate, reason string) error {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	const query = `INSERT INTO rate_quarantine(ask, bid, ask_amount, bid_amount, timestamp, reason)
+                   VALUES($1, $2, $3, $4, $5, $6)`
+
+	if _, err := s.db.ExecContext(ctx, query,
+		rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time, reason); err != nil {
+		return fmt.Errorf("quarantine rate failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) QuarantineRate(ctx context.Context, rate models.Rate, reason string) error {
+	const query = `INSERT INTO rate_quarantine(ask, bid, ask_amount, bid_amount, timestamp, reason, quarantined_at)
+                   VALUES(?, ?, ?, ?, ?, ?, ?)`
+
+	if _, err := s.db.ExecContext(ctx, query,
+		rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, sqliteDialect.timestamp(rate.Time), reason,
+		sqliteDialect.timestamp(time.Now())); err != nil {
+		return fmt.Errorf("quarantine rate failed: %w", err)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) QuarantineRate(ctx context.Context, rate models.Rate, reason string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.quarantine = append(m.quarantine, QuarantinedRate{Rate: rate, Reason: reason, QuarantinedAt: time.Now()})
+	if m.maxRates > 0 && len(m.quarantine) > m.maxRates {
+		m.quarantine = append(m.quarantine[:0:0], m.quarantine[len(m.quarantine)-m.maxRates:]...)
+	}
+	return nil
+}
+
+// QuarantinedRates возвращает отклоненные курсы в порядке поступления
+func (m *MemoryStorage) QuarantinedRates() []QuarantinedRate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]QuarantinedRate(nil), m.quarantine...)
+}
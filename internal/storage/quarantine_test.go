@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gRPC-USDT/internal/models"
+)
+
+func TestStorage_QuarantineRate(t *testing.T) {
+	db, mok, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	s := &Storage{db: &DefaultDatabaseConnector{db: db}}
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate := models.Rate{Ask: 99, Bid: 100, AskAmount: 1, BidAmount: 2, Time: ts}
+
+	mok.ExpectExec("INSERT INTO rate_quarantine").
+		WithArgs(99.0, 100.0, 1.0, 2.0, ts, "crossed_book").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	require.NoError(t, s.QuarantineRate(context.Background(), rate, "crossed_book"))
+
+	mok.ExpectExec("INSERT INTO rate_quarantine").WillReturnError(errors.New("db down"))
+	assert.ErrorContains(t, s.QuarantineRate(context.Background(), rate, "crossed_book"), "quarantine rate failed")
+
+	assert.NoError(t, mok.ExpectationsWereMet())
+}
+
+func TestSQLiteStorage_QuarantineRate(t *testing.T) {
+	store, err := NewSQLiteStorage(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	require.NoError(t, store.Migrate(""))
+
+	ctx := context.Background()
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.QuarantineRate(ctx, models.Rate{Ask: 0, Bid: 100, Time: ts}, "non_positive"))
+
+	// Карантин не попадает в историю курсов
+	rates, err := store.GetRates(ctx, RateQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, rates)
+
+	var reason string
+	require.NoError(t, store.db.QueryRowContext(ctx, "SELECT reason FROM rate_quarantine").Scan(&reason))
+	assert.Equal(t, "non_positive", reason)
+}
+
+func TestMemoryStorage_QuarantineRate(t *testing.T) {
+	store := NewMemoryStorage(2)
+	ctx := context.Background()
+	for i, reason := range []string{"jump", "zscore", "crossed_book"} {
+		require.NoError(t, store.QuarantineRate(ctx, models.Rate{Ask: float64(i)}, reason))
+	}
+
+	// Хранится не больше maxRates последних отклоненных курсов
+	quarantined := store.QuarantinedRates()
+	require.Len(t, quarantined, 2)
+	assert.Equal(t, "zscore", quarantined[0].Reason)
+	assert.Equal(t, "crossed_book", quarantined[1].Reason)
+	assert.Empty(t, store.rates)
+}
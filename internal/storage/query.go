@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gRPC-USDT/internal/models"
+)
+
+// dialect описывает различия SQL-бэкендов при построении запросов
+type dialect struct {
+	placeholder func(n int) string            // Параметр запроса с порядковым номером n (с единицы)
+	timestamp   func(t time.Time) interface{} // Представление времени в запросе
+	symbols     bool                          // В таблице rates есть колонки symbol и source
+}
+
+var postgresDialect = dialect{
+	placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	timestamp:   func(t time.Time) interface{} { return t },
+	symbols:     true,
+}
+
+// В SQLite время хранится в наносекундах Unix, чтобы сравнение было числовым
+var sqliteDialect = dialect{
+	placeholder: func(int) string { return "?" },
+	timestamp:   func(t time.Time) interface{} { return t.UTC().UnixNano() },
+}
+
+// buildRatesQuery строит выборку истории курсов по фильтру
+func buildRatesQuery(q RateQuery, d dialect) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if d.symbols && q.Symbol != "" {
+		args = append(args, q.Symbol)
+		conditions = append(conditions, "symbol = "+d.placeholder(len(args)))
+	}
+	if d.symbols && q.Source != "" {
+		args = append(args, q.Source)
+		conditions = append(conditions, "source = "+d.placeholder(len(args)))
+	}
+	if !q.From.IsZero() {
+		args = append(args, d.timestamp(q.From))
+		conditions = append(conditions, "timestamp >= "+d.placeholder(len(args)))
+	}
+	if !q.To.IsZero() {
+		args = append(args, d.timestamp(q.To))
+		conditions = append(conditions, "timestamp < "+d.placeholder(len(args)))
+	}
+
+	// spread_bps и imbalance - вычисляемые колонки таблицы rates
+	for _, f := range []struct {
+		cond  string
+		value *float64
+	}{
+		{"spread_bps >= ", q.MinSpreadBps},
+		{"spread_bps <= ", q.MaxSpreadBps},
+		{"imbalance >= ", q.MinImbalance},
+		{"imbalance <= ", q.MaxImbalance},
+	} {
+		if f.value != nil {
+			args = append(args, *f.value)
+			conditions = append(conditions, f.cond+d.placeholder(len(args)))
+		}
+	}
+
+	var query strings.Builder
+	query.WriteString("SELECT ask, bid, ask_amount, bid_amount, timestamp FROM rates")
+	if len(conditions) > 0 {
+		query.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+	query.WriteString(" ORDER BY timestamp")
+	if q.Descending {
+		query.WriteString(" DESC")
+	}
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		query.WriteString(" LIMIT " + d.placeholder(len(args)))
+	}
+
+	return query.String(), args
+}
+
+// buildNearestRateQuery выбирает ближайший курс пары symbol из источника source не позже
+// и ближайший курс позже момента at. Подзапросы нужны SQLite, который не допускает ORDER BY и LIMIT в частях UNION.
+func buildNearestRateQuery(at time.Time, symbol, source string, d dialect) (string, []interface{}) {
+	const columns = "ask, bid, ask_amount, bid_amount, timestamp"
+	args := []interface{}{d.timestamp(at), d.timestamp(at)}
+	filter := ""
+	if d.symbols && symbol != "" {
+		args = append(args, symbol)
+		filter += "symbol = " + d.placeholder(len(args)) + " AND "
+	}
+	if d.symbols && source != "" {
+		args = append(args, source)
+		filter += "source = " + d.placeholder(len(args)) + " AND "
+	}
+	query := "SELECT " + columns + " FROM (SELECT " + columns + " FROM rates WHERE " + filter +
+		"timestamp <= " + d.placeholder(1) + " ORDER BY timestamp DESC LIMIT 1) AS before_rate UNION ALL " +
+		"SELECT " + columns + " FROM (SELECT " + columns + " FROM rates WHERE " + filter +
+		"timestamp > " + d.placeholder(2) + " ORDER BY timestamp LIMIT 1) AS after_rate"
+	return query, args
+}
+
+// nearestRate выбирает курс, ближайший по времени к at; при равенстве - более ранний
+func nearestRate(rates []models.Rate, at time.Time) (models.Rate, error) {
+	if len(rates) == 0 {
+		return models.Rate{}, ErrNotFound
+	}
+	best := rates[0]
+	for _, rate := range rates[1:] {
+		if absDuration(rate.Time.Sub(at)) < absDuration(best.Time.Sub(at)) {
+			best = rate
+		}
+	}
+	return best, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gRPC-USDT/internal/metrics"
+)
+
+// RotatingConnector - DatabaseConnector, пул которого можно пересоздать с новым DSN без остановки сервиса.
+// Новые запросы сразу идут в новый пул, старый закрывается после завершения начатых в нем запросов.
+type RotatingConnector struct {
+	factory func() DatabaseConnector
+	driver  string
+
+	mu      sync.RWMutex
+	current *rotatingPool
+}
+
+// rotatingPool считает вызовы, которые получили пул и еще не вернули управление,
+// чтобы пул не закрылся между выбором пула и началом запроса
+type rotatingPool struct {
+	DatabaseConnector
+	inflight sync.WaitGroup
+}
+
+// contextPinger реализуют пулы, проверка которых учитывает отмену контекста
+type contextPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// NewRotatingConnector создает коннектор; factory создает пул для каждого нового DSN
+func NewRotatingConnector(factory func() DatabaseConnector) *RotatingConnector {
+	return &RotatingConnector{factory: factory}
+}
+
+// Open создает первый пул. Повторный вызов возвращает текущий пул.
+func (r *RotatingConnector) Open(driverName, dataSourceName string) (*sql.DB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current == nil {
+		r.current = &rotatingPool{DatabaseConnector: r.factory()}
+		r.driver = driverName
+	}
+	return r.current.Open(driverName, dataSourceName)
+}
+
+// Rotate открывает пул с новым DSN и, если он отвечает на ping, заменяет им текущий.
+// Старый пул закрывается, когда завершатся уже начатые в нем вызовы. При ошибке текущий пул продолжает работать.
+func (r *RotatingConnector) Rotate(ctx context.Context, dataSourceName string) error {
+	r.mu.RLock()
+	driver := r.driver
+	r.mu.RUnlock()
+
+	next := r.factory()
+	if _, err := next.Open(driver, dataSourceName); err != nil {
+		_ = next.Close()
+		metrics.DBPoolRebuilds.WithLabelValues("error").Inc()
+		return fmt.Errorf("open new pool: %w", err)
+	}
+	if err := pingContext(ctx, next); err != nil {
+		_ = next.Close()
+		metrics.DBPoolRebuilds.WithLabelValues("error").Inc()
+		return fmt.Errorf("ping new pool: %w", err)
+	}
+
+	r.mu.Lock()
+	previous := r.current
+	r.current = &rotatingPool{DatabaseConnector: next}
+	r.mu.Unlock()
+
+	metrics.DBPoolRebuilds.WithLabelValues("ok").Inc()
+	if previous == nil {
+		return nil
+	}
+	// После замены новые вызовы старый пул не получают, ждем только начатые
+	previous.inflight.Wait()
+	return previous.Close()
+}
+
+func pingContext(ctx context.Context, c DatabaseConnector) error {
+	if p, ok := c.(contextPinger); ok {
+		return p.PingContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Ping()
+}
+
+// PingContext проверяет текущий пул, прерываясь при отмене ctx, если пул это поддерживает
+func (r *RotatingConnector) PingContext(ctx context.Context) error {
+	c, release := r.acquire()
+	defer release()
+	if c == nil {
+		return errors.New("database not initialized")
+	}
+	return pingContext(ctx, c)
+}
+
+// acquire возвращает текущий пул и отмечает вызов начатым; release нужно вызвать после завершения вызова.
+// Строки и транзакции, открытые до закрытия старого пула, дочитываются: пул закрывается после их освобождения.
+func (r *RotatingConnector) acquire() (DatabaseConnector, func()) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.current == nil {
+		return nil, func() {}
+	}
+	pool := r.current
+	pool.inflight.Add(1)
+	return pool.DatabaseConnector, pool.inflight.Done
+}
+
+func (r *RotatingConnector) Ping() error {
+	c, release := r.acquire()
+	defer release()
+	if c == nil {
+		return errors.New("database not initialized")
+	}
+	return c.Ping()
+}
+
+func (r *RotatingConnector) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
+
+func (r *RotatingConnector) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c, release := r.acquire()
+	defer release()
+	if c == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return c.ExecContext(ctx, query, args...)
+}
+
+func (r *RotatingConnector) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	c, release := r.acquire()
+	defer release()
+	if c == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return c.QueryContext(ctx, query, args...)
+}
+
+func (r *RotatingConnector) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	c, release := r.acquire()
+	defer release()
+	if c == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return c.BeginTx(ctx, opts)
+}
+
+// Stats возвращает статистику текущего пула, если он ее поддерживает
+func (r *RotatingConnector) Stats() metrics.DBPoolStats {
+	c, release := r.acquire()
+	defer release()
+	if c, ok := c.(interface{ Stats() metrics.DBPoolStats }); ok {
+		return c.Stats()
+	}
+	return metrics.DBPoolStats{}
+}
+
+// Reconnect пересоздает пул соединений с новым DSN, например после смены пароля.
+// Поддерживается только для коннектора RotatingConnector; при том же DSN ничего не делает.
+// ctx ограничивает проверку нового пула.
+func (s *Storage) Reconnect(ctx context.Context, dsn string) error {
+	rotating, ok := s.db.(*RotatingConnector)
+	if !ok {
+		return errors.New("database connector does not support reconnect")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if dsn == s.dsn {
+		return nil
+	}
+	if err := rotating.Rotate(ctx, dsn); err != nil {
+		return err
+	}
+	s.dsn = dsn
+	return nil
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubConnector отвечает ошибкой со своим DSN, чтобы было видно, какой пул выполнил запрос
+type stubConnector struct {
+	dsn     string
+	pingErr error
+	closed  bool
+	started chan struct{} // Если задан, ExecContext сообщает о начале и ждет release
+	release chan struct{}
+}
+
+func (c *stubConnector) Open(_, dsn string) (*sql.DB, error) {
+	c.dsn = dsn
+	return nil, nil
+}
+func (c *stubConnector) Ping() error  { return c.pingErr }
+func (c *stubConnector) Close() error { c.closed = true; return nil }
+func (c *stubConnector) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	if c.started != nil {
+		close(c.started)
+		<-c.release
+	}
+	return nil, errors.New(c.dsn)
+}
+func (c *stubConnector) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New(c.dsn)
+}
+func (c *stubConnector) BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error) {
+	return nil, errors.New(c.dsn)
+}
+
+func TestRotatingConnector(t *testing.T) {
+	var created []*stubConnector
+	var pingErr error
+	connector := NewRotatingConnector(func() DatabaseConnector {
+		c := &stubConnector{pingErr: pingErr}
+		created = append(created, c)
+		return c
+	})
+	ctx := context.Background()
+
+	assert.EqualError(t, connector.Ping(), "database not initialized")
+
+	_, err := connector.Open("pgx", "dsn-1")
+	require.NoError(t, err)
+	_, err = connector.ExecContext(ctx, "SELECT 1")
+	assert.EqualError(t, err, "dsn-1")
+
+	t.Run("failed rotation keeps current pool", func(t *testing.T) {
+		pingErr = errors.New("password authentication failed")
+		defer func() { pingErr = nil }()
+
+		assert.ErrorContains(t, connector.Rotate(ctx, "dsn-bad"), "ping new pool: password authentication failed")
+		assert.True(t, created[len(created)-1].closed)
+		_, err := connector.QueryContext(ctx, "SELECT 1")
+		assert.EqualError(t, err, "dsn-1")
+	})
+
+	t.Run("rotation swaps and closes old pool", func(t *testing.T) {
+		require.NoError(t, connector.Rotate(ctx, "dsn-2"))
+		assert.True(t, created[0].closed)
+		_, err := connector.BeginTx(ctx, nil)
+		assert.EqualError(t, err, "dsn-2")
+	})
+
+	t.Run("canceled rotation keeps current pool", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, connector.Rotate(canceled, "dsn-3"), context.Canceled)
+		_, err := connector.QueryContext(ctx, "SELECT 1")
+		assert.EqualError(t, err, "dsn-2")
+	})
+
+	assert.NoError(t, connector.Close())
+	assert.True(t, created[len(created)-1].closed)
+}
+
+func TestRotatingConnector_WaitsForInflightCalls(t *testing.T) {
+	first := &stubConnector{started: make(chan struct{}), release: make(chan struct{})}
+	pools := []*stubConnector{first, {}}
+	connector := NewRotatingConnector(func() DatabaseConnector {
+		c := pools[0]
+		pools = pools[1:]
+		return c
+	})
+	ctx := context.Background()
+	_, err := connector.Open("pgx", "dsn-1")
+	require.NoError(t, err)
+
+	execErr := make(chan error)
+	go func() {
+		_, err := connector.ExecContext(ctx, "SELECT 1")
+		execErr <- err
+	}()
+	<-first.started
+
+	rotated := make(chan error)
+	go func() { rotated <- connector.Rotate(ctx, "dsn-2") }()
+
+	// Пока начатый вызов не завершился, старый пул не закрывается
+	select {
+	case err := <-rotated:
+		t.Fatalf("rotation finished before in-flight call: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(first.release)
+	assert.EqualError(t, <-execErr, "dsn-1")
+	require.NoError(t, <-rotated)
+	assert.True(t, first.closed)
+
+	// Новые вызовы сразу идут в новый пул
+	_, err = connector.QueryContext(ctx, "SELECT 1")
+	assert.EqualError(t, err, "dsn-2")
+}
+
+func TestStorage_Reconnect(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unsupported connector", func(t *testing.T) {
+		s := &Storage{db: &DefaultDatabaseConnector{}, dsn: "dsn-1"}
+		assert.ErrorContains(t, s.Reconnect(ctx, "dsn-2"), "does not support reconnect")
+	})
+
+	t.Run("rotating connector", func(t *testing.T) {
+		opened := 0
+		connector := NewRotatingConnector(func() DatabaseConnector {
+			opened++
+			return &stubConnector{}
+		})
+		s, err := NewStorage("dsn-1", connector, &DefaultMigrateConnector{})
+		require.NoError(t, err)
+
+		// Тот же DSN не пересоздает пул
+		require.NoError(t, s.Reconnect(ctx, "dsn-1"))
+		assert.Equal(t, 1, opened)
+
+		require.NoError(t, s.Reconnect(ctx, "dsn-2"))
+		assert.Equal(t, 2, opened)
+		assert.Equal(t, "dsn-2", s.dsn)
+	})
+}
@@ -0,0 +1,575 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const (
+	spoolFileName   = "rates.spool"
+	replayFileName  = "rates.spool.replay"
+	offsetFileName  = "rates.spool.replay.offset"
+	rejectFileName  = "rates.spool.rejected"
+	replayChunkSize = 500
+)
+
+// ErrSpoolFull возвращается, если журнал достиг максимального размера
+var ErrSpoolFull = errors.New("spool is full")
+
+// spoolRecord - одна строка журнала
+type spoolRecord struct {
+	Key       string      `json:"key"`
+	SpooledAt time.Time   `json:"spooled_at"`
+	Rate      models.Rate `json:"rate"`
+}
+
+// rejectedRecord - курс, который база отклонила при воспроизведении
+type rejectedRecord struct {
+	Key        string      `json:"key"`
+	RejectedAt time.Time   `json:"rejected_at"`
+	Error      string      `json:"error"`
+	Rate       models.Rate `json:"rate"`
+}
+
+// spoolSegment статистика по файлу журнала
+type spoolSegment struct {
+	records int
+	bytes   int64
+	oldest  time.Time
+}
+
+// Spool - дисковый журнал курсов (append-only JSON Lines), в который пишутся курсы,
+// пока база данных недоступна. При воспроизведении активный файл переименовывается,
+// а прогресс сохраняется в файле смещения, поэтому после перезапуска воспроизведение продолжается
+// с места остановки.
+type Spool struct {
+	dir      string
+	maxBytes int64
+
+	mu     sync.Mutex
+	active *os.File
+	live   spoolSegment
+	replay spoolSegment
+
+	drainMu sync.Mutex
+}
+
+// OpenSpool открывает (или создает) журнал в каталоге dir. maxBytes <= 0 снимает ограничение размера.
+func OpenSpool(dir string, maxBytes int64) (*Spool, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("spool directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create spool directory: %w", err)
+	}
+
+	s := &Spool{dir: dir, maxBytes: maxBytes}
+
+	var err error
+	if s.live, err = scanSegment(s.path(spoolFileName), 0); err != nil {
+		return nil, err
+	}
+	offset, err := s.readOffset()
+	if err != nil {
+		return nil, err
+	}
+	if s.replay, err = scanSegment(s.path(replayFileName), offset); err != nil {
+		return nil, err
+	}
+
+	if s.active, err = s.openActive(); err != nil {
+		return nil, err
+	}
+
+	s.updateMetrics()
+	return s, nil
+}
+
+// Append дописывает курс в журнал и синхронизирует файл на диск
+func (s *Spool) Append(rate models.Rate) error {
+	line, err := json.Marshal(spoolRecord{Key: rateKey(rate), SpooledAt: time.Now(), Rate: rate})
+	if err != nil {
+		return fmt.Errorf("encode spool record: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBytes > 0 && s.live.bytes+s.replay.bytes+int64(len(line)) > s.maxBytes {
+		return ErrSpoolFull
+	}
+
+	if _, err := s.active.Write(line); err != nil {
+		return fmt.Errorf("write spool record: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("sync spool: %w", err)
+	}
+
+	if s.live.records == 0 {
+		s.live.oldest = time.Now()
+	}
+	s.live.records++
+	s.live.bytes += int64(len(line))
+
+	metrics.SpoolWrites.Inc()
+	s.updateMetricsLocked()
+	return nil
+}
+
+// Len возвращает количество курсов, ожидающих воспроизведения
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.live.records + s.replay.records
+}
+
+// Drain воспроизводит журнал в хранилище в порядке записи, пропуская дубликаты.
+// Возвращает количество сохраненных курсов.
+func (s *Spool) Drain(ctx context.Context, saver BatchSaver) (int, error) {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	if err := s.rotate(); err != nil {
+		return 0, err
+	}
+
+	file, err := os.Open(s.path(replayFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open spool replay: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	offset, err := s.readOffset()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		replayed int
+		pos      int64
+		seen     = make(map[string]bool)
+		chunk    = make([]models.Rate, 0, replayChunkSize)
+		last     spoolRecord
+		reader   = bufio.NewReader(file)
+	)
+
+	flush := func() error {
+		if len(chunk) > 0 {
+			saved, err := s.replayChunk(ctx, saver, chunk)
+			if err != nil {
+				return fmt.Errorf("replay spool: %w", err)
+			}
+			replayed += saved
+			metrics.SpoolReplayed.Add(float64(saved))
+		}
+		if err := s.writeOffset(pos); err != nil {
+			return err
+		}
+		s.markReplayed(len(chunk), pos, last.SpooledAt)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return replayed, fmt.Errorf("read spool: %w", readErr)
+		}
+		// Неполная последняя строка (обрыв записи) не воспроизводится
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			break
+		}
+		pos += int64(len(line))
+
+		var record spoolRecord
+		if err := json.Unmarshal(line, &record); err != nil || seen[record.Key] {
+			continue
+		}
+		seen[record.Key] = true
+
+		// Записи до сохраненного смещения уже в базе, учитываем их только для дедупликации
+		if pos <= offset {
+			continue
+		}
+
+		last = record
+		chunk = append(chunk, record.Rate)
+		if len(chunk) >= replayChunkSize {
+			if err := flush(); err != nil {
+				return replayed, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return replayed, err
+	}
+
+	if err := os.Remove(s.path(replayFileName)); err != nil {
+		return replayed, fmt.Errorf("remove spool replay: %w", err)
+	}
+	if err := os.Remove(s.path(offsetFileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return replayed, fmt.Errorf("remove spool offset: %w", err)
+	}
+
+	s.mu.Lock()
+	s.replay = spoolSegment{}
+	s.updateMetricsLocked()
+	s.mu.Unlock()
+
+	return replayed, nil
+}
+
+// replayChunk сохраняет пачку курсов и возвращает количество сохраненных. Если база отклонила пачку
+// не из-за недоступности, курсы сохраняются по одному, а отклоненные откладываются в rates.spool.rejected,
+// чтобы один некорректный курс не останавливал воспроизведение навсегда.
+func (s *Spool) replayChunk(ctx context.Context, saver BatchSaver, chunk []models.Rate) (int, error) {
+	err := saver.SaveRates(ctx, chunk)
+	if err == nil {
+		return len(chunk), nil
+	}
+	if IsUnavailable(err) || ctx.Err() != nil {
+		return 0, err
+	}
+
+	saved := 0
+	for _, rate := range chunk {
+		err := saver.SaveRates(ctx, []models.Rate{rate})
+		switch {
+		case err == nil:
+			saved++
+		case IsUnavailable(err) || ctx.Err() != nil:
+			// Уже сохраненные курсы пачки при повторе пропускаются как дубликаты
+			return saved, err
+		default:
+			if err := s.reject(rate, err); err != nil {
+				return saved, err
+			}
+		}
+	}
+	return saved, nil
+}
+
+// reject дописывает отклоненный базой курс в файл отклоненных записей
+func (s *Spool) reject(rate models.Rate, cause error) error {
+	line, err := json.Marshal(rejectedRecord{
+		Key:        rateKey(rate),
+		RejectedAt: time.Now().UTC(),
+		Error:      cause.Error(),
+		Rate:       rate,
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path(rejectFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open spool rejected: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write spool rejected: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write spool rejected: %w", err)
+	}
+	metrics.SpoolRejected.Inc()
+	return nil
+}
+
+// Close закрывает активный файл журнала
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	return err
+}
+
+// rotate переносит активный файл в воспроизведение, если предыдущее воспроизведение завершено
+func (s *Spool) rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.path(replayFileName)); err == nil || s.live.records == 0 {
+		return nil
+	}
+
+	if err := s.active.Close(); err != nil {
+		return fmt.Errorf("close spool: %w", err)
+	}
+	if err := os.Rename(s.path(spoolFileName), s.path(replayFileName)); err != nil {
+		return fmt.Errorf("rotate spool: %w", err)
+	}
+
+	var err error
+	if s.active, err = s.openActive(); err != nil {
+		return err
+	}
+	s.replay = s.live
+	s.live = spoolSegment{}
+	return nil
+}
+
+func (s *Spool) markReplayed(records int, pos int64, spooledAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replay.records -= records
+	if s.replay.records < 0 {
+		s.replay.records = 0
+	}
+	if info, err := os.Stat(s.path(replayFileName)); err == nil {
+		s.replay.bytes = info.Size() - pos
+	}
+	if !spooledAt.IsZero() {
+		s.replay.oldest = spooledAt
+	}
+	s.updateMetricsLocked()
+}
+
+func (s *Spool) updateMetrics() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateMetricsLocked()
+}
+
+func (s *Spool) updateMetricsLocked() {
+	metrics.SpoolRecords.Set(float64(s.live.records + s.replay.records))
+	metrics.SpoolBytes.Set(float64(s.live.bytes + s.replay.bytes))
+
+	var oldest time.Time
+	switch {
+	case s.replay.records > 0 && !s.replay.oldest.IsZero():
+		oldest = s.replay.oldest
+	case s.live.records > 0:
+		oldest = s.live.oldest
+	}
+	if oldest.IsZero() {
+		metrics.SpoolOldestAge.Set(0)
+		return
+	}
+	metrics.SpoolOldestAge.Set(time.Since(oldest).Seconds())
+}
+
+func (s *Spool) openActive() (*os.File, error) {
+	file, err := os.OpenFile(s.path(spoolFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open spool: %w", err)
+	}
+	return file, nil
+}
+
+func (s *Spool) readOffset() (int64, error) {
+	data, err := os.ReadFile(s.path(offsetFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read spool offset: %w", err)
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse spool offset: %w", err)
+	}
+	return offset, nil
+}
+
+func (s *Spool) writeOffset(offset int64) error {
+	tmp := s.path(offsetFileName + ".tmp")
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), 0o600); err != nil {
+		return fmt.Errorf("write spool offset: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(offsetFileName)); err != nil {
+		return fmt.Errorf("write spool offset: %w", err)
+	}
+	return nil
+}
+
+func (s *Spool) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// scanSegment подсчитывает записи файла журнала после смещения
+func scanSegment(path string, offset int64) (spoolSegment, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return spoolSegment{}, nil
+	}
+	if err != nil {
+		return spoolSegment{}, fmt.Errorf("open spool: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var (
+		segment spoolSegment
+		pos     int64
+		reader  = bufio.NewReader(file)
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			break
+		}
+		pos += int64(len(line))
+		if pos <= offset {
+			continue
+		}
+
+		var record spoolRecord
+		if json.Unmarshal(line, &record) != nil {
+			continue
+		}
+		if segment.records == 0 {
+			segment.oldest = record.SpooledAt
+		}
+		segment.records++
+		segment.bytes += int64(len(line))
+
+		if err != nil {
+			break
+		}
+	}
+	return segment, nil
+}
+
+// rateKey идентифицирует курс для дедупликации при воспроизведении
+func rateKey(rate models.Rate) string {
+	return fmt.Sprintf("%d:%g:%g:%g:%g", rate.Time.UnixNano(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount)
+}
+
+// RateWriter сохраняет курсы по одному и пачками
+type RateWriter interface {
+	SaveRate(ctx context.Context, ask, bid, askAmount, bidAmount float64, ts time.Time) error
+	BatchSaver
+}
+
+// SpoolingStorage пишет курсы в хранилище, а при недоступности базы данных - в локальный журнал
+type SpoolingStorage struct {
+	next   RateWriter
+	spool  *Spool
+	logger *zap.Logger
+}
+
+// NewSpoolingStorage создает хранилище с резервным журналом
+func NewSpoolingStorage(next RateWriter, spool *Spool, logger *zap.Logger) *SpoolingStorage {
+	return &SpoolingStorage{next: next, spool: spool, logger: logger}
+}
+
+func (s *SpoolingStorage) SaveRate(
+	ctx context.Context,
+	ask, bid, askAmount, bidAmount float64,
+	ts time.Time,
+) error {
+	err := s.next.SaveRate(ctx, ask, bid, askAmount, bidAmount, ts)
+	if !IsUnavailable(err) {
+		return err
+	}
+	return s.spoolRates(err, models.Rate{Ask: ask, Bid: bid, AskAmount: askAmount, BidAmount: bidAmount, Time: ts})
+}
+
+// SaveRates сохраняет пачку курсов, при недоступности базы данных пачка уходит в журнал
+func (s *SpoolingStorage) SaveRates(ctx context.Context, rates []models.Rate) error {
+	err := s.next.SaveRates(ctx, rates)
+	if !IsUnavailable(err) {
+		return err
+	}
+	return s.spoolRates(err, rates...)
+}
+
+func (s *SpoolingStorage) spoolRates(cause error, rates ...models.Rate) error {
+	for _, rate := range rates {
+		if err := s.spool.Append(rate); err != nil {
+			s.logger.Error("Error writing rate to spool", zap.Error(err), zap.NamedError("cause", cause))
+			return errors.Join(cause, err)
+		}
+	}
+	s.logger.Warn("Database unavailable, rates spooled", zap.Int("count", len(rates)), zap.Error(cause))
+	return nil
+}
+
+// Replay периодически воспроизводит журнал в базу данных до отмены контекста
+func (s *SpoolingStorage) Replay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.spool.updateMetrics()
+		if s.spool.Len() > 0 {
+			replayed, err := s.spool.Drain(ctx, s.next)
+			switch {
+			case err != nil:
+				s.logger.Warn("Spool replay interrupted", zap.Int("replayed", replayed), zap.Error(err))
+			case replayed > 0:
+				s.logger.Info("Spool replayed", zap.Int("replayed", replayed))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close закрывает журнал
+func (s *SpoolingStorage) Close() error {
+	return s.spool.Close()
+}
+
+// IsUnavailable сообщает, вызвана ли ошибка недоступностью базы данных (а не отказом в самом запросе).
+// Недоступностью считаются только сетевые ошибки и ошибки соединения; ошибки остановки сервиса,
+// отмены и таймаута запроса и прочие ошибки возвращаются вызывающему, а не пишутся в журнал.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Класс 08 - ошибки соединения, 57P01-57P03 - сервер останавливается или еще не принимает соединения
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// Соединение разорвано во время запроса
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF)
+}
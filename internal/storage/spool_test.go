@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gRPC-USDT/internal/models"
+)
+
+// errConnRefused сетевая ошибка недоступной базы данных
+var errConnRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+func testRate(i int) models.Rate {
+	return models.Rate{
+		Ask:       100 + float64(i),
+		Bid:       99 + float64(i),
+		AskAmount: 1,
+		BidAmount: 2,
+		Time:      time.Unix(1700000000+int64(i), 0).UTC(),
+	}
+}
+
+func asks(batches [][]models.Rate) []float64 {
+	var result []float64
+	for _, b := range batches {
+		for _, r := range b {
+			result = append(result, r.Ask)
+		}
+	}
+	return result
+}
+
+func TestSpool_AppendAndReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := OpenSpool(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(testRate(1)))
+	require.NoError(t, spool.Append(testRate(2)))
+	assert.Equal(t, 2, spool.Len())
+	require.NoError(t, spool.Close())
+
+	// После перезапуска записи не теряются
+	spool, err = OpenSpool(dir, 0)
+	require.NoError(t, err)
+	defer spool.Close()
+	assert.Equal(t, 2, spool.Len())
+}
+
+func TestSpool_EmptyDir(t *testing.T) {
+	_, err := OpenSpool(" ", 0)
+	assert.Error(t, err)
+}
+
+func TestSpool_Full(t *testing.T) {
+	spool, err := OpenSpool(t.TempDir(), 10)
+	require.NoError(t, err)
+	defer spool.Close()
+
+	assert.ErrorIs(t, spool.Append(testRate(1)), ErrSpoolFull)
+	assert.Equal(t, 0, spool.Len())
+}
+
+func TestSpool_DrainInOrderWithDedup(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := OpenSpool(dir, 0)
+	require.NoError(t, err)
+	defer spool.Close()
+
+	require.NoError(t, spool.Append(testRate(1)))
+	require.NoError(t, spool.Append(testRate(2)))
+	require.NoError(t, spool.Append(testRate(1))) // дубликат
+	require.NoError(t, spool.Append(testRate(3)))
+
+	saver := &fakeBatchSaver{}
+	replayed, err := spool.Drain(context.Background(), saver)
+	require.NoError(t, err)
+	assert.Equal(t, 3, replayed)
+	assert.Equal(t, []float64{101, 102, 103}, asks(saver.batches))
+	assert.Equal(t, 0, spool.Len())
+
+	_, err = os.Stat(filepath.Join(dir, replayFileName))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	// Новые записи после воспроизведения попадают в следующий цикл
+	require.NoError(t, spool.Append(testRate(4)))
+	replayed, err = spool.Drain(context.Background(), saver)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+}
+
+// failingSaver падает на заданном вызове
+type failingSaver struct {
+	fakeBatchSaver
+	failOn int
+	calls  int
+}
+
+func (f *failingSaver) SaveRates(ctx context.Context, rates []models.Rate) error {
+	f.calls++
+	if f.calls == f.failOn {
+		return errConnRefused
+	}
+	return f.fakeBatchSaver.SaveRates(ctx, rates)
+}
+
+func TestSpool_DrainResumesAfterFailure(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := OpenSpool(dir, 0)
+	require.NoError(t, err)
+
+	total := replayChunkSize + 100
+	for i := 0; i < total; i++ {
+		require.NoError(t, spool.Append(testRate(i)))
+	}
+
+	// Первая пачка сохраняется, вторая падает
+	saver := &failingSaver{failOn: 2}
+	replayed, err := spool.Drain(context.Background(), saver)
+	require.Error(t, err)
+	assert.Equal(t, replayChunkSize, replayed)
+	assert.Equal(t, 100, spool.Len())
+	require.NoError(t, spool.Close())
+
+	// После перезапуска воспроизводится только остаток
+	spool, err = OpenSpool(dir, 0)
+	require.NoError(t, err)
+	defer spool.Close()
+	assert.Equal(t, 100, spool.Len())
+
+	next := &fakeBatchSaver{}
+	replayed, err = spool.Drain(context.Background(), next)
+	require.NoError(t, err)
+	assert.Equal(t, 100, replayed)
+	assert.Equal(t, float64(100+replayChunkSize), asks(next.batches)[0])
+	assert.Equal(t, 0, spool.Len())
+}
+
+// rejectingSaver отклоняет пачки, содержащие курс с заданной ценой ask
+type rejectingSaver struct {
+	fakeBatchSaver
+	rejectAsk float64
+}
+
+func (r *rejectingSaver) SaveRates(ctx context.Context, rates []models.Rate) error {
+	for _, rate := range rates {
+		if rate.Ask == r.rejectAsk {
+			return &pgconn.PgError{Code: "22003", Message: "numeric field overflow"}
+		}
+	}
+	return r.fakeBatchSaver.SaveRates(ctx, rates)
+}
+
+func TestSpool_DrainSetsAsideRejectedRates(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := OpenSpool(dir, 0)
+	require.NoError(t, err)
+	defer spool.Close()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, spool.Append(testRate(i)))
+	}
+
+	saver := &rejectingSaver{rejectAsk: testRate(1).Ask}
+	replayed, err := spool.Drain(context.Background(), saver)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, []float64{testRate(0).Ask, testRate(2).Ask}, asks(saver.batches))
+	assert.Equal(t, 0, spool.Len())
+
+	file, err := os.Open(filepath.Join(dir, rejectFileName))
+	require.NoError(t, err)
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	require.True(t, scanner.Scan())
+	var record rejectedRecord
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+	assert.Equal(t, testRate(1), record.Rate)
+	assert.Contains(t, record.Error, "numeric field overflow")
+	assert.False(t, scanner.Scan())
+}
+
+func TestSpool_TruncatedRecordIgnored(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := OpenSpool(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(testRate(1)))
+	require.NoError(t, spool.Close())
+
+	// Имитируем обрыв записи
+	f, err := os.OpenFile(filepath.Join(dir, spoolFileName), os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"key":"partial`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	spool, err = OpenSpool(dir, 0)
+	require.NoError(t, err)
+	defer spool.Close()
+	assert.Equal(t, 1, spool.Len())
+
+	saver := &fakeBatchSaver{}
+	replayed, err := spool.Drain(context.Background(), saver)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+}
+
+// MockRateWriter - мок для RateWriter
+type MockRateWriter struct {
+	mock.Mock
+}
+
+func (m *MockRateWriter) SaveRate(ctx context.Context, ask, bid, askAmount, bidAmount float64, ts time.Time) error {
+	return m.Called(ctx, ask, bid, askAmount, bidAmount, ts).Error(0)
+}
+
+func (m *MockRateWriter) SaveRates(ctx context.Context, rates []models.Rate) error {
+	return m.Called(ctx, rates).Error(0)
+}
+
+func TestSpoolingStorage_SaveRate(t *testing.T) {
+	rate := testRate(1)
+
+	t.Run("database available", func(t *testing.T) {
+		spool, err := OpenSpool(t.TempDir(), 0)
+		require.NoError(t, err)
+		defer spool.Close()
+
+		next := &MockRateWriter{}
+		next.On("SaveRate", mock.Anything, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time).Return(nil)
+
+		s := NewSpoolingStorage(next, spool, zap.NewNop())
+		require.NoError(t, s.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		assert.Equal(t, 0, spool.Len())
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		spool, err := OpenSpool(t.TempDir(), 0)
+		require.NoError(t, err)
+		defer spool.Close()
+
+		next := &MockRateWriter{}
+		next.On("SaveRate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("save rate failed: %w", errConnRefused))
+
+		s := NewSpoolingStorage(next, spool, zap.NewNop())
+		require.NoError(t, s.SaveRate(context.Background(), rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time))
+		assert.Equal(t, 1, spool.Len())
+	})
+
+	t.Run("query error is not spooled", func(t *testing.T) {
+		spool, err := OpenSpool(t.TempDir(), 0)
+		require.NoError(t, err)
+		defer spool.Close()
+
+		next := &MockRateWriter{}
+		next.On("SaveRates", mock.Anything, mock.Anything).Return(&pgconn.PgError{Code: "22003"})
+
+		s := NewSpoolingStorage(next, spool, zap.NewNop())
+		assert.Error(t, s.SaveRates(context.Background(), []models.Rate{rate}))
+		assert.Equal(t, 0, spool.Len())
+	})
+}
+
+func TestSpoolingStorage_Replay(t *testing.T) {
+	spool, err := OpenSpool(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(testRate(1)))
+
+	next := &MockRateWriter{}
+	next.On("SaveRates", mock.Anything, []models.Rate{testRate(1)}).Return(nil)
+
+	s := NewSpoolingStorage(next, spool, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Replay(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return spool.Len() == 0 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	require.NoError(t, s.Close())
+	next.AssertExpectations(t)
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "network error", err: fmt.Errorf("save rates failed: %w", errConnRefused), want: true},
+		{name: "connect error", err: &pgconn.ConnectError{}, want: true},
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "deadline", err: context.DeadlineExceeded, want: false},
+		{name: "writer closed", err: ErrWriterClosed, want: false},
+		{name: "nil connection", err: errors.New("database connection is nil"), want: false},
+		{name: "database closed", err: errors.New("sql: database is closed"), want: false},
+		{name: "connection exception", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "cannot connect now", err: &pgconn.PgError{Code: "57P03"}, want: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUnavailable(tt.err))
+		})
+	}
+}
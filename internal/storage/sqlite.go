@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite" // Регистрирует драйвер "sqlite" без cgo
+)
+
+//go:embed migrations_sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// sqliteBusyTimeout время ожидания блокировки файла базы другим процессом
+const sqliteBusyTimeout = 5 * time.Second
+
+// SQLiteStorage хранит курсы во встроенной базе SQLite.
+// Время хранится в наносекундах Unix (UTC), чтобы выборки по периоду были числовыми.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage открывает (или создает) базу SQLite по пути path.
+// Путь ":memory:" создает базу в памяти процесса.
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("sqlite path cannot be empty")
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)",
+		path, sqliteBusyTimeout.Milliseconds())
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+
+	// SQLite допускает одного писателя, а база ":memory:" живет в пределах соединения
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite ping failed: %w", err)
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Migrate применяет миграции SQLite.
+// Пустой путь означает встроенные в бинарник миграции.
+func (s *SQLiteStorage) Migrate(migrationsPath string) error {
+	driver, err := migratesqlite.WithInstance(s.db, &migratesqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("migration init failed: %w", err)
+	}
+
+	var m *migrate.Migrate
+	if strings.TrimSpace(migrationsPath) == "" {
+		source, err := iofs.New(sqliteMigrations, "migrations_sqlite")
+		if err != nil {
+			return fmt.Errorf("migration init failed: %w", err)
+		}
+		m, err = migrate.NewWithInstance("iofs", source, "sqlite", driver)
+		if err != nil {
+			return fmt.Errorf("migration init failed: %w", err)
+		}
+	} else {
+		m, err = migrate.NewWithDatabaseInstance("file://"+migrationsPath, "sqlite", driver)
+		if err != nil {
+			return fmt.Errorf("migration init failed: %w", err)
+		}
+	}
+
+	// m.Close не вызывается: он закрыл бы общее соединение s.db
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration up failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStorage) SaveRate(
+	ctx context.Context,
+	ask, bid, askAmount, bidAmount float64,
+	ts time.Time,
+) error {
+	return s.SaveRates(ctx, []models.Rate{{
+		Ask:       ask,
+		Bid:       bid,
+		AskAmount: askAmount,
+		BidAmount: bidAmount,
+		Time:      ts,
+	}})
+}
+
+// SaveRates сохраняет пачку курсов одним многострочным INSERT
+func (s *SQLiteStorage) SaveRates(ctx context.Context, rates []models.Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO rates(ask, bid, ask_amount, bid_amount, timestamp) VALUES ")
+	args := make([]interface{}, 0, len(rates)*5)
+	for i, rate := range rates {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?)")
+		args = append(args, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, sqliteDialect.timestamp(rate.Time))
+	}
+
+	tr := otel.GetTracerProvider().Tracer("storage-sqlite")
+	ctx, span := tr.Start(ctx, "SaveRates",
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation", "INSERT"),
+			attribute.Int("db.rows", len(rates)),
+		))
+	defer span.End()
+
+	if _, err := s.db.ExecContext(ctx, query.String(), args...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "save rates failed")
+		return fmt.Errorf("save rates failed: %w", err)
+	}
+
+	metrics.DBSaves.Add(float64(len(rates)))
+	metrics.DBSaveLatency.Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+// GetRates возвращает курсы за период в порядке возрастания времени (убывания при q.Descending)
+func (s *SQLiteStorage) GetRates(ctx context.Context, q RateQuery) ([]models.Rate, error) {
+	query, args := buildRatesQuery(q, sqliteDialect)
+
+	rates, err := s.queryRates(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get rates failed: %w", err)
+	}
+	return rates, nil
+}
+
+// LatestRate возвращает последний сохраненный курс
+func (s *SQLiteStorage) LatestRate(ctx context.Context) (models.Rate, error) {
+	const query = `SELECT ask, bid, ask_amount, bid_amount, timestamp FROM rates
+                   ORDER BY timestamp DESC LIMIT 1`
+
+	rates, err := s.queryRates(ctx, query)
+	if err != nil {
+		return models.Rate{}, fmt.Errorf("get latest rate failed: %w", err)
+	}
+	if len(rates) == 0 {
+		return models.Rate{}, ErrNotFound
+	}
+	return rates[0], nil
+}
+
+// NearestRate возвращает курс, ближайший по времени к at
+func (s *SQLiteStorage) NearestRate(ctx context.Context, at time.Time) (models.Rate, error) {
+	query, args := buildNearestRateQuery(at, "", "", sqliteDialect)
+	rates, err := s.queryRates(ctx, query, args...)
+	if err != nil {
+		return models.Rate{}, fmt.Errorf("get nearest rate failed: %w", err)
+	}
+	return nearestRate(rates, at)
+}
+
+func (s *SQLiteStorage) queryRates(ctx context.Context, query string, args ...interface{}) ([]models.Rate, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var rates []models.Rate
+	for rows.Next() {
+		var (
+			rate models.Rate
+			ts   int64
+		)
+		if err := rows.Scan(&rate.Ask, &rate.Bid, &rate.AskAmount, &rate.BidAmount, &ts); err != nil {
+			return nil, err
+		}
+		rate.Time = time.Unix(0, ts).UTC()
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+// Ping проверяет доступность базы данных
+func (s *SQLiteStorage) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStorage_History(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "rates.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	require.NoError(t, store.Migrate(""))
+	// Повторный запуск миграций не должен падать
+	require.NoError(t, store.Migrate(""))
+	require.NoError(t, store.Ping(context.Background()))
+
+	testHistory(t, store)
+}
+
+func TestSQLiteStorage_InMemory(t *testing.T) {
+	store, err := NewSQLiteStorage(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	require.NoError(t, store.Migrate(""))
+	testHistory(t, store)
+}
+
+func TestSQLiteStorage_MigrationsDown(t *testing.T) {
+	store, err := NewSQLiteStorage(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	require.NoError(t, store.Migrate(""))
+
+	driver, err := migratesqlite.WithInstance(store.db, &migratesqlite.Config{})
+	require.NoError(t, err)
+	source, err := iofs.New(sqliteMigrations, "migrations_sqlite")
+	require.NoError(t, err)
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	require.NoError(t, err)
+
+	// Каждой миграции соответствует откат, после полного отката остается только служебная таблица
+	require.NoError(t, m.Down())
+	var tables int
+	require.NoError(t, store.db.QueryRow(
+		`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name IN ('rates', 'rate_quarantine')`,
+	).Scan(&tables))
+	assert.Zero(t, tables)
+
+	// Схема заново поднимается после отката
+	require.NoError(t, store.Migrate(""))
+	testHistory(t, store)
+}
+
+func TestSQLiteStorage_EmptyPath(t *testing.T) {
+	_, err := NewSQLiteStorage(" ")
+	assert.Error(t, err)
+}
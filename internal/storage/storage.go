@@ -3,15 +3,20 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"embed"
 	"errors"
 	"fmt"
 	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres" //Драйвер для миграций
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -26,18 +31,68 @@ type DatabaseConnector interface {
 	Ping() error
 	Close() error
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
 
 // MigrateConnector представляет абстракцию для работы с миграциями
 type MigrateConnector interface {
 	New(sourceURL, databaseURL string) (*migrate.Migrate, error)
+	NewWithSourceInstance(sourceName string, sourceInstance source.Driver, databaseURL string) (*migrate.Migrate, error)
 	Up() error
+	Steps(n int) error
+	Migrate(version uint) error
+	Version() (version uint, dirty bool, err error)
+	Force(version int) error
+}
+
+//go:embed migrations/*.sql
+var postgresMigrations embed.FS
+
+// ErrNotFound возвращается, если в хранилище нет подходящих курсов
+var ErrNotFound = errors.New("rate not found")
+
+// RateQuery фильтр выборки истории курсов
+type RateQuery struct {
+	Symbol string // Пара, пустая - пара хранилища; учитывается только хранилищем Postgres
+	Source string // Источник курсов, пустой - источник хранилища; учитывается только хранилищем Postgres
+
+	From  time.Time // Начало периода (включительно), нулевое значение - без ограничения
+	To    time.Time // Конец периода (не включительно), нулевое значение - без ограничения
+	Limit int       // Максимальное количество курсов, 0 - без ограничения
+	// Descending возвращает курсы в порядке убывания времени; вместе с Limit выбирает последние курсы периода
+	Descending bool
+
+	// Фильтры по производным показателям (см. models.Rate.Analytics), nil - без ограничения
+	MinSpreadBps *float64 // Минимальный спред в базисных пунктах (включительно)
+	MaxSpreadBps *float64 // Максимальный спред в базисных пунктах (включительно)
+	MinImbalance *float64 // Минимальный дисбаланс стакана (включительно)
+	MaxImbalance *float64 // Максимальный дисбаланс стакана (включительно)
+}
+
+// matchAnalytics проверяет курс по фильтрам производных показателей
+func (q RateQuery) matchAnalytics(rate models.Rate) bool {
+	a := rate.Analytics()
+	switch {
+	case q.MinSpreadBps != nil && a.SpreadBps < *q.MinSpreadBps,
+		q.MaxSpreadBps != nil && a.SpreadBps > *q.MaxSpreadBps,
+		q.MinImbalance != nil && a.Imbalance < *q.MinImbalance,
+		q.MaxImbalance != nil && a.Imbalance > *q.MaxImbalance:
+		return false
+	}
+	return true
 }
 
 // Interface определяет контракт для работы с хранилищем
 type Interface interface {
 	Migrate(migrationsPath string) error
 	SaveRate(ctx context.Context, ask, bid, askAmount, bidAmount float64, ts time.Time) error
+	SaveRates(ctx context.Context, rates []models.Rate) error
+	GetRates(ctx context.Context, q RateQuery) ([]models.Rate, error)
+	LatestRate(ctx context.Context) (models.Rate, error)
+	NearestRate(ctx context.Context, at time.Time) (models.Rate, error)
+	QuarantineRate(ctx context.Context, rate models.Rate, reason string) error
+	Ping(ctx context.Context) error
 	Close() error
 }
 
@@ -83,6 +138,24 @@ func (d *DefaultDatabaseConnector) ExecContext(
 	return d.db.ExecContext(ctx, query, args...)
 }
 
+func (d *DefaultDatabaseConnector) QueryContext(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) (*sql.Rows, error) {
+	if d.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return d.db.QueryContext(ctx, query, args...)
+}
+
+func (d *DefaultDatabaseConnector) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if d.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	return d.db.BeginTx(ctx, opts)
+}
+
 // DefaultMigrateConnector - реализация MigrateConnector по умолчанию
 type DefaultMigrateConnector struct {
 	m *migrate.Migrate
@@ -99,6 +172,21 @@ func (d *DefaultMigrateConnector) New(sourceURL, databaseURL string) (*migrate.M
 	return d.m, nil
 }
 
+func (d *DefaultMigrateConnector) NewWithSourceInstance(
+	sourceName string,
+	sourceInstance source.Driver,
+	databaseURL string,
+) (*migrate.Migrate, error) {
+	if d.m == nil {
+		var err error
+		d.m, err = migrate.NewWithSourceInstance(sourceName, sourceInstance, databaseURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.m, nil
+}
+
 func (d *DefaultMigrateConnector) Up() error {
 	if d.m == nil {
 		return errors.New("migrate not initialized")
@@ -106,11 +194,51 @@ func (d *DefaultMigrateConnector) Up() error {
 	return d.m.Up()
 }
 
+func (d *DefaultMigrateConnector) Steps(n int) error {
+	if d.m == nil {
+		return errors.New("migrate not initialized")
+	}
+	return d.m.Steps(n)
+}
+
+func (d *DefaultMigrateConnector) Migrate(version uint) error {
+	if d.m == nil {
+		return errors.New("migrate not initialized")
+	}
+	return d.m.Migrate(version)
+}
+
+func (d *DefaultMigrateConnector) Version() (uint, bool, error) {
+	if d.m == nil {
+		return 0, false, errors.New("migrate not initialized")
+	}
+	return d.m.Version()
+}
+
+func (d *DefaultMigrateConnector) Force(version int) error {
+	if d.m == nil {
+		return errors.New("migrate not initialized")
+	}
+	return d.m.Force(version)
+}
+
+// Пара и источник курсов по умолчанию; ими же миграция 0006 помечает курсы, сохраненные до ее появления
+const (
+	DefaultSymbol = "BTCUSDT"
+	DefaultSource = "binance"
+)
+
 // Storage реализует Interface
 type Storage struct {
 	db               DatabaseConnector
 	migrateConnector MigrateConnector
-	dsn              string
+	outboxTopic      string // Непустой - вместе с курсом в той же транзакции пишется событие
+	symbol           string // Пара сохраняемых и читаемых курсов
+	source           string // Источник сохраняемых курсов
+	partitioning     bool   // Миграция 0006 секционирует таблицу rates по месяцам
+
+	mu  sync.Mutex // Защищает dsn при пересоздании пула
+	dsn string
 }
 
 // NewStorage создает новое соединение с базой данных
@@ -128,30 +256,123 @@ func NewStorage(dsn string, dbConnector DatabaseConnector, migrateConnector Migr
 	return &Storage{
 		db:               dbConnector,
 		migrateConnector: migrateConnector,
+		symbol:           DefaultSymbol,
+		source:           DefaultSource,
 		dsn:              dsn,
 	}, nil
 }
 
+// SetSymbol задает пару и источник курсов. Курсы других пар в той же таблице не читаются.
+func (s *Storage) SetSymbol(symbol, source string) {
+	s.symbol = symbol
+	s.source = source
+}
+
+// SetRatesPartitioning включает секционирование таблицы rates по месяцам при применении миграции 0006.
+// На уже примененную миграцию не влияет.
+func (s *Storage) SetRatesPartitioning(enabled bool) {
+	s.partitioning = enabled
+}
+
 func (s *Storage) Migrate(migrationsPath string) error {
+	if err := s.initMigrate(migrationsPath); err != nil {
+		return err
+	}
 
-	if strings.TrimSpace(migrationsPath) == "" {
-		return errors.New("migrations path cannot be empty")
+	if err := s.migrateConnector.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration up failed: %w", err)
 	}
 
-	if !strings.HasPrefix(migrationsPath, "/") {
-		migrationsPath = "/" + migrationsPath
+	return nil
+}
+
+// MigrateSteps применяет n следующих миграций или, при отрицательном n, откатывает -n последних
+func (s *Storage) MigrateSteps(migrationsPath string, n int) error {
+	if n == 0 {
+		return errors.New("migration steps cannot be zero")
+	}
+	if err := s.initMigrate(migrationsPath); err != nil {
+		return err
 	}
 
-	migrationDSN := strings.Split(s.dsn, "?")[0]
-	migrationDSN += "?sslmode=disable&x-migrations-table=schema_migrations"
+	if err := s.migrateConnector.Steps(n); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration steps failed: %w", err)
+	}
 
-	_, err := s.migrateConnector.New("file://"+migrationsPath, migrationDSN)
+	return nil
+}
+
+// MigrateTo применяет или откатывает миграции до версии version
+func (s *Storage) MigrateTo(migrationsPath string, version uint) error {
+	if err := s.initMigrate(migrationsPath); err != nil {
+		return err
+	}
+
+	if err := s.migrateConnector.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration to version %d failed: %w", version, err)
+	}
+
+	return nil
+}
+
+// MigrationVersion возвращает текущую версию схемы; dirty означает, что последняя миграция прервалась.
+// Если миграции еще не применялись, возвращается версия 0.
+func (s *Storage) MigrationVersion(migrationsPath string) (version uint, dirty bool, err error) {
+	if err := s.initMigrate(migrationsPath); err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err = s.migrateConnector.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migration version failed: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// ForceMigration записывает версию схемы без выполнения миграций и снимает признак dirty.
+// Используется для восстановления после прерванной миграции, когда схема исправлена вручную.
+func (s *Storage) ForceMigration(migrationsPath string, version int) error {
+	if err := s.initMigrate(migrationsPath); err != nil {
+		return err
+	}
+
+	if err := s.migrateConnector.Force(version); err != nil {
+		return fmt.Errorf("migration force failed: %w", err)
+	}
+
+	return nil
+}
+
+// initMigrate подготавливает миграции; пустой путь означает встроенные в бинарник миграции
+func (s *Storage) initMigrate(migrationsPath string) error {
+	// Миграции подключаются с теми же параметрами TLS и сессии, что и пул
+	s.mu.Lock()
+	dsn, err := migrationDSN(s.dsn, s.partitioning)
+	s.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("migration init failed: %w", err)
 	}
 
-	if err := s.migrateConnector.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("migration up failed: %w", err)
+	if strings.TrimSpace(migrationsPath) == "" {
+		embedded, err := iofs.New(postgresMigrations, "migrations")
+		if err != nil {
+			return fmt.Errorf("migration init failed: %w", err)
+		}
+		if _, err := s.migrateConnector.NewWithSourceInstance("iofs", embedded, dsn); err != nil {
+			return fmt.Errorf("migration init failed: %w", err)
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(migrationsPath, "/") {
+		migrationsPath = "/" + migrationsPath
+	}
+
+	if _, err := s.migrateConnector.New("file://"+migrationsPath, dsn); err != nil {
+		return fmt.Errorf("migration init failed: %w", err)
 	}
 
 	return nil
@@ -168,8 +389,10 @@ func (s *Storage) SaveRate(
 
 	start := time.Now()
 
-	const query = `INSERT INTO rates(ask, bid, ask_amount, bid_amount, timestamp)
-                   VALUES($1, $2, $3, $4, $5)`
+	// Курс с той же парой, источником и временем уже сохранен - повтор пропускается
+	const query = `INSERT INTO rates(ask, bid, ask_amount, bid_amount, timestamp, symbol, source)
+                   VALUES($1, $2, $3, $4, $5, $6, $7)
+                   ON CONFLICT (symbol, source, timestamp) DO NOTHING`
 
 	tr := otel.GetTracerProvider().Tracer("storage-postgres")
 	ctx, span := tr.Start(ctx, "SaveRate",
@@ -180,7 +403,16 @@ func (s *Storage) SaveRate(
 		))
 	defer span.End()
 
-	_, err := s.db.ExecContext(ctx, query, ask, bid, askAmount, bidAmount, ts)
+	err := s.withOutbox(ctx, []models.Rate{{
+		Ask:       ask,
+		Bid:       bid,
+		AskAmount: askAmount,
+		BidAmount: bidAmount,
+		Time:      ts,
+	}}, func(exec execer) error {
+		_, err := exec.ExecContext(ctx, query, ask, bid, askAmount, bidAmount, ts, s.symbol, s.source)
+		return err
+	})
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "save rate failed")
@@ -199,6 +431,152 @@ func (s *Storage) SaveRate(
 	return nil
 }
 
+// SaveRates сохраняет пачку курсов одним многострочным INSERT
+func (s *Storage) SaveRates(ctx context.Context, rates []models.Rate) error {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO rates(ask, bid, ask_amount, bid_amount, timestamp, symbol, source) VALUES ")
+	args := make([]interface{}, 0, len(rates)*rateInsertColumns)
+	for i, rate := range rates {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * rateInsertColumns
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+		args = append(args, rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount, rate.Time, s.symbol, s.source)
+	}
+	// Повторно сохраненные курсы (например, при воспроизведении журнала) пропускаются
+	query.WriteString(" ON CONFLICT (symbol, source, timestamp) DO NOTHING")
+
+	tr := otel.GetTracerProvider().Tracer("storage-postgres")
+	ctx, span := tr.Start(ctx, "SaveRates",
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			attribute.String("db.operation", "INSERT"),
+			attribute.Int("db.rows", len(rates)),
+		))
+	defer span.End()
+
+	err := s.withOutbox(ctx, rates, func(exec execer) error {
+		_, err := exec.ExecContext(ctx, query.String(), args...)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "save rates failed")
+		return fmt.Errorf("save rates failed: %w", err)
+	}
+
+	metrics.DBSaves.Add(float64(len(rates)))
+	metrics.DBSaveLatency.Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+// GetRates возвращает курсы за период в порядке возрастания времени (убывания при q.Descending)
+func (s *Storage) GetRates(ctx context.Context, q RateQuery) ([]models.Rate, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	if q.Symbol == "" {
+		q.Symbol = s.symbol
+	}
+	if q.Source == "" {
+		q.Source = s.source
+	}
+	query, args := buildRatesQuery(q, postgresDialect)
+
+	tr := otel.GetTracerProvider().Tracer("storage-postgres")
+	ctx, span := tr.Start(ctx, "GetRates",
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			attribute.String("db.operation", "SELECT"),
+			attribute.String("db.statement", query),
+		))
+	defer span.End()
+
+	rates, err := s.queryRates(ctx, query, args...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "get rates failed")
+		return nil, fmt.Errorf("get rates failed: %w", err)
+	}
+	return rates, nil
+}
+
+// LatestRate возвращает последний сохраненный курс
+func (s *Storage) LatestRate(ctx context.Context) (models.Rate, error) {
+	if s.db == nil {
+		return models.Rate{}, fmt.Errorf("database connection is nil")
+	}
+
+	const query = `SELECT ask, bid, ask_amount, bid_amount, timestamp FROM rates
+                   WHERE symbol = $1 AND source = $2 ORDER BY timestamp DESC LIMIT 1`
+
+	rates, err := s.queryRates(ctx, query, s.symbol, s.source)
+	if err != nil {
+		return models.Rate{}, fmt.Errorf("get latest rate failed: %w", err)
+	}
+	if len(rates) == 0 {
+		return models.Rate{}, ErrNotFound
+	}
+	return rates[0], nil
+}
+
+// NearestRate возвращает курс, ближайший по времени к at
+func (s *Storage) NearestRate(ctx context.Context, at time.Time) (models.Rate, error) {
+	if s.db == nil {
+		return models.Rate{}, fmt.Errorf("database connection is nil")
+	}
+
+	query, args := buildNearestRateQuery(at, s.symbol, s.source, postgresDialect)
+	rates, err := s.queryRates(ctx, query, args...)
+	if err != nil {
+		return models.Rate{}, fmt.Errorf("get nearest rate failed: %w", err)
+	}
+	return nearestRate(rates, at)
+}
+
+func (s *Storage) queryRates(ctx context.Context, query string, args ...interface{}) ([]models.Rate, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var rates []models.Rate
+	for rows.Next() {
+		var rate models.Rate
+		if err := rows.Scan(&rate.Ask, &rate.Bid, &rate.AskAmount, &rate.BidAmount, &rate.Time); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+// Ping проверяет доступность базы данных
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := pingContext(ctx, s.db); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
 func (s *Storage) Close() error {
 	if err := s.db.Close(); err != nil {
 		return fmt.Errorf("database close failed: %w", err)
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gRPC-USDT/internal/models"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Поддерживаемые форматы выгрузки во временной ряд
+const (
+	TSDBFormatInflux      = "influx"
+	TSDBFormatRemoteWrite = "remote_write"
+)
+
+// errBodyLimit сколько байт ответа TSDB попадает в текст ошибки
+const errBodyLimit = 512
+
+// TSDBConfig настройки выгрузки курсов во временную БД
+type TSDBConfig struct {
+	Format      string            // TSDBFormatInflux или TSDBFormatRemoteWrite
+	URL         string            // Адрес записи, например http://influx:8086/api/v2/write?org=o&bucket=b&precision=ns
+	Token       string            // Токен авторизации, пустой - без авторизации
+	Measurement string            // Имя измерения (префикс метрик для remote-write)
+	Tags        map[string]string // Постоянные теги/метки каждой точки
+	Timeout     time.Duration     // Таймаут одного запроса
+}
+
+// TSDBSink отправляет курсы во временную БД по HTTP.
+// Реализует RateWriter, поэтому может стоять рядом с Postgres в FanOutStorage.
+type TSDBSink struct {
+	cfg    TSDBConfig
+	client *http.Client
+	encode func(rates []models.Rate) ([]byte, error)
+	header http.Header
+}
+
+// NewTSDBSink создает приемник в заданном формате
+func NewTSDBSink(cfg TSDBConfig, client *http.Client) (*TSDBSink, error) {
+	if strings.TrimSpace(cfg.URL) == "" {
+		return nil, errors.New("tsdb url cannot be empty")
+	}
+	if cfg.Measurement == "" {
+		cfg.Measurement = "usdt_rate"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	sink := &TSDBSink{cfg: cfg, client: client, header: http.Header{}}
+
+	switch cfg.Format {
+	case TSDBFormatInflux:
+		sink.encode = sink.encodeLineProtocol
+		sink.header.Set("Content-Type", "text/plain; charset=utf-8")
+		if cfg.Token != "" {
+			sink.header.Set("Authorization", "Token "+cfg.Token)
+		}
+	case TSDBFormatRemoteWrite:
+		sink.encode = sink.encodeRemoteWrite
+		sink.header.Set("Content-Type", "application/x-protobuf")
+		sink.header.Set("Content-Encoding", "snappy")
+		sink.header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		if cfg.Token != "" {
+			sink.header.Set("Authorization", "Bearer "+cfg.Token)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tsdb format %q", cfg.Format)
+	}
+
+	return sink, nil
+}
+
+func (t *TSDBSink) SaveRate(
+	ctx context.Context,
+	ask, bid, askAmount, bidAmount float64,
+	ts time.Time,
+) error {
+	return t.SaveRates(ctx, []models.Rate{{
+		Ask:       ask,
+		Bid:       bid,
+		AskAmount: askAmount,
+		BidAmount: bidAmount,
+		Time:      ts,
+	}})
+}
+
+// SaveRates отправляет пачку курсов одним запросом
+func (t *TSDBSink) SaveRates(ctx context.Context, rates []models.Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	body, err := t.encode(rates)
+	if err != nil {
+		return fmt.Errorf("encode rates failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header = t.header.Clone()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tsdb write failed: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, errBodyLimit))
+		return fmt.Errorf("tsdb returned status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// rateFieldNames имена полей курса в порядке выгрузки
+var rateFieldNames = [...]string{"ask", "bid", "ask_amount", "bid_amount"}
+
+// rateFieldValues значения полей курса в порядке rateFieldNames
+func rateFieldValues(rate models.Rate) [len(rateFieldNames)]float64 {
+	return [...]float64{rate.Ask, rate.Bid, rate.AskAmount, rate.BidAmount}
+}
+
+// sortedTags возвращает ключи тегов по алфавиту: так рекомендует InfluxDB и требует remote-write
+func (t *TSDBSink) sortedTags() []string {
+	keys := make([]string, 0, len(t.cfg.Tags))
+	for k := range t.cfg.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeLineProtocol кодирует курсы в InfluxDB line protocol с наносекундной точностью:
+// usdt_rate,symbol=BTCUSDT ask=1,bid=2,ask_amount=3,bid_amount=4 1700000000000000000
+func (t *TSDBSink) encodeLineProtocol(rates []models.Rate) ([]byte, error) {
+	measurement := influxEscaper.Replace(t.cfg.Measurement)
+	var tags strings.Builder
+	for _, k := range t.sortedTags() {
+		tags.WriteString("," + influxTagEscaper.Replace(k) + "=" + influxTagEscaper.Replace(t.cfg.Tags[k]))
+	}
+
+	var buf bytes.Buffer
+	for _, rate := range rates {
+		buf.WriteString(measurement)
+		buf.WriteString(tags.String())
+		for i, value := range rateFieldValues(rate) {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, fmt.Errorf("field %s is not a finite number", rateFieldNames[i])
+			}
+			if i == 0 {
+				buf.WriteByte(' ')
+			} else {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(rateFieldNames[i] + "=" + strconv.FormatFloat(value, 'f', -1, 64))
+		}
+		buf.WriteString(" " + strconv.FormatInt(rate.Time.UnixNano(), 10) + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	influxEscaper    = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// encodeRemoteWrite кодирует курсы в prometheus.WriteRequest (protobuf + snappy).
+// Каждое поле курса - отдельный ряд <measurement>_<field> с миллисекундными отметками.
+func (t *TSDBSink) encodeRemoteWrite(rates []models.Rate) ([]byte, error) {
+	tagKeys := t.sortedTags()
+
+	var request []byte
+	for i, name := range rateFieldNames {
+		var series []byte
+
+		// Метки должны быть отсортированы по имени, а "__name__" идет раньше букв
+		series = appendLabel(series, "__name__", t.cfg.Measurement+"_"+name)
+		for _, k := range tagKeys {
+			series = appendLabel(series, k, t.cfg.Tags[k])
+		}
+
+		for _, rate := range rates {
+			value := rateFieldValues(rate)[i]
+
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(rate.Time.UnixMilli()))
+
+			series = protowire.AppendTag(series, 2, protowire.BytesType)
+			series = protowire.AppendBytes(series, sample)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, series)
+	}
+
+	return snappy.Encode(nil, request), nil
+}
+
+func appendLabel(series []byte, name, value string) []byte {
+	var label []byte
+	label = protowire.AppendTag(label, 1, protowire.BytesType)
+	label = protowire.AppendString(label, name)
+	label = protowire.AppendTag(label, 2, protowire.BytesType)
+	label = protowire.AppendString(label, value)
+
+	series = protowire.AppendTag(series, 1, protowire.BytesType)
+	return protowire.AppendBytes(series, label)
+}
+
+// ParseTSDBTags разбирает теги из строки вида "symbol=BTCUSDT,source=binance"
+func ParseTSDBTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return tags, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid tsdb tag %q, expected key=value", pair)
+		}
+		tags[k] = v
+	}
+	return tags, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"gRPC-USDT/internal/models"
+)
+
+// tsdbStub - локальная заглушка TSDB, запоминающая последний запрос
+type tsdbStub struct {
+	server *httptest.Server
+	header http.Header
+	body   []byte
+	status int
+}
+
+func newTSDBStub(t *testing.T, status int) *tsdbStub {
+	stub := &tsdbStub{status: status}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.header = r.Header.Clone()
+		stub.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(stub.status)
+		_, _ = w.Write([]byte("stub response"))
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func TestTSDBSink_InfluxLineProtocol(t *testing.T) {
+	stub := newTSDBStub(t, http.StatusNoContent)
+
+	sink, err := NewTSDBSink(TSDBConfig{
+		Format:      TSDBFormatInflux,
+		URL:         stub.server.URL,
+		Token:       "secret",
+		Measurement: "usdt rate",
+		Tags:        map[string]string{"symbol": "BTCUSDT", "source": "binance,spot"},
+	}, nil)
+	require.NoError(t, err)
+
+	ts := time.Unix(1700000000, 5).UTC()
+	require.NoError(t, sink.SaveRates(context.Background(), []models.Rate{
+		{Ask: 100.5, Bid: 100.25, AskAmount: 1, BidAmount: 2, Time: ts},
+		{Ask: 101, Bid: 100, AskAmount: 0.5, BidAmount: 3, Time: ts.Add(time.Second)},
+	}))
+
+	assert.Equal(t, "Token secret", stub.header.Get("Authorization"))
+	assert.Equal(t,
+		`usdt\ rate,source=binance\,spot,symbol=BTCUSDT ask=100.5,bid=100.25,ask_amount=1,bid_amount=2 1700000000000000005`+"\n"+
+			`usdt\ rate,source=binance\,spot,symbol=BTCUSDT ask=101,bid=100,ask_amount=0.5,bid_amount=3 1700000001000000005`+"\n",
+		string(stub.body))
+}
+
+func TestTSDBSink_InfluxRejectsNaN(t *testing.T) {
+	stub := newTSDBStub(t, http.StatusNoContent)
+	sink, err := NewTSDBSink(TSDBConfig{Format: TSDBFormatInflux, URL: stub.server.URL}, nil)
+	require.NoError(t, err)
+
+	err = sink.SaveRate(context.Background(), math.NaN(), 1, 1, 1, time.Now())
+	assert.ErrorContains(t, err, "not a finite number")
+	assert.Nil(t, stub.body)
+}
+
+// remoteWriteSeries разобранный ряд из WriteRequest
+type remoteWriteSeries struct {
+	labels map[string]string
+	values []float64
+	stamps []int64
+}
+
+func decodeRemoteWrite(t *testing.T, body []byte) []remoteWriteSeries {
+	raw, err := snappy.Decode(nil, body)
+	require.NoError(t, err)
+
+	var result []remoteWriteSeries
+	for len(raw) > 0 {
+		num, _, n := protowire.ConsumeTag(raw)
+		require.Equal(t, protowire.Number(1), num)
+		raw = raw[n:]
+		seriesBytes, n := protowire.ConsumeBytes(raw)
+		raw = raw[n:]
+
+		series := remoteWriteSeries{labels: map[string]string{}}
+		for len(seriesBytes) > 0 {
+			num, _, n := protowire.ConsumeTag(seriesBytes)
+			seriesBytes = seriesBytes[n:]
+			msg, n := protowire.ConsumeBytes(seriesBytes)
+			seriesBytes = seriesBytes[n:]
+
+			switch num {
+			case 1:
+				_, _, n := protowire.ConsumeTag(msg)
+				name, m := protowire.ConsumeString(msg[n:])
+				msg = msg[n+m:]
+				_, _, n = protowire.ConsumeTag(msg)
+				value, _ := protowire.ConsumeString(msg[n:])
+				series.labels[name] = value
+			case 2:
+				_, _, n := protowire.ConsumeTag(msg)
+				bits, m := protowire.ConsumeFixed64(msg[n:])
+				msg = msg[n+m:]
+				_, _, n = protowire.ConsumeTag(msg)
+				stamp, _ := protowire.ConsumeVarint(msg[n:])
+				series.values = append(series.values, math.Float64frombits(bits))
+				series.stamps = append(series.stamps, int64(stamp))
+			}
+		}
+		result = append(result, series)
+	}
+	return result
+}
+
+func TestTSDBSink_RemoteWrite(t *testing.T) {
+	stub := newTSDBStub(t, http.StatusOK)
+
+	sink, err := NewTSDBSink(TSDBConfig{
+		Format: TSDBFormatRemoteWrite,
+		URL:    stub.server.URL,
+		Token:  "secret",
+		Tags:   map[string]string{"symbol": "BTCUSDT"},
+	}, nil)
+	require.NoError(t, err)
+
+	ts := time.UnixMilli(1700000000123)
+	require.NoError(t, sink.SaveRate(context.Background(), 100.5, 100.25, 1, 2, ts))
+
+	assert.Equal(t, "snappy", stub.header.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", stub.header.Get("Content-Type"))
+	assert.Equal(t, "Bearer secret", stub.header.Get("Authorization"))
+
+	series := decodeRemoteWrite(t, stub.body)
+	require.Len(t, series, 4)
+
+	expected := map[string]float64{
+		"usdt_rate_ask":        100.5,
+		"usdt_rate_bid":        100.25,
+		"usdt_rate_ask_amount": 1,
+		"usdt_rate_bid_amount": 2,
+	}
+	for _, s := range series {
+		assert.Equal(t, "BTCUSDT", s.labels["symbol"])
+		assert.Equal(t, []float64{expected[s.labels["__name__"]]}, s.values, s.labels["__name__"])
+		assert.Equal(t, []int64{1700000000123}, s.stamps)
+	}
+}
+
+func TestTSDBSink_ErrorStatus(t *testing.T) {
+	stub := newTSDBStub(t, http.StatusBadRequest)
+	sink, err := NewTSDBSink(TSDBConfig{Format: TSDBFormatInflux, URL: stub.server.URL}, nil)
+	require.NoError(t, err)
+
+	err = sink.SaveRate(context.Background(), 1, 1, 1, 1, time.Now())
+	assert.ErrorContains(t, err, "400 Bad Request: stub response")
+}
+
+func TestNewTSDBSink_Validation(t *testing.T) {
+	_, err := NewTSDBSink(TSDBConfig{Format: TSDBFormatInflux}, nil)
+	assert.ErrorContains(t, err, "url cannot be empty")
+
+	_, err = NewTSDBSink(TSDBConfig{Format: "graphite", URL: "http://localhost"}, nil)
+	assert.ErrorContains(t, err, "unknown tsdb format")
+}
+
+func TestParseTSDBTags(t *testing.T) {
+	tags, err := ParseTSDBTags("symbol=BTCUSDT, source = binance")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"symbol": "BTCUSDT", "source": "binance"}, tags)
+
+	tags, err = ParseTSDBTags("")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	_, err = ParseTSDBTags("symbol")
+	assert.Error(t, err)
+}
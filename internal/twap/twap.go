@@ -0,0 +1,158 @@
+package twap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+// GapPolicy определяет обработку промежутков между курсами длиннее допустимого
+type GapPolicy int
+
+const (
+	// GapExclude учитывает курс не дольше MaxGap, остаток промежутка исключается из расчета
+	GapExclude GapPolicy = iota
+	// GapCarryForward считает курс действующим до следующего, независимо от длины промежутка
+	GapCarryForward
+	// GapFail отказывает в расчете при любом длинном промежутке
+	GapFail
+)
+
+var (
+	// ErrNoSamples возвращается, если в окне нет ни одного курса
+	ErrNoSamples = errors.New("no rates in window")
+	// ErrGap возвращается при политике GapFail, если в окне есть длинный промежуток
+	ErrGap = errors.New("window contains a gap")
+	// ErrInvalidWindow возвращается для пустого или слишком длинного окна
+	ErrInvalidWindow = errors.New("invalid window")
+)
+
+// History источник сохраненных курсов
+type History interface {
+	GetRates(ctx context.Context, q storage.RateQuery) ([]models.Rate, error)
+}
+
+// Gap промежуток без курсов длиннее допустимого
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Result итог расчета
+type Result struct {
+	TWAP    float64       // Средневзвешенная по времени средняя цена
+	Samples int           // Количество курсов в окне; перенесенный в окно более ранний курс не считается
+	Covered time.Duration // Суммарное время, учтенное в расчете
+	Gaps    []Gap
+}
+
+// Calculator рассчитывает TWAP средней цены по сохраненным курсам.
+// Каждый курс действует с момента получения до следующего курса (последний - до конца окна)
+// и входит в среднее с весом, равным этому времени. Последний курс до начала окна действует
+// с начала окна до первого курса в окне; если его нет, этот участок не учитывается.
+type Calculator struct {
+	history   History
+	maxGap    time.Duration
+	maxWindow time.Duration
+}
+
+// NewCalculator создает калькулятор.
+// maxGap - наибольший промежуток между курсами, который не считается пропуском данных (0 - без ограничения),
+// maxWindow - наибольшая длина окна (0 - без ограничения).
+func NewCalculator(history History, maxGap, maxWindow time.Duration) *Calculator {
+	return &Calculator{history: history, maxGap: maxGap, maxWindow: maxWindow}
+}
+
+// MaxGap возвращает наибольший промежуток между курсами, не считающийся пропуском данных
+func (c *Calculator) MaxGap() time.Duration {
+	return c.maxGap
+}
+
+// MaxWindow возвращает наибольшую длину окна, 0 - без ограничения
+func (c *Calculator) MaxWindow() time.Duration {
+	return c.maxWindow
+}
+
+// Calculate рассчитывает TWAP за окно [from, to)
+func (c *Calculator) Calculate(ctx context.Context, from, to time.Time, policy GapPolicy) (Result, error) {
+	if !from.Before(to) {
+		return Result{}, fmt.Errorf("%w: start %s is not before end %s", ErrInvalidWindow,
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	if c.maxWindow > 0 && to.Sub(from) > c.maxWindow {
+		return Result{}, fmt.Errorf("%w: %s exceeds maximum %s", ErrInvalidWindow, to.Sub(from), c.maxWindow)
+	}
+
+	previous, err := c.history.GetRates(ctx, storage.RateQuery{To: from, Limit: 1, Descending: true})
+	if err != nil {
+		return Result{}, fmt.Errorf("get rate before window: %w", err)
+	}
+	rates, err := c.history.GetRates(ctx, storage.RateQuery{From: from, To: to})
+	if err != nil {
+		return Result{}, fmt.Errorf("get rates: %w", err)
+	}
+	return Compute(append(previous, rates...), from, to, c.maxGap, policy)
+}
+
+// Compute рассчитывает TWAP по курсам окна [from, to), упорядоченным по времени.
+// Курсы до from учитываются с from; из них значение имеет только последний.
+func Compute(rates []models.Rate, from, to time.Time, maxGap time.Duration, policy GapPolicy) (Result, error) {
+	// Более ранние курсы до окна перекрыты последним из них
+	for len(rates) > 1 && !rates[1].Time.After(from) {
+		rates = rates[1:]
+	}
+	if len(rates) == 0 {
+		return Result{}, ErrNoSamples
+	}
+
+	result := Result{Samples: len(rates)}
+	if rates[0].Time.Before(from) {
+		result.Samples--
+	}
+	isGap := func(d time.Duration) bool {
+		return maxGap > 0 && d > maxGap
+	}
+
+	if lead := rates[0].Time.Sub(from); isGap(lead) {
+		result.Gaps = append(result.Gaps, Gap{Start: from, End: rates[0].Time})
+	}
+
+	var weighted float64
+	for i, rate := range rates {
+		start := rate.Time
+		if start.Before(from) {
+			start = from
+		}
+		end := to
+		if i+1 < len(rates) {
+			end = rates[i+1].Time
+		}
+		valid := end.Sub(start)
+
+		// Давность курса до окна тоже учитывается: устаревший курс не переносится в окно
+		if isGap(end.Sub(rate.Time)) {
+			result.Gaps = append(result.Gaps, Gap{Start: start, End: end})
+			if policy == GapExclude {
+				valid = max(0, rate.Time.Add(maxGap).Sub(start))
+			}
+		}
+
+		weighted += rate.Analytics().Mid * valid.Seconds()
+		result.Covered += valid
+	}
+
+	if policy == GapFail && len(result.Gaps) > 0 {
+		gap := result.Gaps[0]
+		return result, fmt.Errorf("%w: %s to %s", ErrGap, gap.Start.Format(time.RFC3339), gap.End.Format(time.RFC3339))
+	}
+	if result.Covered <= 0 {
+		return result, ErrNoSamples
+	}
+
+	result.TWAP = weighted / result.Covered.Seconds()
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package twap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/storage"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// sample строит курс со средней ценой mid через offset от начала окна
+func sample(mid float64, offset time.Duration) models.Rate {
+	return models.Rate{Ask: mid + 1, Bid: mid - 1, Time: base.Add(offset)}
+}
+
+func TestCompute(t *testing.T) {
+	t.Run("weights by validity", func(t *testing.T) {
+		// 100 действует 30 секунд, 200 - 10 секунд до конца окна
+		rates := []models.Rate{sample(100, 0), sample(200, 30*time.Second)}
+		result, err := Compute(rates, base, base.Add(40*time.Second), time.Minute, GapExclude)
+		require.NoError(t, err)
+		assert.InDelta(t, 125.0, result.TWAP, 1e-9)
+		assert.Equal(t, 2, result.Samples)
+		assert.Equal(t, 40*time.Second, result.Covered)
+		assert.Empty(t, result.Gaps)
+	})
+
+	t.Run("leading interval is not covered", func(t *testing.T) {
+		rates := []models.Rate{sample(100, 10*time.Second)}
+		result, err := Compute(rates, base, base.Add(20*time.Second), time.Minute, GapExclude)
+		require.NoError(t, err)
+		assert.InDelta(t, 100.0, result.TWAP, 1e-9)
+		assert.Equal(t, 10*time.Second, result.Covered)
+	})
+
+	t.Run("rate before window is carried in", func(t *testing.T) {
+		// 50 действует до начала окна и 10 секунд в окне, более ранний курс перекрыт им
+		rates := []models.Rate{sample(10, -time.Minute), sample(50, -30*time.Second), sample(100, 10*time.Second)}
+		result, err := Compute(rates, base, base.Add(20*time.Second), time.Minute, GapExclude)
+		require.NoError(t, err)
+		assert.InDelta(t, 75.0, result.TWAP, 1e-9)
+		assert.Equal(t, 1, result.Samples)
+		assert.Equal(t, 20*time.Second, result.Covered)
+		assert.Empty(t, result.Gaps)
+	})
+
+	t.Run("stale rate before window", func(t *testing.T) {
+		// 50 получен за 50 секунд до окна и при лимите в минуту действует только 10 секунд окна
+		rates := []models.Rate{sample(50, -50*time.Second), sample(100, 30*time.Second)}
+		result, err := Compute(rates, base, base.Add(40*time.Second), time.Minute, GapExclude)
+		require.NoError(t, err)
+		assert.InDelta(t, 75.0, result.TWAP, 1e-9)
+		assert.Equal(t, 20*time.Second, result.Covered)
+		assert.Equal(t, []Gap{{Start: base, End: base.Add(30 * time.Second)}}, result.Gaps)
+	})
+
+	rates := []models.Rate{sample(100, 0), sample(200, 10*time.Minute), sample(300, 11*time.Minute)}
+	to := base.Add(12 * time.Minute)
+
+	t.Run("exclude gap", func(t *testing.T) {
+		// 100 учитывается только минуту из десяти
+		result, err := Compute(rates, base, to, time.Minute, GapExclude)
+		require.NoError(t, err)
+		assert.InDelta(t, 200.0, result.TWAP, 1e-9)
+		assert.Equal(t, 3*time.Minute, result.Covered)
+		assert.Equal(t, []Gap{{Start: base, End: base.Add(10 * time.Minute)}}, result.Gaps)
+	})
+
+	t.Run("carry forward gap", func(t *testing.T) {
+		result, err := Compute(rates, base, to, time.Minute, GapCarryForward)
+		require.NoError(t, err)
+		assert.InDelta(t, (100*10+200+300)/12.0, result.TWAP, 1e-9)
+		assert.Equal(t, 12*time.Minute, result.Covered)
+		assert.Len(t, result.Gaps, 1)
+	})
+
+	t.Run("fail on gap", func(t *testing.T) {
+		result, err := Compute(rates, base, to, time.Minute, GapFail)
+		assert.ErrorIs(t, err, ErrGap)
+		assert.Len(t, result.Gaps, 1)
+	})
+
+	t.Run("leading gap is reported", func(t *testing.T) {
+		result, err := Compute([]models.Rate{sample(100, 5*time.Minute)}, base, base.Add(6*time.Minute),
+			time.Minute, GapExclude)
+		require.NoError(t, err)
+		assert.Equal(t, []Gap{{Start: base, End: base.Add(5 * time.Minute)}}, result.Gaps)
+	})
+
+	t.Run("no limit on gaps", func(t *testing.T) {
+		result, err := Compute(rates, base, to, 0, GapFail)
+		require.NoError(t, err)
+		assert.Empty(t, result.Gaps)
+	})
+
+	t.Run("no samples", func(t *testing.T) {
+		_, err := Compute(nil, base, to, time.Minute, GapExclude)
+		assert.ErrorIs(t, err, ErrNoSamples)
+	})
+}
+
+// failingHistory всегда возвращает ошибку хранилища
+type failingHistory struct{}
+
+func (failingHistory) GetRates(context.Context, storage.RateQuery) ([]models.Rate, error) {
+	return nil, errors.New("db down")
+}
+
+func TestCalculator_Calculate(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage(0)
+	require.NoError(t, store.SaveRates(ctx, []models.Rate{
+		sample(50, -time.Minute), // До окна, перекрыт следующим
+		sample(80, -10*time.Second),
+		sample(100, 20*time.Second),
+		sample(200, 40*time.Second),
+		sample(400, 2*time.Minute), // После окна, не учитывается
+	}))
+	c := NewCalculator(store, time.Minute, time.Hour)
+
+	// 80 действует с начала окна до первого курса в нем
+	result, err := c.Calculate(ctx, base, base.Add(time.Minute), GapExclude)
+	require.NoError(t, err)
+	assert.InDelta(t, (80*20+100*20+200*20)/60.0, result.TWAP, 1e-9)
+	assert.Equal(t, 2, result.Samples)
+	assert.Equal(t, time.Minute, result.Covered)
+
+	_, err = c.Calculate(ctx, base, base, GapExclude)
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+	_, err = c.Calculate(ctx, base, base.Add(2*time.Hour), GapExclude)
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+
+	_, err = NewCalculator(failingHistory{}, 0, 0).Calculate(ctx, base, base.Add(time.Minute), GapExclude)
+	assert.ErrorContains(t, err, "db down")
+}
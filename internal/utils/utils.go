@@ -1,91 +1,737 @@
 package utils
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"gRPC-USDT/api/proto"
+	"gRPC-USDT/internal/alerts"
+	"gRPC-USDT/internal/anomaly"
 	"gRPC-USDT/internal/config"
+	"gRPC-USDT/internal/convert"
+	"gRPC-USDT/internal/export"
+	"gRPC-USDT/internal/lifecycle"
+	"gRPC-USDT/internal/metrics"
+	"gRPC-USDT/internal/models"
+	"gRPC-USDT/internal/outbox"
+	"gRPC-USDT/internal/probes"
+	"gRPC-USDT/internal/reload"
+	"gRPC-USDT/internal/secrets"
 	"gRPC-USDT/internal/service"
 	"gRPC-USDT/internal/storage"
+	"gRPC-USDT/internal/twap"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strconv"
 	"syscall"
 	"time"
 
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
 	health "google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/reflection"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
-type HealthService struct{}
+// Version версия приложения, задается при сборке:
+// go build -ldflags "-X gRPC-USDT/internal/utils.Version=v1.2.3"
+var Version = "dev"
 
-func (s *HealthService) Check(context.Context, *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
-	return &health.HealthCheckResponse{Status: health.HealthCheckResponse_SERVING}, nil
+// VersionString возвращает версию, ревизию исходников (если известна) и версию Go
+func VersionString() string {
+	revision := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 7 {
+				revision = " (" + s.Value[:7] + ")"
+			}
+		}
+	}
+	return fmt.Sprintf("gRPC-USDT %s%s %s", Version, revision, runtime.Version())
 }
 
-func (s *HealthService) Watch(*health.HealthCheckRequest, health.Health_WatchServer) error {
-	return status.Error(codes.Unimplemented, "unimplemented")
+// SetupLogger создает логгер с изменяемым уровнем: уровень задается после загрузки конфигурации
+// и меняется при ее перезагрузке
+func SetupLogger() (*zap.Logger, zap.AtomicLevel, error) {
+	zapCfg := zap.NewProductionConfig()
+	logger, err := zapCfg.Build()
+	return logger, zapCfg.Level, err
 }
 
-func SetupLogger() (*zap.Logger, error) {
-	return zap.NewProduction()
+func LoadConfig(logger *zap.Logger, flags *flag.FlagSet) (*config.Config, error) {
+	cfg, err := config.LoadConfig(logger, flags)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
 }
 
-func LoadConfig(logger *zap.Logger, flags *flag.FlagSet) *config.Config {
-	cfg := config.LoadConfig(logger, flags)
-	return &cfg
+// ShowConfig печатает итоговую конфигурацию с источниками значений, секреты скрываются.
+// Ошибки проверки печатаются после таблицы и возвращаются.
+func ShowConfig(w io.Writer, flags *flag.FlagSet) error {
+	_ = godotenv.Load()
+
+	_, effective, err := config.Load(flags)
+	if effective != nil {
+		_, _ = fmt.Fprint(w, effective)
+	}
+	return err
 }
 
-func CreateStorage(cfg *config.Config) (*storage.Storage, error) {
-	dataSourceName := "postgres://" + cfg.DBUser + ":" + cfg.DBPassword + "@" + cfg.DBHost + ":" + strconv.Itoa(cfg.DBPort) + "/" + cfg.DBName + "?sslmode=disable"
+// CreateStorage создает хранилище курсов выбранного в конфигурации бэкенда
+func CreateStorage(cfg *config.Config) (storage.Interface, error) {
+	switch cfg.StorageBackend {
+	case config.StorageBackendPostgres:
+		return createPostgresStorage(cfg)
+	case config.StorageBackendSQLite:
+		return storage.NewSQLiteStorage(cfg.SQLitePath)
+	case config.StorageBackendMemory:
+		return storage.NewMemoryStorage(cfg.MemoryMaxRates), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+func createPostgresStorage(cfg *config.Config) (*storage.Storage, error) {
+	creds, err := dbCredentials(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	dataSourceName, err := postgresDSN(cfg, creds)
+	if err != nil {
+		return nil, err
+	}
 
-	dbConnector := &storage.DefaultDatabaseConnector{}
+	poolConfig := storage.PoolConfig{
+		MaxConns:               int32(cfg.DBMaxConns),
+		MinConns:               int32(cfg.DBMinConns),
+		MaxConnLifetime:        cfg.DBMaxConnLifetime,
+		MaxConnIdleTime:        cfg.DBMaxConnIdleTime,
+		StatementCacheCapacity: cfg.DBStatementCacheCapacity,
+		QueryExecMode:          cfg.DBQueryExecMode,
+	}
+	// Пул пересоздается при смене учетных данных без перезапуска сервиса
+	dbConnector := storage.NewRotatingConnector(func() storage.DatabaseConnector {
+		return storage.NewPgxPoolConnector(poolConfig)
+	})
 	migrateConnector := &storage.DefaultMigrateConnector{}
 
 	store, err := storage.NewStorage(dataSourceName, dbConnector, migrateConnector)
 	if err != nil {
+		_ = dbConnector.Close()
 		return nil, err
 	}
+	store.SetSymbol(convert.Market{Base: cfg.BaseAsset, Quote: cfg.QuoteAsset}.Symbol(), storage.DefaultSource)
+	store.SetRatesPartitioning(cfg.DBRatesPartitioning)
+
+	// Метрики пула регистрируются один раз на процесс
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err := metrics.RegisterDBPool(dbConnector.Stats); err != nil && !errors.As(err, &alreadyRegistered) {
+		_ = store.Close()
+		return nil, fmt.Errorf("register pool metrics: %w", err)
+	}
 
 	return store, nil
 }
 
-func ApplyMigrations(store *storage.Storage, cfg *config.Config, logger *zap.Logger) error {
+// postgresDSN строит строку подключения из DATABASE_URL или параметров DB_*; учетные данные экранируются
+func postgresDSN(cfg *config.Config, creds secrets.Credentials) (string, error) {
+	conn := storage.ConnConfig{
+		URL:             cfg.DatabaseURL,
+		Host:            cfg.DBHost,
+		Port:            cfg.DBPort,
+		Name:            cfg.DBName,
+		User:            creds.User,
+		Password:        creds.Password,
+		SSLMode:         cfg.DBSSLMode,
+		SSLRootCert:     cfg.DBSSLRootCert,
+		SSLCert:         cfg.DBSSLCert,
+		SSLKey:          cfg.DBSSLKey,
+		SearchPath:      cfg.DBSearchPath,
+		ApplicationName: cfg.DBApplicationName,
+	}
+	return conn.DSN()
+}
+
+// CreateSecretProvider создает клиент хранилища секретов; без SECRETS_PROVIDER возвращает nil
+func CreateSecretProvider(cfg *config.Config) (secrets.Provider, error) {
+	switch cfg.SecretsProvider {
+	case "":
+		// Учетные данные из DB_USER_FILE и DB_PASSWORD_FILE перечитываются, чтобы ротация файла не требовала перезапуска
+		if files := credentialFiles(); len(files) > 0 {
+			return secrets.NewFileProvider(files), nil
+		}
+		return nil, nil
+	case config.SecretsProviderVault:
+		return secrets.NewVaultKV(cfg.VaultAddr, cfg.VaultToken, cfg.VaultMount, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", cfg.SecretsProvider)
+	}
+}
+
+// credentialFiles возвращает файлы учетных данных БД, заданные переменными с суффиксом _FILE
+func credentialFiles() map[string]string {
+	files := make(map[string]string)
+	for field, env := range map[string]string{secrets.FieldUsername: "DB_USER", secrets.FieldPassword: "DB_PASSWORD"} {
+		if path := os.Getenv(env + config.FileEnvSuffix); path != "" {
+			files[field] = path
+		}
+	}
+	return files
+}
+
+// dbCredentials возвращает учетные данные БД из хранилища секретов или из конфигурации
+func dbCredentials(ctx context.Context, cfg *config.Config) (secrets.Credentials, error) {
+	fallback := secrets.Credentials{User: cfg.DBUser, Password: cfg.DBPassword}
+	provider, err := CreateSecretProvider(cfg)
+	if err != nil || provider == nil {
+		return fallback, err
+	}
+	return secrets.DBCredentials(ctx, provider, cfg.VaultDBSecretPath, fallback)
+}
+
+// CreateCredentialRotator создает Rotator, пересоздающий пул Postgres при смене учетных данных
+// в хранилище секретов, в файлах *_FILE или в перезагруженной конфигурации. Для других бэкендов
+// без хранилища секретов возвращает nil.
+func CreateCredentialRotator(store storage.Interface, logger *zap.Logger, cfg *config.Config) (*secrets.Rotator, error) {
+	provider, err := CreateSecretProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pg, ok := store.(*storage.Storage)
+	if !ok {
+		if cfg.SecretsProvider == "" {
+			return nil, nil
+		}
+		return nil, errors.New("credential rotation requires postgres storage backend")
+	}
+
+	// Учетные данные перечитываются, чтобы первая проверка сравнивала с действующими
+	current, err := dbCredentials(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	fallback := secrets.Credentials{User: cfg.DBUser, Password: cfg.DBPassword}
+	return secrets.NewRotator(provider, cfg.VaultDBSecretPath, fallback, current, logger,
+		func(ctx context.Context, creds secrets.Credentials) error {
+			dsn, err := postgresDSN(cfg, creds)
+			if err != nil {
+				return err
+			}
+			return pg.Reconnect(ctx, dsn)
+		}), nil
+}
+
+// CreatePartitionMaintainer создает обслуживание месячных секций таблицы rates; без PARTITIONS_ENABLED возвращает nil
+func CreatePartitionMaintainer(
+	store storage.Interface,
+	logger *zap.Logger,
+	cfg *config.Config,
+) (*storage.PartitionMaintainer, error) {
+	if !cfg.PartitionsEnabled {
+		if cfg.DBRatesPartitioning {
+			logger.Warn("Rates table is partitioned but partition maintenance is disabled; " +
+				"rates after the last created month will go to rates_default")
+		}
+		return nil, nil
+	}
+	pg, ok := store.(*storage.Storage)
+	if !ok {
+		return nil, errors.New("partition maintenance requires postgres storage backend")
+	}
+	format, err := export.ParseFormat(cfg.PartitionsArchiveFormat)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewPartitionMaintainer(pg, storage.PartitionConfig{
+		Ahead:           cfg.PartitionsAhead,
+		RetentionMonths: cfg.PartitionsRetentionMonths,
+		RetentionAction: cfg.PartitionsRetentionAction,
+		ArchiveDir:      cfg.PartitionsArchiveDir,
+		ArchiveFormat:   format,
+		DryRun:          cfg.PartitionsDryRun,
+	}, logger.Named("partitions")), nil
+}
+
+func ApplyMigrations(store storage.Interface, cfg *config.Config, logger *zap.Logger) error {
+	// SQLite использует встроенные миграции, хранилищу в памяти они не нужны
+	if cfg.StorageBackend != config.StorageBackendPostgres {
+		logger.Info("Using built-in migrations", zap.String("backend", cfg.StorageBackend))
+		return store.Migrate("")
+	}
+
+	migrationsPath, err := resolveMigrationsPath(cfg, logger)
+	if err != nil {
+		return err
+	}
+	return store.Migrate(migrationsPath)
+}
+
+// resolveMigrationsPath возвращает каталог миграций Postgres; пустая строка - встроенные в бинарник миграции
+func resolveMigrationsPath(cfg *config.Config, logger *zap.Logger) (string, error) {
 	migrationsPath := cfg.MigrationsPath
+	if migrationsPath == "" {
+		logger.Info("Using embedded migrations")
+		return "", nil
+	}
 
 	// Если путь абсолютный, используем его как есть
 	if filepath.IsAbs(migrationsPath) {
 		logger.Info("Using absolute migrations path", zap.String("path", migrationsPath))
-	} else {
-		// Если путь относительный, пытаемся найти его относительно текущей директории
-		// Это может быть полезно для локального запуска
-		currentDir, err := os.Getwd()
+		return migrationsPath, nil
+	}
+
+	// Если путь относительный, пытаемся найти его относительно текущей директории
+	// Это может быть полезно для локального запуска
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	migrationsPath = filepath.Join(currentDir, migrationsPath)
+	logger.Info("Using relative migrations path", zap.String("path", migrationsPath))
+	return migrationsPath, nil
+}
+
+// Подкоманды бинарника
+const (
+	CommandServe   = "serve"
+	CommandMigrate = "migrate"
+	CommandExport  = "export"
+)
+
+// ParseCommand разбирает аргументы командной строки. Подкоманда может стоять перед флагами
+// (migrate -config c.yaml up) или после них (-config c.yaml migrate up); без подкоманды запускается serve.
+// У export свои флаги, поэтому общие флаги указываются только перед ней (-config c.yaml export -format jsonl).
+// Возвращает подкоманду и ее позиционные аргументы; ошибки, как и пакет flag, печатает вместе со справкой.
+func ParseCommand(fs *flag.FlagSet, args []string) (string, []string, error) {
+	command, rest, err := parseCommand(fs, args)
+	var parseErr commandError
+	if errors.As(err, &parseErr) {
+		_, _ = fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+	}
+	return command, rest, err
+}
+
+// commandError ошибка в подкоманде (ошибки флагов печатает сам пакет flag)
+type commandError struct{ msg string }
+
+func (e commandError) Error() string { return e.msg }
+
+func parseCommand(fs *flag.FlagSet, args []string) (string, []string, error) {
+	command := ""
+	if len(args) > 0 && args[0] == CommandExport {
+		if err := fs.Parse(nil); err != nil {
+			return "", nil, err
+		}
+		return CommandExport, args[1:], nil
+	}
+	if len(args) > 0 && (args[0] == CommandServe || args[0] == CommandMigrate) {
+		command, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", nil, err
+	}
+
+	rest := fs.Args()
+	if command == "" && len(rest) > 0 {
+		command, rest = rest[0], rest[1:]
+	}
+	switch command {
+	case "":
+		return CommandServe, nil, nil
+	case CommandServe:
+		if len(rest) > 0 {
+			return "", nil, commandError{fmt.Sprintf("serve: unexpected arguments %v", rest)}
+		}
+		return command, nil, nil
+	case CommandMigrate, CommandExport:
+		if name := setServeOnlyFlag(fs); name != "" {
+			return "", nil, commandError{fmt.Sprintf("%s: flag -%s is only supported by serve", command, name)}
+		}
+		return command, rest, nil
+	default:
+		return "", nil, commandError{fmt.Sprintf("unknown command %q", command)}
+	}
+}
+
+// serveOnlyFlags флаги, которые имеют смысл только для serve
+var serveOnlyFlags = map[string]bool{"skip-migrations": true}
+
+// setServeOnlyFlag возвращает имя заданного в командной строке флага, допустимого только для serve
+func setServeOnlyFlag(fs *flag.FlagSet) string {
+	name := ""
+	fs.Visit(func(f *flag.Flag) {
+		if name == "" && serveOnlyFlags[f.Name] {
+			name = f.Name
+		}
+	})
+	return name
+}
+
+// Migrator операции над схемой, доступные из подкоманды migrate
+type Migrator interface {
+	Migrate(migrationsPath string) error
+	MigrateSteps(migrationsPath string, n int) error
+	MigrateTo(migrationsPath string, version uint) error
+	MigrationVersion(migrationsPath string) (version uint, dirty bool, err error)
+	ForceMigration(migrationsPath string, version int) error
+}
+
+// MigrateUsage справка по подкоманде migrate
+const MigrateUsage = `Migrate commands:
+  migrate up          apply all pending migrations
+  migrate down N      roll back the last N migrations
+  migrate goto V      migrate up or down to version V
+  migrate version     print the current schema version
+  migrate force V     set version V without running migrations and clear the dirty flag
+`
+
+// RunMigrateCommand выполняет подкоманду migrate над схемой Postgres и печатает итоговую версию схемы в out
+func RunMigrateCommand(m Migrator, migrationsPath string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("migrate: command is required (up, down, goto, version, force)")
+	}
+
+	action, args := args[0], args[1:]
+	var wantArgs int
+	switch action {
+	case "up", "version":
+		wantArgs = 0
+	case "down", "goto", "force":
+		wantArgs = 1
+	default:
+		return fmt.Errorf("migrate: unknown command %q", action)
+	}
+	if len(args) != wantArgs {
+		return fmt.Errorf("migrate %s: expected %d argument(s), got %d", action, wantArgs, len(args))
+	}
+
+	var err error
+	switch action {
+	case "up":
+		err = m.Migrate(migrationsPath)
+	case "down":
+		var n int
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("migrate down: invalid number of migrations %q", args[0])
+		}
+		err = m.MigrateSteps(migrationsPath, -n)
+	case "goto":
+		var version uint64
+		version, err = strconv.ParseUint(args[0], 10, 0)
 		if err != nil {
-			return err
+			return fmt.Errorf("migrate goto: invalid version %q", args[0])
+		}
+		err = m.MigrateTo(migrationsPath, uint(version))
+	case "force":
+		// Версия -1 означает пустую схему, как в утилите migrate
+		var version int
+		version, err = strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("migrate force: invalid version %q", args[0])
 		}
-		migrationsPath = filepath.Join(currentDir, migrationsPath)
-		logger.Info("Using relative migrations path", zap.String("path", migrationsPath))
+		err = m.ForceMigration(migrationsPath, version)
+	}
+	if err != nil {
+		return err
 	}
 
-	return store.Migrate(migrationsPath)
+	version, dirty, err := m.MigrationVersion(migrationsPath)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		_, err = fmt.Fprintf(out, "version %d (dirty)\n", version)
+	} else {
+		_, err = fmt.Fprintf(out, "version %d\n", version)
+	}
+	return err
+}
+
+// RunMigrations подключается к Postgres и выполняет подкоманду migrate
+func RunMigrations(logger *zap.Logger, cfg *config.Config, args []string, out io.Writer) error {
+	if cfg.StorageBackend != config.StorageBackendPostgres {
+		return fmt.Errorf("migrate command requires %s storage backend", config.StorageBackendPostgres)
+	}
+	migrationsPath, err := resolveMigrationsPath(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	store, err := createPostgresStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	return RunMigrateCommand(store, migrationsPath, args, out)
+}
+
+// ExportUsage справка по подкоманде export
+const ExportUsage = `Export command (global flags go before it):
+  export [-from T] [-to T] [-symbol S] [-format csv|jsonl|parquet] [-output FILE]
+                      write rates for the period [from, to) to FILE or stdout
+`
+
+// RunExportCommand выполняет подкоманду export: выгружает курсы за период в файл или в stdout.
+// Без -to выгружаются курсы до текущего момента. Файл удаляется, если выгрузка не завершилась.
+func RunExportCommand(ctx context.Context, exporter service.RateExporter, args []string, stdout io.Writer) (err error) {
+	fs := flag.NewFlagSet(CommandExport, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	from := fs.String("from", "", "Start of the period (RFC3339, inclusive), empty - from the first rate")
+	to := fs.String("to", "", "End of the period (RFC3339, exclusive), empty - now")
+	symbol := fs.String("symbol", "", "Pair, e.g. BTCUSDT, empty - configured pair")
+	formatName := fs.String("format", string(export.FormatCSV), "Output format: csv, jsonl or parquet")
+	output := fs.String("output", "-", "Output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("export: unexpected arguments %v", fs.Args())
+	}
+
+	q := storage.ExportQuery{Symbol: *symbol, To: time.Now()}
+	if *from != "" {
+		if q.From, err = time.Parse(time.RFC3339, *from); err != nil {
+			return errors.New("export: -from must be RFC3339")
+		}
+	}
+	if *to != "" {
+		if q.To, err = time.Parse(time.RFC3339, *to); err != nil {
+			return errors.New("export: -to must be RFC3339")
+		}
+	}
+	if !q.From.IsZero() && !q.From.Before(q.To) {
+		return errors.New("export: -from must be before -to")
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	out := stdout
+	if *output != "-" {
+		file, createErr := os.Create(*output)
+		if createErr != nil {
+			return fmt.Errorf("export: %w", createErr)
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(file.Name())
+			}
+		}()
+		out = file
+	}
+
+	buf := bufio.NewWriter(out)
+	writer, err := export.NewWriter(buf, format)
+	if err != nil {
+		return err
+	}
+	if err := exporter.ExportRates(ctx, q, writer.Write); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	return buf.Flush()
 }
 
-func CreateRateService(store *storage.Storage, logger *zap.Logger, cfg *config.Config) proto.RateServiceServer {
+// RunExport подключается к Postgres и выполняет подкоманду export
+func RunExport(cfg *config.Config, args []string, out io.Writer) error {
+	if cfg.StorageBackend != config.StorageBackendPostgres {
+		return fmt.Errorf("export command requires %s storage backend", config.StorageBackendPostgres)
+	}
+
+	store, err := createPostgresStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	return RunExportCommand(context.Background(), store, args, out)
+}
+
+// CreateRateExporter возвращает выгрузку истории курсов; она использует серверный курсор
+// и доступна только для хранилища Postgres, для остальных возвращается nil
+func CreateRateExporter(store storage.Interface) service.RateExporter {
+	if pg, ok := store.(*storage.Storage); ok {
+		return pg
+	}
+	return nil
+}
+
+func CreateRateService(store service.RateStorage, logger *zap.Logger, cfg *config.Config) *service.RateService {
 	return service.NewRateService(store, logger, cfg, nil)
 }
 
-func StartServer(logger *zap.Logger, cfg *config.Config, rateService proto.RateServiceServer) (*grpc.Server, net.Listener, error) {
+// CreateSpoolingStorage оборачивает хранилище локальным журналом на время недоступности БД
+func CreateSpoolingStorage(store storage.RateWriter, logger *zap.Logger, cfg *config.Config) (*storage.SpoolingStorage, error) {
+	spool, err := storage.OpenSpool(cfg.SpoolDir, int64(cfg.SpoolMaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	if pending := spool.Len(); pending > 0 {
+		logger.Info("Found spooled rates from previous run", zap.Int("count", pending))
+	}
+	return storage.NewSpoolingStorage(store, spool, logger), nil
+}
+
+// CreateTSDBSink создает приемник выгрузки курсов во временную БД
+func CreateTSDBSink(cfg *config.Config) (*storage.TSDBSink, error) {
+	tags, err := storage.ParseTSDBTags(cfg.TSDBTags)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewTSDBSink(storage.TSDBConfig{
+		Format:      cfg.TSDBFormat,
+		URL:         cfg.TSDBURL,
+		Token:       cfg.TSDBToken,
+		Measurement: cfg.TSDBMeasurement,
+		Tags:        tags,
+		Timeout:     cfg.TSDBTimeout,
+	}, nil)
+}
+
+// CreateOutboxRelay включает запись событий в outbox и создает ретранслятор до получателя
+func CreateOutboxRelay(store storage.Interface, logger *zap.Logger, cfg *config.Config) (*outbox.Relay, error) {
+	pg, ok := store.(*storage.Storage)
+	if !ok {
+		return nil, errors.New("outbox requires postgres storage backend")
+	}
+
+	publisher, err := outbox.NewPublisher(cfg.OutboxPublisher, cfg.OutboxTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	pg.EnableOutbox(cfg.OutboxTopic)
+	return outbox.NewRelay(pg, publisher, logger, cfg.OutboxBatchSize), nil
+}
+
+// CreateAlerts создает движок и gRPC-сервис оповещений.
+// Правила хранятся в Postgres, для остальных бэкендов - в памяти процесса.
+func CreateAlerts(store storage.Interface, logger *zap.Logger, cfg *config.Config) (*alerts.Engine, *alerts.Server) {
+	var alertStore alerts.Store
+	if pg, ok := store.(*storage.Storage); ok {
+		alertStore = pg
+	} else {
+		logger.Warn("Alert rules are kept in memory and will be lost on restart",
+			zap.String("backend", cfg.StorageBackend))
+		alertStore = alerts.NewMemoryStore()
+	}
+
+	notifier := alerts.NewNotifier(alerts.NotifierConfig{
+		Secret:       cfg.AlertWebhookSecret,
+		MaxAttempts:  cfg.AlertMaxAttempts,
+		RetryBackoff: cfg.AlertRetryBackoff,
+		Timeout:      cfg.AlertWebhookTimeout,
+	}, nil)
+	engine := alerts.NewEngine(alertStore, notifier, logger, cfg.AlertQueueSize)
+
+	return engine, alerts.NewServer(alertStore, engine, logger)
+}
+
+// CreateAnomalyDetector создает проверку курсов перед сохранением.
+// Недавние курсы и карантин берутся из основного хранилища.
+func CreateAnomalyDetector(store storage.Interface, logger *zap.Logger, cfg *config.Config) *anomaly.Detector {
+	return anomaly.NewDetector(store, store, logger, anomalyConfig(cfg))
+}
+
+func anomalyConfig(cfg *config.Config) anomaly.Config {
+	return anomaly.Config{
+		Window:          cfg.AnomalyWindow,
+		MaxJumpPercent:  cfg.AnomalyMaxJumpPercent,
+		ZScoreThreshold: cfg.AnomalyZScore,
+		MinSamples:      cfg.AnomalyMinSamples,
+		Confirmations:   cfg.AnomalyConfirmations,
+	}
+}
+
+// CreateConverter создает конвертер по курсам отслеживаемой пары из основного хранилища
+func CreateConverter(store storage.Interface, cfg *config.Config) *convert.Converter {
+	return convert.NewConverter(convert.Market{Base: cfg.BaseAsset, Quote: cfg.QuoteAsset, Source: store})
+}
+
+// CreateTWAPCalculator создает расчет TWAP по курсам основного хранилища
+func CreateTWAPCalculator(store storage.Interface, cfg *config.Config) *twap.Calculator {
+	return twap.NewCalculator(store, cfg.TWAPMaxGap, cfg.TWAPMaxWindow)
+}
+
+// CreateBatchWriter создает буфер отложенной пакетной записи курсов
+func CreateBatchWriter(store storage.BatchSaver, logger *zap.Logger, cfg *config.Config) *storage.BatchWriter {
+	return storage.NewBatchWriter(store, logger, storage.BatchConfig{
+		BatchSize:      cfg.WriteBatchSize,
+		FlushInterval:  cfg.WriteFlushInterval,
+		QueueSize:      cfg.WriteQueueSize,
+		EnqueueTimeout: cfg.WriteEnqueueTimeout,
+	})
+}
+
+// GRPCService дополнительный gRPC-сервис, регистрируемый рядом с RateService
+type GRPCService struct {
+	Desc *grpc.ServiceDesc
+	Impl interface{}
+}
+
+// StartServer запускает gRPC сервер. Если передан status, сервис здоровья сообщает NOT_SERVING,
+// пока не выполнены все условия готовности.
+func StartServer(
+	logger *zap.Logger,
+	cfg *config.Config,
+	rateService proto.RateServiceServer,
+	status *probes.Status,
+	services ...GRPCService,
+) (*grpc.Server, net.Listener, error) {
 	grpcServer := grpc.NewServer()
 	proto.RegisterRateServiceServer(grpcServer, rateService)
-	health.RegisterHealthServer(grpcServer, &HealthService{})
+	for _, service := range services {
+		grpcServer.RegisterService(service.Desc, service.Impl)
+	}
+
+	healthServer := grpchealth.NewServer()
+	health.RegisterHealthServer(grpcServer, healthServer)
+	if status != nil {
+		status.OnChange(func(ready bool) {
+			servingStatus := health.HealthCheckResponse_NOT_SERVING
+			if ready {
+				servingStatus = health.HealthCheckResponse_SERVING
+			}
+			healthServer.SetServingStatus("", servingStatus)
+			healthServer.SetServingStatus(proto.RateService_ServiceDesc.ServiceName, servingStatus)
+			for _, service := range services {
+				healthServer.SetServingStatus(service.Desc.ServiceName, servingStatus)
+			}
+		})
+	}
+
+	if cfg.GRPCReflection {
+		if err := RegisterReflection(grpcServer); err != nil {
+			return nil, nil, err
+		}
+		logger.Info("gRPC server reflection enabled")
+	}
 
 	addr := fmt.Sprintf(":%d", cfg.GRPCPort)
 	lis, err := net.Listen("tcp", addr)
@@ -97,64 +743,148 @@ func StartServer(logger *zap.Logger, cfg *config.Config, rateService proto.RateS
 
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
-			logger.Fatal("Failed to serve", zap.Error(err))
+			logger.Error("Failed to serve", zap.Error(err))
+			if status != nil {
+				status.Fail(fmt.Errorf("grpc serve failed: %w", err))
+			}
 		}
 	}()
 
 	return grpcServer, lis, nil
 }
 
-func PerformHealthCheck(logger *zap.Logger, cfg *config.Config) error {
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	}
-	conn, err := grpc.NewClient("localhost:"+strconv.Itoa(cfg.GRPCPort), opts...)
+// RegisterReflection регистрирует gRPC reflection (v1 и v1alpha) поверх встроенного FileDescriptorSet
+func RegisterReflection(grpcServer *grpc.Server) error {
+	files, err := proto.DescriptorFiles()
 	if err != nil {
-		return err
+		return fmt.Errorf("load descriptor set: %w", err)
 	}
-	defer func(conn *grpc.ClientConn) {
-		_ = conn.Close()
-	}(conn)
 
-	healthClient := health.NewHealthClient(conn)
-	req := &health.HealthCheckRequest{}
-	resp, err := healthClient.Check(context.Background(), req)
-	if err != nil {
-		return err
+	opts := reflection.ServerOptions{
+		Services:           grpcServer,
+		DescriptorResolver: files,
+	}
+	v1reflectiongrpc.RegisterServerReflectionServer(grpcServer, reflection.NewServerV1(opts))
+	v1alphareflectiongrpc.RegisterServerReflectionServer(grpcServer, reflection.NewServer(opts))
+	return nil
+}
+
+// StartMetricsServer запускает HTTP сервер с метриками Prometheus и пробами /livez, /readyz
+func StartMetricsServer(logger *zap.Logger, cfg *config.Config, status *probes.Status) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.ExposeMetrics())
+	if status != nil {
+		mux.Handle("/livez", status.LivezHandler())
+		mux.Handle("/readyz", status.ReadyzHandler())
 	}
 
-	if resp.Status != health.HealthCheckResponse_SERVING {
-		return fmt.Errorf("server is not serving")
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.MetricsPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	logger.Info("Healthcheck passed")
-	return nil
+	go func() {
+		logger.Info("Metrics endpoint started on port", zap.Int("port", cfg.MetricsPort))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Error starting metrics server", zap.Error(err))
+		}
+	}()
+
+	return srv
 }
 
-func HandleSignals(logger *zap.Logger, grpcServer *grpc.Server, tp *tracesdk.TracerProvider) {
+// WatchDatabase периодически проверяет соединение с БД и обновляет условие готовности
+func WatchDatabase(ctx context.Context, store storage.Interface, status *probes.Status, cfg *config.Config) {
+	status.Watch(ctx, probes.Database, cfg.DBCheckInterval, store.Ping)
+}
+
+// ExchangeProbe получает курс от биржи, не сохраняя его
+type ExchangeProbe interface {
+	FetchRate(ctx context.Context) (models.Rate, error)
+}
+
+// WarmUpExchange повторяет запрос курса до первого успешного ответа и отмечает биржу готовой.
+// Курс только запрашивается: проверка готовности не должна записывать курсы в хранилище.
+// Интервал повтора читается перед каждой попыткой и может меняться перезагрузкой конфигурации.
+func WarmUpExchange(
+	ctx context.Context,
+	logger *zap.Logger,
+	exchange ExchangeProbe,
+	status *probes.Status,
+	retryInterval func() time.Duration,
+) {
+	for {
+		_, err := exchange.FetchRate(ctx)
+		if err == nil {
+			status.SetReady(probes.Exchange)
+			logger.Info("First exchange fetch succeeded")
+			return
+		}
+
+		interval := retryInterval()
+		status.SetNotReady(probes.Exchange, err)
+		logger.Warn("Exchange is not ready yet, retrying", zap.Error(err), zap.Duration("retry_in", interval))
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// CreateReloader создает Reloader, который перечитывает конфигурацию с теми же флагами
+// и применяет изменяемые без перезапуска параметры к сервису курсов, детектору выбросов, уровню логирования
+// и учетным данным БД, которые rotator применяет при следующей проверке
+func CreateReloader(
+	logger *zap.Logger,
+	level zap.AtomicLevel,
+	flags *flag.FlagSet,
+	cfg *config.Config,
+	rateService *service.RateService,
+	detector *anomaly.Detector,
+	rotator *secrets.Rotator,
+) *reload.Reloader {
+	reloader := reload.New(logger, func() (config.Config, error) {
+		c, _, err := config.Load(flags)
+		return c, err
+	}, *cfg)
+
+	reloader.Subscribe(reload.LogLevel(level))
+	reloader.Subscribe(func(c config.Config) {
+		rateService.SetBinanceAPIURL(c.BinanceAPIURL)
+	})
+	if detector != nil {
+		reloader.Subscribe(func(c config.Config) {
+			detector.SetConfig(anomalyConfig(&c))
+		})
+	}
+	if rotator != nil {
+		reloader.Subscribe(func(c config.Config) {
+			rotator.SetFallback(secrets.Credentials{User: c.DBUser, Password: c.DBPassword})
+		})
+	}
+	return reloader
+}
+
+// HandleSignals ожидает SIGINT/SIGTERM и останавливает компоненты через менеджер жизненного цикла
+func HandleSignals(logger *zap.Logger, manager *lifecycle.Manager, timeout time.Duration) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
 
 	sig := <-signals
 	logger.Info("Received signal, shutting down gracefully...", zap.String("signal", sig.String()))
 
-	// Закрываем провайдер трассировки
-	if err := tp.Shutdown(context.Background()); err != nil {
-		logger.Error("Error shutting down tracer provider", zap.Error(err))
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	go func() {
-		grpcServer.GracefulStop()
-	}()
-
-	select {
-	case <-ctx.Done():
-		logger.Warn("Shutdown timed out, forcing exit")
-	case <-time.After(3 * time.Second):
-		logger.Info("Server stopped gracefully")
+	if err := manager.Shutdown(ctx); err != nil {
+		logger.Warn("Shutdown completed with errors", zap.Error(err))
+		return
 	}
 
 	logger.Info("Server stopped")